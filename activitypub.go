@@ -0,0 +1,668 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/httpsig"
+	"github.com/codahale/yellhole-go/internal/markdown"
+	"github.com/google/uuid"
+)
+
+const (
+	activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	activityStreamsPublic  = "https://www.w3.org/ns/activitystreams#Public"
+	securityContext        = "https://w3id.org/security/v1"
+	activityJSONType       = "application/activity+json"
+
+	// maxActivitySize is the maximum size of an activity or actor document received from another server.
+	maxActivitySize = 1 << 20
+
+	// activityBatchSize is the maximum number of queued activities delivered at a time.
+	activityBatchSize = 20
+
+	// activityDeliveryTTL is how long delivered and failed activities are kept before being purged.
+	activityDeliveryTTL = 7 * 24 * time.Hour
+)
+
+// deliveryRetries is the retry policy for delivering activities.
+var deliveryRetries = retryPolicy{maxAttempts: 8, delay: 1 * time.Minute}
+
+// An actor is the ActivityPub identity of the yellhole instance.
+type actor struct {
+	username, name, summary string
+	baseURL                 *url.URL
+	key                     *rsa.PrivateKey
+}
+
+func (a *actor) id() string {
+	return a.baseURL.JoinPath("activitypub", "actor").String()
+}
+
+func (a *actor) keyID() string {
+	return a.id() + "#main-key"
+}
+
+func (a *actor) acct() string {
+	return "acct:" + a.username + "@" + a.baseURL.Host
+}
+
+func (a *actor) endpoint(name string) string {
+	return a.baseURL.JoinPath("activitypub", name).String()
+}
+
+// handleWebFinger responds to WebFinger queries for the instance's actor, per RFC 7033.
+func handleWebFinger(actor *actor) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if resource := r.FormValue("resource"); resource != actor.acct() && resource != actor.id() {
+			http.NotFound(w, r)
+			return nil
+		}
+
+		type link struct {
+			Rel  string `json:"rel"`
+			Type string `json:"type"`
+			Href string `json:"href"`
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return activityResponse(w, "application/jrd+json", struct {
+			Subject string   `json:"subject"`
+			Aliases []string `json:"aliases"`
+			Links   []link   `json:"links"`
+		}{
+			Subject: actor.acct(),
+			Aliases: []string{actor.id(), actor.baseURL.String()},
+			Links: []link{
+				{Rel: "self", Type: activityJSONType, Href: actor.id()},
+				{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: actor.baseURL.String()},
+			},
+		})
+	}
+}
+
+// handleActor renders the instance's actor document.
+func handleActor(actor *actor) appHandler {
+	return func(w http.ResponseWriter, _ *http.Request) error {
+		der, err := x509.MarshalPKIXPublicKey(&actor.key.PublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to marshal actor public key: %w", err)
+		}
+
+		type image struct {
+			Type      string `json:"type"`
+			MediaType string `json:"mediaType"`
+			URL       string `json:"url"`
+		}
+
+		type publicKey struct {
+			ID           string `json:"id"`
+			Owner        string `json:"owner"`
+			PublicKeyPem string `json:"publicKeyPem"`
+		}
+
+		return activityResponse(w, activityJSONType, struct {
+			Context                   []string  `json:"@context"`
+			ID                        string    `json:"id"`
+			Type                      string    `json:"type"`
+			PreferredUsername         string    `json:"preferredUsername"`
+			Name                      string    `json:"name"`
+			Summary                   string    `json:"summary"`
+			URL                       string    `json:"url"`
+			Inbox                     string    `json:"inbox"`
+			Outbox                    string    `json:"outbox"`
+			Followers                 string    `json:"followers"`
+			ManuallyApprovesFollowers bool      `json:"manuallyApprovesFollowers"`
+			Discoverable              bool      `json:"discoverable"`
+			Icon                      image     `json:"icon"`
+			PublicKey                 publicKey `json:"publicKey"`
+		}{
+			Context:           []string{activityStreamsContext, securityContext},
+			ID:                actor.id(),
+			Type:              "Person",
+			PreferredUsername: actor.username,
+			Name:              actor.name,
+			Summary:           actor.summary,
+			URL:               actor.baseURL.String(),
+			Inbox:             actor.endpoint("inbox"),
+			Outbox:            actor.endpoint("outbox"),
+			Followers:         actor.endpoint("followers"),
+			Discoverable:      true,
+			Icon:              image{Type: "Image", MediaType: "image/png", URL: actor.baseURL.JoinPath("android-chrome-512x512.png").String()},
+			PublicKey: publicKey{
+				ID:           actor.keyID(),
+				Owner:        actor.id(),
+				PublicKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+			},
+		})
+	}
+}
+
+// handleOutbox renders the instance's outbox, which contains Create activities for the most recent notes.
+func handleOutbox(queries *db.Queries, actor *actor) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		notes, err := queries.RecentNotes(r.Context(), time.Now(), feedPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve recent notes for outbox: %w", err)
+		}
+
+		items := make([]*activity, len(notes))
+		for i, note := range notes {
+			items[i], err = actor.create(&note)
+			if err != nil {
+				return err
+			}
+			items[i].Context = nil
+		}
+
+		return activityResponse(w, activityJSONType, &orderedCollection{
+			Context:      activityStreamsContext,
+			ID:           actor.endpoint("outbox"),
+			Type:         "OrderedCollection",
+			TotalItems:   int64(len(items)),
+			OrderedItems: items,
+		})
+	}
+}
+
+// handleFollowers renders the instance's followers collection. Only the number of followers is disclosed.
+func handleFollowers(queries *db.Queries, actor *actor) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		n, err := queries.CountFollowers(r.Context())
+		if err != nil {
+			return fmt.Errorf("failed to count followers: %w", err)
+		}
+
+		return activityResponse(w, activityJSONType, &orderedCollection{
+			Context:    activityStreamsContext,
+			ID:         actor.endpoint("followers"),
+			Type:       "OrderedCollection",
+			TotalItems: n,
+		})
+	}
+}
+
+// handleInbox receives activities from other servers. Requests must be signed by the activity's actor. Follow and
+// Undo{Follow} activities are handled, and all others are ignored.
+func handleInbox(logger *slog.Logger, queries *db.Queries, actor *actor, client *http.Client) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxActivitySize))
+		if err != nil {
+			return fmt.Errorf("failed to read activity: %w", err)
+		}
+
+		var act inboxActivity
+		if err := json.Unmarshal(body, &act); err != nil || act.Actor == "" {
+			http.Error(w, "Invalid activity.", http.StatusBadRequest)
+			return nil
+		}
+
+		var signer *remoteActor
+		if _, err := httpsig.Verify(r, body, func(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+			signer, err = fetchRemoteActor(ctx, client, actor, keyID)
+			if err != nil {
+				return nil, err
+			}
+			return signer.publicKey(keyID)
+		}); err != nil {
+			logger.InfoContext(r.Context(), "rejected unsigned activity", "actor", act.Actor, "type", act.Type, "err", err)
+			http.Error(w, "Invalid signature.", http.StatusUnauthorized)
+			return nil
+		}
+
+		if signer.ID != act.Actor {
+			http.Error(w, "Activity not signed by its actor.", http.StatusUnauthorized)
+			return nil
+		}
+
+		switch {
+		case act.Type == "Follow" && act.Object.ID == actor.id():
+			if err := queries.CreateFollower(r.Context(), signer.ID, signer.Inbox, signer.Endpoints.SharedInbox, time.Now()); err != nil {
+				return fmt.Errorf("failed to create follower %q: %w", signer.ID, err)
+			}
+
+			accept := &activity{
+				Context: activityStreamsContext,
+				ID:      actor.id() + "#accepts/" + uuid.NewString(),
+				Type:    "Accept",
+				Actor:   actor.id(),
+				Object:  json.RawMessage(body),
+			}
+			if err := queueDelivery(r.Context(), queries, signer.Inbox, accept); err != nil {
+				return err
+			}
+			logger.InfoContext(r.Context(), "new follower", "actor", signer.ID)
+		case act.Type == "Undo" && act.Object.Type == "Follow":
+			if err := queries.DeleteFollower(r.Context(), signer.ID); err != nil {
+				return fmt.Errorf("failed to delete follower %q: %w", signer.ID, err)
+			}
+			logger.InfoContext(r.Context(), "lost follower", "actor", signer.ID)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+// activityPubHook returns a publish hook which queues the delivery of Create activities for newly-visible notes to all
+// followers.
+func activityPubHook(queries *db.Queries, actor *actor) publishHook {
+	return publishHook{name: "activitypub", run: func(ctx context.Context, note *db.Note) error {
+		followers, err := queries.Followers(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve followers: %w", err)
+		}
+
+		create, err := actor.create(note)
+		if err != nil {
+			return err
+		}
+
+		// Deliver once per server to followers with shared inboxes.
+		inboxes := make(map[string]bool, len(followers))
+		for _, f := range followers {
+			inbox := f.SharedInbox
+			if inbox == "" {
+				inbox = f.Inbox
+			}
+
+			if inboxes[inbox] {
+				continue
+			}
+			inboxes[inbox] = true
+
+			if err := queueDelivery(ctx, queries, inbox, create); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+}
+
+// queueDelivery queues an activity for delivery to an inbox. Queueing the same activity for the same inbox more than
+// once has no effect.
+func queueDelivery(ctx context.Context, queries *db.Queries, inbox string, act *activity) error {
+	b, err := json.Marshal(act)
+	if err != nil {
+		return fmt.Errorf("failed to marshal activity %s: %w", act.ID, err)
+	}
+
+	id := uuid.NewSHA1(uuid.NameSpaceURL, []byte(act.ID+" "+inbox)).String()
+	if err := queries.CreateActivityDelivery(ctx, id, inbox, b, time.Now()); err != nil {
+		return fmt.Errorf("failed to queue delivery of activity %s to %q: %w", act.ID, inbox, err)
+	}
+	return nil
+}
+
+// deliverActivities delivers a batch of queued activities which are due. Failed deliveries are retried with exponential
+// backoff, unless the inbox rejected them or they've been attempted too many times.
+func deliverActivities(ctx context.Context, logger *slog.Logger, queries *db.Queries, client *http.Client, actor *actor) error {
+	due, err := queries.DueActivityDeliveries(ctx, time.Now(), activityBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve due activity deliveries: %w", err)
+	}
+
+	for _, d := range due {
+		err := postActivity(ctx, client, actor, d.Inbox, d.Activity)
+		a := deliveryRetries.attempt(d.Attempts, d.NextAttemptAt, "delivered", err, errors.Is(err, errActivityRejected))
+
+		if err := queries.UpdateActivityDelivery(ctx, a.status, a.attempts, a.lastError, a.nextAttemptAt, a.completedAt, d.ActivityDeliveryID); err != nil {
+			return fmt.Errorf("failed to update activity delivery %s: %w", d.ActivityDeliveryID, err)
+		}
+		logger.InfoContext(ctx, "delivered activity", "inbox", d.Inbox, "status", a.status, "err", a.lastError)
+	}
+
+	return nil
+}
+
+// errActivityRejected is returned when an inbox rejects an activity as invalid.
+var errActivityRejected = errors.New("activity rejected")
+
+// postActivity signs and posts an activity to an inbox. If the inbox responds with a client error, the returned error
+// wraps errActivityRejected.
+func postActivity(ctx context.Context, client *http.Client, actor *actor, inbox string, body []byte) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %q: %w", inbox, err)
+	}
+	req.Header.Set("Content-Type", activityJSONType)
+
+	if err := httpsig.Sign(req, actor.keyID(), actor.key, body); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post activity to %q: %w", inbox, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w by %q: %s", errActivityRejected, inbox, resp.Status)
+	default:
+		return fmt.Errorf("unexpected response posting activity to %q: %s", inbox, resp.Status)
+	}
+}
+
+// remoteActor is the subset of another server's actor document which is needed to verify and deliver activities.
+type remoteActor struct {
+	ID        string `json:"id"`
+	Inbox     string `json:"inbox"`
+	Endpoints struct {
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey struct {
+		ID           string `json:"id"`
+		Owner        string `json:"owner"`
+		PublicKeyPem string `json:"publicKeyPem"`
+	} `json:"publicKey"`
+}
+
+// publicKey returns the actor's public key, if it has the given ID.
+func (a *remoteActor) publicKey(keyID string) (*rsa.PublicKey, error) {
+	if a.PublicKey.ID != keyID || a.PublicKey.Owner != a.ID {
+		return nil, fmt.Errorf("actor %q has no key %q", a.ID, keyID)
+	}
+
+	block, _ := pem.Decode([]byte(a.PublicKey.PublicKeyPem))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key for actor %q", a.ID)
+	}
+
+	var key any
+	var err error
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key for actor %q: %w", a.ID, err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type for actor %q: %T", a.ID, key)
+	}
+	return rsaKey, nil
+}
+
+// fetchRemoteActor fetches the actor document at the given URL with a signed request. The actor's ID must have the same
+// origin as the URL it was fetched from.
+func fetchRemoteActor(ctx context.Context, client *http.Client, actor *actor, actorURL string) (a *remoteActor, err error) {
+	actorURL, _, _ = strings.Cut(actorURL, "#")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actorURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %q: %w", actorURL, err)
+	}
+	req.Header.Set("Accept", activityJSONType)
+
+	if err := httpsig.Sign(req, actor.keyID(), actor.key, nil); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch actor %q: %w", actorURL, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response fetching actor %q: %s", actorURL, resp.Status)
+	}
+
+	a = new(remoteActor)
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxActivitySize)).Decode(a); err != nil {
+		return nil, fmt.Errorf("failed to decode actor %q: %w", actorURL, err)
+	}
+
+	if !sameOrigin(a.ID, resp.Request.URL) {
+		return nil, fmt.Errorf("actor %q has a different origin than %q", a.ID, actorURL)
+	}
+
+	// Only deliver activities to inboxes on the actor's own server.
+	if !sameOrigin(a.Inbox, resp.Request.URL) || (a.Endpoints.SharedInbox != "" && !sameOrigin(a.Endpoints.SharedInbox, resp.Request.URL)) {
+		return nil, fmt.Errorf("actor %q has an inbox with a different origin", a.ID)
+	}
+
+	return a, nil
+}
+
+// sameOrigin returns true if s is a URL with the same scheme and host as u.
+func sameOrigin(s string, u *url.URL) bool {
+	v, err := url.Parse(s)
+	return err == nil && v.Scheme == u.Scheme && v.Host == u.Host
+}
+
+// create returns a Create activity for the given note.
+func (a *actor) create(note *db.Note) (*activity, error) {
+	object, err := a.note(note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &activity{
+		Context:   activityStreamsContext,
+		ID:        object.ID + "#create",
+		Type:      "Create",
+		Actor:     a.id(),
+		Published: object.Published,
+		To:        object.To,
+		Cc:        object.Cc,
+		Object:    object,
+	}, nil
+}
+
+// note returns an ActivityStreams Note object for the given note. Images are included as attachments, since most
+// servers remove them from the content.
+func (a *actor) note(note *db.Note) (*activityNote, error) {
+	html, err := markdown.HTML(note.Body, a.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render note %s for activitypub: %w", note.NoteID, err)
+	}
+
+	content := string(html)
+	if note.Title != "" {
+		content = "<h2>" + template.HTMLEscapeString(note.Title) + "</h2>" + content
+	}
+
+	tags, err := markdown.Tags(note.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tags for note %s: %w", note.NoteID, err)
+	}
+
+	images, err := markdown.Images(note.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse images for note %s: %w", note.NoteID, err)
+	}
+
+	noteURL := a.baseURL.JoinPath("note", note.NoteID).String()
+	object := &activityNote{
+		ID:           noteURL,
+		Type:         "Note",
+		AttributedTo: a.id(),
+		Content:      content,
+		Published:    note.CreatedAt.UTC().Format(time.RFC3339),
+		URL:          noteURL,
+		To:           []string{activityStreamsPublic},
+		Cc:           []string{a.endpoint("followers")},
+	}
+
+	if note.UpdatedAt.Valid {
+		object.Updated = note.UpdatedAt.Time.UTC().Format(time.RFC3339)
+	}
+
+	for _, tag := range tags {
+		object.Tag = append(object.Tag, activityTag{Type: "Hashtag", Href: a.baseURL.JoinPath("tags", tag).String(), Name: "#" + tag})
+	}
+
+	for _, image := range images {
+		object.Attachment = append(object.Attachment, activityAttachment{Type: "Image", URL: a.baseURL.ResolveReference(image).String()})
+	}
+
+	return object, nil
+}
+
+// activity is an ActivityStreams activity.
+type activity struct {
+	Context   any      `json:"@context,omitempty"`
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Published string   `json:"published,omitempty"`
+	To        []string `json:"to,omitempty"`
+	Cc        []string `json:"cc,omitempty"`
+	Object    any      `json:"object"`
+}
+
+// activityNote is an ActivityStreams Note object.
+type activityNote struct {
+	Context      any                  `json:"@context,omitempty"`
+	ID           string               `json:"id"`
+	Type         string               `json:"type"`
+	AttributedTo string               `json:"attributedTo"`
+	Content      string               `json:"content"`
+	Published    string               `json:"published"`
+	Updated      string               `json:"updated,omitempty"`
+	URL          string               `json:"url"`
+	To           []string             `json:"to"`
+	Cc           []string             `json:"cc"`
+	Tag          []activityTag        `json:"tag,omitempty"`
+	Attachment   []activityAttachment `json:"attachment,omitempty"`
+}
+
+type activityTag struct {
+	Type string `json:"type"`
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+type activityAttachment struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// orderedCollection is an ActivityStreams OrderedCollection.
+type orderedCollection struct {
+	Context      any         `json:"@context"`
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	TotalItems   int64       `json:"totalItems"`
+	OrderedItems []*activity `json:"orderedItems,omitempty"`
+}
+
+// inboxActivity is the subset of an activity received in the inbox which is needed to handle it.
+type inboxActivity struct {
+	Type   string         `json:"type"`
+	Actor  string         `json:"actor"`
+	Object activityObject `json:"object"`
+}
+
+// activityObject is the object of an activity, which may be either embedded or referred to by its ID.
+type activityObject struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func (o *activityObject) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &o.ID); err == nil {
+		return nil
+	}
+
+	type object activityObject
+	return json.Unmarshal(b, (*object)(o))
+}
+
+// acceptsActivityJSON returns true if the request prefers an ActivityStreams representation.
+func acceptsActivityJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, activityJSONType) || strings.Contains(accept, "application/ld+json")
+}
+
+func activityResponse(w http.ResponseWriter, contentType string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s response: %w", contentType, err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("failed to write %s response: %w", contentType, err)
+	}
+	return nil
+}
+
+// loadActorKey reads the actor's RSA private key from the given file, creating it if it doesn't exist. The key is used to
+// sign requests to other ActivityPub servers.
+func loadActorKey(path string) (*rsa.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(b)
+		if block == nil || block.Type != "PRIVATE KEY" {
+			return nil, fmt.Errorf("invalid actor key in %q", path)
+		}
+
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse actor key: %w", err)
+		}
+
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("invalid actor key type in %q: %T", path, key)
+		}
+		return rsaKey, nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read actor key: %w", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate actor key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal actor key: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create actor key file: %w", err)
+	}
+
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to write actor key: %w", err), f.Close())
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close actor key file: %w", err)
+	}
+	return key, nil
+}
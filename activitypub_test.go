@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/httpsig"
+	"github.com/google/uuid"
+)
+
+func TestWebFinger(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/webfinger?resource=acct:notes@example.com", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Content-Type"), "application/jrd+json"; got != want {
+		t.Errorf("Content-Type = %q, want = %q", got, want)
+	}
+
+	var jrd struct {
+		Subject string `json:"subject"`
+		Links   []struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jrd); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := jrd.Links[0].Href, "http://example.com/activitypub/actor"; got != want {
+		t.Errorf("links[0].href = %q, want = %q", got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/webfinger?resource=acct:someone@example.com", nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+}
+
+func TestActor(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/activitypub/actor", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	var doc remoteActor
+	if err := json.NewDecoder(w.Result().Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := doc.Inbox, "http://example.com/activitypub/inbox"; got != want {
+		t.Errorf("inbox = %q, want = %q", got, want)
+	}
+
+	key, err := doc.publicKey("http://example.com/activitypub/actor#main-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !key.Equal(&testActorKey().PublicKey) {
+		t.Error("actor document has the wrong public key")
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "A Title", "It's a #test.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/activitypub/outbox", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	var outbox struct {
+		TotalItems   int `json:"totalItems"`
+		OrderedItems []struct {
+			Type   string       `json:"type"`
+			Object activityNote `json:"object"`
+		} `json:"orderedItems"`
+	}
+	if err := json.NewDecoder(w.Result().Body).Decode(&outbox); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := outbox.TotalItems, 1; got != want {
+		t.Fatalf("totalItems = %d, want = %d", got, want)
+	}
+
+	note := outbox.OrderedItems[0].Object
+	if got, want := note.ID, "http://example.com/note/"+noteID; got != want {
+		t.Errorf("note.ID = %q, want = %q", got, want)
+	}
+
+	if got, want := note.Content, "<h2>A Title</h2>"; !strings.HasPrefix(got, want) {
+		t.Errorf("note.Content = %q, want = /%s.*/", got, want)
+	}
+
+	if got, want := note.Tag, []activityTag{{Type: "Hashtag", Href: "http://example.com/tags/test", Name: "#test"}}; !slices.Equal(got, want) {
+		t.Errorf("note.Tag = %v, want = %v", got, want)
+	}
+
+	// Notes are also available by URL to fediverse servers.
+	req = httptest.NewRequest(http.MethodGet, note.URL, nil)
+	req.Header.Set("Accept", activityJSONType)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().Header.Get("Content-Type"), activityJSONType; got != want {
+		t.Errorf("Content-Type = %q, want = %q", got, want)
+	}
+}
+
+func TestFederation(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	appActor := "http://example.com/activitypub/actor"
+
+	// Stand in for another server with a single actor and an inbox which records verified activities.
+	var delivered []string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	remoteKey := testActorKey()
+	remoteActorID := server.URL + "/actor"
+	serveActor := func(id, inbox string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			der, _ := x509.MarshalPKIXPublicKey(&remoteKey.PublicKey)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":    id,
+				"type":  "Person",
+				"inbox": inbox,
+				"publicKey": map[string]string{
+					"id":           id + "#main-key",
+					"owner":        id,
+					"publicKeyPem": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+				},
+			})
+		}
+	}
+	mux.HandleFunc("GET /actor", serveActor(remoteActorID, server.URL+"/inbox"))
+
+	// Stand in for an actor whose inbox is on another server.
+	elsewhereActorID := server.URL + "/elsewhere"
+	mux.HandleFunc("GET /elsewhere", serveActor(elsewhereActorID, "http://169.254.169.254/inbox"))
+	mux.HandleFunc("POST /inbox", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if _, err := httpsig.Verify(r, body, func(context.Context, string) (*rsa.PublicKey, error) {
+			return &testActorKey().PublicKey, nil
+		}); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var act inboxActivity
+		_ = json.Unmarshal(body, &act)
+		delivered = append(delivered, act.Type)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	post := func(activity map[string]any, signer string) int {
+		body, _ := json.Marshal(activity)
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/activitypub/inbox", bytes.NewReader(body))
+		req.Header.Set("Content-Type", activityJSONType)
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		if signer != "" {
+			if err := httpsig.Sign(req, signer+"#main-key", remoteKey, body); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+
+	followers := func() int64 {
+		n, err := app.queries.CountFollowers(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	a := &actor{username: "notes", baseURL: &url.URL{Scheme: "http", Host: "example.com", Path: "/"}, key: testActorKey()}
+	deliver := func() {
+		if err := deliverActivities(t.Context(), logger, app.queries, server.Client(), a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	follow := map[string]any{"id": remoteActorID + "#follow", "type": "Follow", "actor": remoteActorID, "object": appActor}
+
+	// Unsigned activities and activities signed by someone other than their actor are rejected.
+	if got, want := post(follow, ""), http.StatusUnauthorized; got != want {
+		t.Errorf("unsigned: resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	forged := map[string]any{"type": "Follow", "actor": "https://elsewhere.example/actor", "object": appActor}
+	if got, want := post(forged, remoteActorID), http.StatusUnauthorized; got != want {
+		t.Errorf("forged: resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// Actors with inboxes on other servers are rejected.
+	elsewhere := map[string]any{"type": "Follow", "actor": elsewhereActorID, "object": appActor}
+	if got, want := post(elsewhere, elsewhereActorID), http.StatusUnauthorized; got != want {
+		t.Errorf("elsewhere: resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := followers(), int64(0); got != want {
+		t.Errorf("followers = %d, want = %d", got, want)
+	}
+
+	// A signed Follow adds a follower and is accepted.
+	if got, want := post(follow, remoteActorID), http.StatusAccepted; got != want {
+		t.Errorf("follow: resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := followers(), int64(1); got != want {
+		t.Errorf("followers = %d, want = %d", got, want)
+	}
+
+	deliver()
+
+	// New notes are delivered to followers once they're published.
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "Hello, fediverse.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := announceNotes(t.Context(), logger, app.queries, []publishHook{activityPubHook(app.queries, a)}); err != nil {
+		t.Fatal(err)
+	}
+
+	deliver()
+
+	if got, want := delivered, []string{"Accept", "Create"}; !slices.Equal(got, want) {
+		t.Errorf("delivered = %v, want = %v", got, want)
+	}
+
+	// Undoing the Follow removes the follower.
+	undo := map[string]any{"type": "Undo", "actor": remoteActorID, "object": follow}
+	if got, want := post(undo, remoteActorID), http.StatusAccepted; got != want {
+		t.Errorf("undo: resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := followers(), int64(0); got != want {
+		t.Errorf("followers = %d, want = %d", got, want)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codahale/yellhole-go/internal/db"
@@ -21,29 +24,302 @@ func handleAdminPage(queries *db.Queries, t *template.Template) appHandler {
 			return fmt.Errorf("failed to retrieve recent images: %w", err)
 		}
 
+		// Look up the most recent 10 notes, if any.
+		notes, err := queries.RecentNotes(r.Context(), time.Now(), 10)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve recent notes for admin page: %w", err)
+		}
+
+		// Look up the most recently deleted 10 notes, if any.
+		deleted, err := queries.DeletedNotes(r.Context(), 10)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve deleted notes for admin page: %w", err)
+		}
+
+		// Look up the most recent 10 drafts, if any.
+		drafts, err := queries.Drafts(r.Context(), 10)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve drafts for admin page: %w", err)
+		}
+
+		// Look up all notes scheduled to be published in the future, if any.
+		scheduled, err := queries.ScheduledNotes(r.Context(), time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve scheduled notes for admin page: %w", err)
+		}
+
 		// Render the admin page.
-		return htmlResponse(w, t, "new.gohtml", images)
+		return htmlResponse(w, t, "new.gohtml", &adminPage{
+			Images:         images,
+			Notes:          notes,
+			Drafts:         drafts,
+			ScheduledNotes: scheduled,
+			DeletedNotes:   deleted,
+		})
 	}
 }
 
 // handleNewNote creates new notes or displays them as a preview.
 func handleNewNote(queries *db.Queries, t *template.Template, baseURL *url.URL) appHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
+		title := strings.TrimSpace(r.FormValue("title"))
 		body := r.FormValue("body")
 
 		// If ?preview=true, render the note as it would appear if created.
-		if preview, err := strconv.ParseBool(r.FormValue("preview")); preview && err == nil {
+		if isPreview(r) {
 			return htmlResponse(w, t, "preview.gohtml", body)
 		}
 
-		// Otherwise, create a new note and redirect to it.
+		// If ?draft=true, save the note as a draft and redirect to its editing page.
 		id := uuid.New().String()
-		if err := queries.CreateNote(r.Context(), id, body, time.Now()); err != nil {
+		if isDraft(r) {
+			if err := queries.CreateDraft(r.Context(), id, title, body, time.Now()); err != nil {
+				return fmt.Errorf("failed to create new draft: %w", err)
+			}
+
+			if err := setNoteTags(r.Context(), queries, id, body); err != nil {
+				return err
+			}
+
+			http.Redirect(w, r, baseURL.JoinPath("admin", "note", id, "edit").String(), http.StatusSeeOther)
+			return nil
+		}
+
+		// Otherwise, create a new note to be published at the given time.
+		createdAt, err := publishAt(r)
+		if err != nil {
+			http.Error(w, "invalid publish time", http.StatusBadRequest)
+			return nil //nolint:nilerr // the error is handled here
+		}
+
+		if err := queries.CreateNote(r.Context(), id, title, body, createdAt); err != nil {
 			return fmt.Errorf("failed to create new note: %w", err)
 		}
 
+		if err := setNoteTags(r.Context(), queries, id, body); err != nil {
+			return err
+		}
+
 		// Redirect to the new note.
+		redirectToNote(w, r, baseURL, id, createdAt)
+		return nil
+	}
+}
+
+// handleEditNotePage renders the admin note editing page.
+func handleEditNotePage(queries *db.Queries, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		note, err := queries.EditableNoteByID(r.Context(), r.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.NotFound(w, r)
+				return nil
+			}
+			return fmt.Errorf("failed to retrieve note for editing: %w", err)
+		}
+
+		// Look up the note's previous revisions, if any.
+		revisions, err := queries.NoteRevisions(r.Context(), note.NoteID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve revisions for note %s: %w", note.NoteID, err)
+		}
+
+		// Look up the most recent 10 images, if any.
+		images, err := queries.RecentImages(r.Context(), 10)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve recent images for edit page: %w", err)
+		}
+
+		// Render the admin page with the note loaded into the editor.
+		return htmlResponse(w, t, "new.gohtml", &adminPage{Images: images, Note: &note, Revisions: revisions, Scheduled: isScheduled(&note)})
+	}
+}
+
+// handleEditNote updates existing notes or displays them as a preview.
+func handleEditNote(queries *db.Queries, t *template.Template, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		title := strings.TrimSpace(r.FormValue("title"))
+		body := r.FormValue("body")
+
+		// If ?preview=true, render the note as it would appear if updated.
+		if isPreview(r) {
+			return htmlResponse(w, t, "preview.gohtml", body)
+		}
+
+		// Ensure the note exists.
+		note, err := queries.EditableNoteByID(r.Context(), r.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.NotFound(w, r)
+				return nil
+			}
+			return fmt.Errorf("failed to retrieve note for update: %w", err)
+		}
+
+		// If the note is a draft, either keep it as a draft or publish it. If it's scheduled, reschedule it.
+		keepDraft, scheduled := note.Draft && isDraft(r), isScheduled(&note)
+		createdAt := note.CreatedAt
+		if (note.Draft && !keepDraft) || scheduled {
+			createdAt, err = publishAt(r)
+			if err != nil {
+				http.Error(w, "invalid publish time", http.StatusBadRequest)
+				return nil //nolint:nilerr // the error is handled here
+			}
+		}
+
+		// Make all the changes to the note at once.
+		if err := queries.Tx(r.Context(), func(queries *db.Queries) error {
+			// Update the note. The previous body is preserved as a revision by the database.
+			if err := queries.UpdateNote(r.Context(), title, body, sql.NullTime{Time: time.Now(), Valid: true}, note.NoteID); err != nil {
+				return fmt.Errorf("failed to update note %s: %w", note.NoteID, err)
+			}
+
+			if err := setNoteTags(r.Context(), queries, note.NoteID, body); err != nil {
+				return err
+			}
+
+			if err := queueEditedNoteWebmentions(r.Context(), queries, &note, body); err != nil {
+				return err
+			}
+
+			if note.Draft && !keepDraft {
+				if err := queries.PublishDraft(r.Context(), createdAt, note.NoteID); err != nil {
+					return fmt.Errorf("failed to publish draft %s: %w", note.NoteID, err)
+				}
+			} else if scheduled {
+				if err := queries.RescheduleNote(r.Context(), createdAt, note.NoteID); err != nil {
+					return fmt.Errorf("failed to reschedule note %s: %w", note.NoteID, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if keepDraft {
+			http.Redirect(w, r, baseURL.JoinPath("admin", "note", note.NoteID, "edit").String(), http.StatusSeeOther)
+			return nil
+		}
+
+		// Redirect to the updated note.
+		redirectToNote(w, r, baseURL, note.NoteID, createdAt)
+		return nil
+	}
+}
+
+// handlePublishDraft publishes a draft note as of the current time.
+func handlePublishDraft(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		if _, err := queries.EditableNoteByID(r.Context(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.NotFound(w, r)
+				return nil
+			}
+			return fmt.Errorf("failed to retrieve note %s for publishing: %w", id, err)
+		}
+
+		if err := queries.PublishDraft(r.Context(), time.Now(), id); err != nil {
+			return fmt.Errorf("failed to publish draft %s: %w", id, err)
+		}
+
 		http.Redirect(w, r, baseURL.JoinPath("note", id).String(), http.StatusSeeOther)
 		return nil
 	}
 }
+
+// handleDeleteNote marks a note as deleted, hiding it from all public pages and feeds.
+func handleDeleteNote(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		if _, err := queries.EditableNoteByID(r.Context(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.NotFound(w, r)
+				return nil
+			}
+			return fmt.Errorf("failed to retrieve note %s for deletion: %w", id, err)
+		}
+
+		if err := queries.DeleteNote(r.Context(), sql.NullTime{Time: time.Now(), Valid: true}, id); err != nil {
+			return fmt.Errorf("failed to delete note %s: %w", id, err)
+		}
+
+		http.Redirect(w, r, baseURL.JoinPath("admin").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+// handleRestoreNote restores a deleted note.
+func handleRestoreNote(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id := r.PathValue("id")
+		if err := queries.RestoreNote(r.Context(), id); err != nil {
+			return fmt.Errorf("failed to restore note %s: %w", id, err)
+		}
+
+		note, err := queries.EditableNoteByID(r.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to retrieve note %s: %w", id, err)
+		}
+
+		// Drafts don't have note pages, so redirect to the draft's edit page instead.
+		if note.Draft {
+			http.Redirect(w, r, baseURL.JoinPath("admin", "note", id, "edit").String(), http.StatusSeeOther)
+			return nil
+		}
+
+		redirectToNote(w, r, baseURL, id, note.CreatedAt)
+		return nil
+	}
+}
+
+// isPreview returns true if the request is for a preview of a note rather than for its creation or modification.
+func isPreview(r *http.Request) bool {
+	preview, err := strconv.ParseBool(r.FormValue("preview"))
+	return preview && err == nil
+}
+
+// publishAt returns the time at which a note should be published, which defaults to the current time.
+func publishAt(r *http.Request) (time.Time, error) {
+	s := r.FormValue("publish_at")
+	if s == "" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04", s, time.Local)
+}
+
+// isScheduled returns true if the note is scheduled for future publication.
+func isScheduled(note *db.Note) bool {
+	return !note.Draft && note.CreatedAt.After(time.Now())
+}
+
+// redirectToNote redirects to the given note or, if it has not yet been published, to the admin page.
+func redirectToNote(w http.ResponseWriter, r *http.Request, baseURL *url.URL, id string, createdAt time.Time) {
+	if createdAt.After(time.Now()) {
+		http.Redirect(w, r, baseURL.JoinPath("admin").String(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, baseURL.JoinPath("note", id).String(), http.StatusSeeOther)
+}
+
+// isDraft returns true if the request is to save a note as a draft rather than to publish it.
+func isDraft(r *http.Request) bool {
+	draft, err := strconv.ParseBool(r.FormValue("draft"))
+	return draft && err == nil
+}
+
+type adminPage struct {
+	Images         []db.Image
+	Notes          []db.Note
+	Drafts         []db.Note
+	ScheduledNotes []db.Note
+	DeletedNotes   []db.Note
+	Note           *db.Note
+	Revisions      []db.NoteRevision
+
+	// Scheduled is true if Note is scheduled for future publication, and can be rescheduled.
+	Scheduled bool
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"database/sql"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -19,7 +20,7 @@ func TestAdminPage(t *testing.T) {
 	app := newTestApp(t)
 
 	sessionID := uuid.NewString()
-	if err := app.queries.CreateSession(t.Context(), sessionID, time.Now()); err != nil {
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -48,7 +49,7 @@ func TestAdminNotePreview(t *testing.T) {
 	app := newTestApp(t)
 
 	sessionID := uuid.NewString()
-	if err := app.queries.CreateSession(t.Context(), sessionID, time.Now()); err != nil {
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -90,7 +91,7 @@ func TestAdminNoteCreate(t *testing.T) {
 	app := newTestApp(t)
 
 	sessionID := uuid.NewString()
-	if err := app.queries.CreateSession(t.Context(), sessionID, time.Now()); err != nil {
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -117,7 +118,7 @@ func TestAdminNoteCreate(t *testing.T) {
 		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
 	}
 
-	notes, err := app.queries.RecentNotes(t.Context(), 10)
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -134,3 +135,590 @@ func TestAdminNoteCreate(t *testing.T) {
 		t.Errorf(`resp.Header.Get("Location") = %v, want = %v`, got, want)
 	}
 }
+
+func TestAdminNoteEditPage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "This is a tpyo.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/admin/note/"+noteID+"/edit", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	response, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := string(response), "This is a tpyo."; !strings.Contains(got, want) {
+		t.Errorf(`response = %v, want = /%v/`, got, want)
+	}
+
+	if got, want := string(response), "/admin/note/"+noteID+"/edit"; !strings.Contains(got, want) {
+		t.Errorf(`response = %v, want = /%v/`, got, want)
+	}
+}
+
+func TestAdminNoteEditPage404(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/admin/note/"+uuid.NewString()+"/edit", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+}
+
+func TestAdminNoteEdit(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "This is a tpyo.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	body, _ := mw.CreateFormField("body")
+	_, _ = body.Write([]byte("This is a typo."))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/edit", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/note/"+noteID; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %v, want = %v`, got, want)
+	}
+
+	note, err := app.queries.NoteByID(t.Context(), noteID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := note.Body, "This is a typo."; got != want {
+		t.Errorf(`note.Body = %v, want = %v`, got, want)
+	}
+
+	if !note.UpdatedAt.Valid {
+		t.Error("note.UpdatedAt.Valid = false, want = true")
+	}
+
+	revisions, err := app.queries.NoteRevisions(t.Context(), noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(revisions), 1; got != want {
+		t.Fatalf(`len(revisions) = %v, want = %v`, got, want)
+	}
+
+	if got, want := revisions[0].Body, "This is a tpyo."; got != want {
+		t.Errorf(`revisions[0].Body = %v, want = %v`, got, want)
+	}
+}
+
+func TestAdminNoteEditTitle(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "A Ttile", "Same body.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.UpdateNote(t.Context(), "A Title", "Same body.", sql.NullTime{Time: time.Now(), Valid: true}, noteID); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := app.queries.NoteRevisions(t.Context(), noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(revisions), 1; got != want {
+		t.Fatalf(`len(revisions) = %v, want = %v`, got, want)
+	}
+
+	if got, want := revisions[0].Title, "A Ttile"; got != want {
+		t.Errorf(`revisions[0].Title = %v, want = %v`, got, want)
+	}
+}
+
+func TestAdminNoteDeleteAndRestore(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Regrettable.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/delete", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 0; got != want {
+		t.Errorf(`len(notes) = %v, want = %v`, got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/restore", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/note/"+noteID; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %v, want = %v`, got, want)
+	}
+
+	notes, err = app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 1; got != want {
+		t.Errorf(`len(notes) = %v, want = %v`, got, want)
+	}
+}
+
+func TestAdminDraftRestore(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateDraft(t.Context(), noteID, "", "Not quite ready.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.DeleteNote(t.Context(), sql.NullTime{Time: time.Now(), Valid: true}, noteID); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/restore", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/admin/note/"+noteID+"/edit"; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %v, want = %v`, got, want)
+	}
+}
+
+func TestAdminDraftCreate(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	body, _ := mw.CreateFormField("body")
+	_, _ = body.Write([]byte("Not quite ready."))
+	draft, _ := mw.CreateFormField("draft")
+	_, _ = draft.Write([]byte("true"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/new", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 0; got != want {
+		t.Errorf(`len(notes) = %v, want = %v`, got, want)
+	}
+
+	drafts, err := app.queries.Drafts(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(drafts), 1; got != want {
+		t.Fatalf(`len(drafts) = %v, want = %v`, got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/admin/note/"+drafts[0].NoteID+"/edit"; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %v, want = %v`, got, want)
+	}
+}
+
+func TestAdminDraftPublish(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateDraft(t.Context(), noteID, "", "Finally ready.", time.Now().AddDate(0, 0, -3)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/publish", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	note, err := app.queries.NoteByID(t.Context(), noteID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if note.Draft {
+		t.Error("note.Draft = true, want = false")
+	}
+
+	if got, want := time.Since(note.CreatedAt), time.Minute; got > want {
+		t.Errorf("time.Since(note.CreatedAt) = %v, want < %v", got, want)
+	}
+}
+
+func TestAdminNoteCreateScheduled(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	publishAt := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	body, _ := mw.CreateFormField("body")
+	_, _ = body.Write([]byte("Not yet."))
+	publish, _ := mw.CreateFormField("publish_at")
+	_, _ = publish.Write([]byte(publishAt.Format("2006-01-02T15:04")))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/new", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/admin"; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %v, want = %v`, got, want)
+	}
+
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 0; got != want {
+		t.Errorf(`len(notes) = %v, want = %v`, got, want)
+	}
+
+	scheduled, err := app.queries.ScheduledNotes(t.Context(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(scheduled), 1; got != want {
+		t.Fatalf(`len(scheduled) = %v, want = %v`, got, want)
+	}
+
+	if got, want := scheduled[0].CreatedAt, publishAt; !got.Equal(want) {
+		t.Errorf(`scheduled[0].CreatedAt = %v, want = %v`, got, want)
+	}
+}
+
+func TestAdminNoteReschedule(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduledAt := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Soon.", scheduledAt); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/admin/note/"+noteID+"/edit", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	page, _ := io.ReadAll(w.Result().Body)
+	if got, want := string(page), `value="`+scheduledAt.Local().Format("2006-01-02T15:04")+`"`; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	rescheduledAt := scheduledAt.Add(24 * time.Hour)
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	body, _ := mw.CreateFormField("body")
+	_, _ = body.Write([]byte("Later."))
+	publishAt, _ := mw.CreateFormField("publish_at")
+	_, _ = publishAt.Write([]byte(rescheduledAt.Local().Format("2006-01-02T15:04")))
+	_ = mw.Close()
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/edit", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	note, err := app.queries.EditableNoteByID(t.Context(), noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := note.CreatedAt, rescheduledAt; !got.Equal(want) {
+		t.Errorf("note.CreatedAt = %v, want = %v", got, want)
+	}
+}
+
+func TestAdminNoteRescheduleInvalid(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduledAt := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Soon.", scheduledAt); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	body, _ := mw.CreateFormField("body")
+	_, _ = body.Write([]byte("Later."))
+	publishAt, _ := mw.CreateFormField("publish_at")
+	_, _ = publishAt.Write([]byte("next tuesday"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/edit", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// The note is left entirely unchanged.
+	note, err := app.queries.EditableNoteByID(t.Context(), noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := note.Body, "Soon."; got != want {
+		t.Errorf("note.Body = %q, want = %q", got, want)
+	}
+
+	if got, want := note.CreatedAt, scheduledAt; !got.Equal(want) {
+		t.Errorf("note.CreatedAt = %v, want = %v", got, want)
+	}
+}
+
+func TestAdminNotePublishAndDelete404(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range []string{"publish", "delete"} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+uuid.NewString()+"/"+action, nil)
+		req.Header.Set("Sec-Fetch-Site", "none")
+		req.AddCookie(&http.Cookie{
+			Name:  "sessionID",
+			Value: sessionID,
+		})
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		if got, want := w.Result().StatusCode, http.StatusNotFound; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", action, got, want)
+		}
+	}
+}
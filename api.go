@@ -0,0 +1,377 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/google/uuid"
+)
+
+// apiPrefix is the path prefix of the JSON API.
+const apiPrefix = "/api/"
+
+// maxAPIPageSize is the largest number of items returned by a single API list request.
+const maxAPIPageSize = 100
+
+// handleAPIListNotes returns a page of the most recent published notes, optionally older than the note with the ID given
+// by ?before=.
+func handleAPIListNotes(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		limit, err := apiLimit(r)
+		if err != nil {
+			return err
+		}
+
+		var notes []db.Note
+		if before := r.FormValue("before"); before != "" {
+			notes, err = queries.RecentNotesOlderThan(r.Context(), before, time.Now(), limit)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve notes older than note %q for api: %w", before, err)
+			}
+		} else {
+			notes, err = queries.RecentNotes(r.Context(), time.Now(), limit)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve recent notes for api: %w", err)
+			}
+		}
+
+		resp := apiNoteList{Notes: make([]apiNote, len(notes))}
+		for i := range notes {
+			resp.Notes[i] = newAPINote(&notes[i], baseURL)
+		}
+		return jsonResponse(w, &resp)
+	}
+}
+
+// handleAPIGetNote returns a single note, including drafts and scheduled notes.
+func handleAPIGetNote(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		note, err := apiEditableNote(r, queries)
+		if err != nil {
+			return err
+		}
+
+		return jsonResponse(w, newAPINote(&note, baseURL))
+	}
+}
+
+// handleAPICreateNote creates a new note or draft.
+func handleAPICreateNote(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req apiNoteRequest
+		if err := decodeAPIRequest(r, &req); err != nil {
+			return err
+		}
+
+		id := uuid.NewString()
+		title := strings.TrimSpace(req.Title)
+		if req.Draft {
+			if err := queries.CreateDraft(r.Context(), id, title, req.Body, time.Now()); err != nil {
+				return fmt.Errorf("failed to create new draft via api: %w", err)
+			}
+		} else {
+			if err := queries.CreateNote(r.Context(), id, title, req.Body, req.publishAt()); err != nil {
+				return fmt.Errorf("failed to create new note via api: %w", err)
+			}
+		}
+
+		if err := setNoteTags(r.Context(), queries, id, req.Body); err != nil {
+			return err
+		}
+
+		note, err := queries.EditableNoteByID(r.Context(), id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve new note %s for api: %w", id, err)
+		}
+
+		w.Header().Set("Location", baseURL.JoinPath("api", "v1", "notes", id).String())
+		return apiResponse(w, http.StatusCreated, newAPINote(&note, baseURL))
+	}
+}
+
+// handleAPIUpdateNote replaces a note's title and body. If the note is a draft and the request doesn't mark it as one,
+// the draft is published. If the note is scheduled and the request has a publication time, the note is rescheduled.
+// Published notes can't be made drafts or rescheduled.
+func handleAPIUpdateNote(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		note, err := apiEditableNote(r, queries)
+		if err != nil {
+			return err
+		}
+
+		var req apiNoteRequest
+		if err := decodeAPIRequest(r, &req); err != nil {
+			return err
+		}
+
+		scheduled := isScheduled(&note)
+		if !note.Draft && req.Draft {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Published and scheduled notes can't be made drafts."}
+		}
+
+		if !note.Draft && !scheduled && req.PublishAt != nil {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Published notes can't be rescheduled."}
+		}
+
+		// Make all the changes to the note at once.
+		if err := queries.Tx(r.Context(), func(queries *db.Queries) error {
+			// Update the note. The previous body is preserved as a revision by the database.
+			if err := queries.UpdateNote(r.Context(), strings.TrimSpace(req.Title), req.Body, sql.NullTime{Time: time.Now(), Valid: true}, note.NoteID); err != nil {
+				return fmt.Errorf("failed to update note %s via api: %w", note.NoteID, err)
+			}
+
+			if err := setNoteTags(r.Context(), queries, note.NoteID, req.Body); err != nil {
+				return err
+			}
+
+			if err := queueEditedNoteWebmentions(r.Context(), queries, &note, req.Body); err != nil {
+				return err
+			}
+
+			if note.Draft && !req.Draft {
+				if err := queries.PublishDraft(r.Context(), req.publishAt(), note.NoteID); err != nil {
+					return fmt.Errorf("failed to publish draft %s via api: %w", note.NoteID, err)
+				}
+			} else if scheduled && req.PublishAt != nil {
+				if err := queries.RescheduleNote(r.Context(), *req.PublishAt, note.NoteID); err != nil {
+					return fmt.Errorf("failed to reschedule note %s via api: %w", note.NoteID, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		id := note.NoteID
+		note, err = queries.EditableNoteByID(r.Context(), id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve updated note %s for api: %w", id, err)
+		}
+
+		return jsonResponse(w, newAPINote(&note, baseURL))
+	}
+}
+
+// handleAPIDeleteNote marks a note as deleted.
+func handleAPIDeleteNote(queries *db.Queries) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		note, err := apiEditableNote(r, queries)
+		if err != nil {
+			return err
+		}
+
+		if err := queries.DeleteNote(r.Context(), sql.NullTime{Time: time.Now(), Valid: true}, note.NoteID); err != nil {
+			return fmt.Errorf("failed to delete note %s via api: %w", note.NoteID, err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// handleAPIListImages returns the most recently added images.
+func handleAPIListImages(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		limit, err := apiLimit(r)
+		if err != nil {
+			return err
+		}
+
+		images, err := queries.RecentImages(r.Context(), limit)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve recent images for api: %w", err)
+		}
+
+		resp := apiImageList{Images: make([]apiImage, len(images))}
+		for i := range images {
+			resp.Images[i] = newAPIImage(&images[i], baseURL)
+		}
+		return jsonResponse(w, &resp)
+	}
+}
+
+// handleAPIUploadImage adds an image uploaded as the "image" field of a multipart form.
+func handleAPIUploadImage(queries *db.Queries, images *imgstore.Store, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) (err error) {
+		f, h, err := r.FormFile("image")
+		if err != nil {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "An image file is required."}
+		}
+		defer func() {
+			err = errors.Join(err, f.Close())
+		}()
+
+		id := uuid.New()
+
+		filename, format, err := images.Add(r.Context(), id, f)
+		if errors.Is(err, imgstore.ErrInvalidImage) {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "The image is invalid or in an unsupported format."}
+		} else if err != nil {
+			return fmt.Errorf("failed to add uploaded image to store via api: %w", err)
+		}
+
+		image := db.Image{ImageID: id.String(), Filename: filename, OriginalFilename: h.Filename, Format: format, CreatedAt: time.Now()}
+		if err := queries.CreateImage(r.Context(), image.ImageID, image.Filename, image.OriginalFilename, image.Format, image.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create image record in database via api: %w", err)
+		}
+
+		return apiResponse(w, http.StatusCreated, newAPIImage(&image, baseURL))
+	}
+}
+
+// handleAPIErrors converts the errors returned by API handlers into structured JSON error responses.
+func handleAPIErrors(handler appHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := handler(w, r); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				slog.ErrorContext(r.Context(), "error handling api request", "err", err)
+				apiErr = &apiError{Status: http.StatusInternalServerError, Code: "internal_error", Message: "Internal server error."}
+			}
+			_ = apiResponse(w, apiErr.Status, map[string]*apiError{"error": apiErr})
+		}
+	})
+}
+
+// apiResponse writes the given value as a JSON response with the given status code.
+func apiResponse(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		return fmt.Errorf("failed to write JSON response: %w", err)
+	}
+	return nil
+}
+
+// apiError is an error which is returned to API clients.
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// isAPIRequest returns true if the request is for the JSON API.
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiPrefix)
+}
+
+// apiEditableNote returns the note with the ID in the request's path, or a not found error.
+func apiEditableNote(r *http.Request, queries *db.Queries) (db.Note, error) {
+	note, err := queries.EditableNoteByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return note, &apiError{Status: http.StatusNotFound, Code: "not_found", Message: "Note not found."}
+	} else if err != nil {
+		return note, fmt.Errorf("failed to retrieve note for api: %w", err)
+	}
+	return note, nil
+}
+
+// apiLimit returns the requested page size, which defaults to 20.
+func apiLimit(r *http.Request) (int64, error) {
+	s := r.FormValue("limit")
+	if s == "" {
+		return feedPageSize, nil
+	}
+
+	limit, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || limit < 1 || limit > maxAPIPageSize {
+		return 0, &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("The limit must be between 1 and %d.", maxAPIPageSize)}
+	}
+	return limit, nil
+}
+
+// decodeAPIRequest decodes the request's JSON body into a note request.
+func decodeAPIRequest(r *http.Request, req *apiNoteRequest) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Invalid JSON request: " + err.Error()}
+	}
+
+	if strings.TrimSpace(req.Body) == "" {
+		return &apiError{Status: http.StatusUnprocessableEntity, Code: "invalid_note", Message: "A note body is required."}
+	}
+	return nil
+}
+
+type apiNoteRequest struct {
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Draft     bool       `json:"draft"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// publishAt returns the time at which the note should be published, which defaults to the current time.
+func (req *apiNoteRequest) publishAt() time.Time {
+	if req.PublishAt == nil {
+		return time.Now()
+	}
+	return *req.PublishAt
+}
+
+type apiNote struct {
+	ID        string     `json:"id"`
+	URL       string     `json:"url"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Draft     bool       `json:"draft"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func newAPINote(note *db.Note, baseURL *url.URL) apiNote {
+	n := apiNote{
+		ID:        note.NoteID,
+		URL:       baseURL.JoinPath("note", note.NoteID).String(),
+		Title:     note.Title,
+		Body:      note.Body,
+		Draft:     note.Draft,
+		CreatedAt: note.CreatedAt,
+	}
+	if note.UpdatedAt.Valid {
+		n.UpdatedAt = &note.UpdatedAt.Time
+	}
+	return n
+}
+
+type apiNoteList struct {
+	Notes []apiNote `json:"notes"`
+}
+
+type apiImage struct {
+	ID               string    `json:"id"`
+	URL              string    `json:"url"`
+	ThumbnailURL     string    `json:"thumbnail_url"`
+	OriginalFilename string    `json:"original_filename"`
+	Format           string    `json:"format"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newAPIImage(image *db.Image, baseURL *url.URL) apiImage {
+	return apiImage{
+		ID:               image.ImageID,
+		URL:              baseURL.JoinPath("images", "feed", image.Filename).String(),
+		ThumbnailURL:     baseURL.JoinPath("images", "thumb", image.Filename).String(),
+		OriginalFilename: image.OriginalFilename,
+		Format:           image.Format,
+		CreatedAt:        image.CreatedAt,
+	}
+}
+
+type apiImageList struct {
+	Images []apiImage `json:"images"`
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAPINotes(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	token := createTestAPIToken(t, app, scopeNotesRead+" "+scopeNotesWrite)
+
+	do := func(method, path, body string) *http.Response {
+		req := httptest.NewRequest(method, "http://example.com"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	// Create a draft.
+	resp := do(http.MethodPost, "/api/v1/notes", `{"title":"Hello","body":"It's a #test.","draft":true}`)
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var note apiNote
+	if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/api/v1/notes/"+note.ID; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %q, want = %q`, got, want)
+	}
+
+	if got, want := note.Draft, true; got != want {
+		t.Errorf("note.Draft = %v, want = %v", got, want)
+	}
+
+	// Drafts aren't listed.
+	resp = do(http.MethodGet, "/api/v1/notes", "")
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := string(body), `{"notes":[]}`+"\n"; got != want {
+		t.Errorf("body = %q, want = %q", got, want)
+	}
+
+	// Publish the draft.
+	resp = do(http.MethodPut, "/api/v1/notes/"+note.ID, `{"title":"Hello","body":"It's an updated #test."}`)
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := note.Draft, false; got != want {
+		t.Errorf("note.Draft = %v, want = %v", got, want)
+	}
+
+	if got, want := note.Body, "It's an updated #test."; got != want {
+		t.Errorf("note.Body = %q, want = %q", got, want)
+	}
+
+	// Published notes are listed.
+	resp = do(http.MethodGet, "/api/v1/notes?limit=5", "")
+	var list apiNoteList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(list.Notes), 1; got != want {
+		t.Fatalf("len(list.Notes) = %d, want = %d", got, want)
+	}
+
+	if got, want := list.Notes[0].URL, "http://example.com/note/"+note.ID; got != want {
+		t.Errorf("list.Notes[0].URL = %q, want = %q", got, want)
+	}
+
+	// Published notes can't be made drafts or rescheduled.
+	for _, update := range []string{
+		`{"body":"It's an updated #test.","draft":true}`,
+		`{"body":"It's an updated #test.","publish_at":"2099-01-01T00:00:00Z"}`,
+	} {
+		resp = do(http.MethodPut, "/api/v1/notes/"+note.ID, update)
+		if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", update, got, want)
+		}
+	}
+
+	// Delete the note.
+	resp = do(http.MethodDelete, "/api/v1/notes/"+note.ID, "")
+	if got, want := resp.StatusCode, http.StatusNoContent; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	resp = do(http.MethodGet, "/api/v1/notes/"+note.ID, "")
+	body, _ = io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := string(body), `{"error":{"code":"not_found","message":"Note not found."}}`+"\n"; got != want {
+		t.Errorf("body = %q, want = %q", got, want)
+	}
+}
+
+func TestAPIRescheduleNote(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	token := createTestAPIToken(t, app, scopeNotesRead+" "+scopeNotesWrite)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Soon.", time.Now().Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	publishAt := time.Now().Add(48 * time.Hour).Truncate(time.Second).UTC()
+	req := httptest.NewRequest(http.MethodPut, "http://example.com/api/v1/notes/"+noteID,
+		strings.NewReader(`{"body":"Later.","publish_at":"`+publishAt.Format(time.RFC3339)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var note apiNote
+	if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := note.CreatedAt, publishAt; !got.Equal(want) {
+		t.Errorf("note.CreatedAt = %v, want = %v", got, want)
+	}
+
+	if got, want := note.Body, "Later."; got != want {
+		t.Errorf("note.Body = %q, want = %q", got, want)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		method, path, body string
+		session            bool
+		wantStatus         int
+		wantCode           string
+	}{
+		"unauthenticated": {http.MethodGet, "/api/v1/notes", "", false, http.StatusUnauthorized, "unauthorized"},
+		"invalid json":    {http.MethodPost, "/api/v1/notes", `{"body":`, true, http.StatusBadRequest, "invalid_request"},
+		"unknown field":   {http.MethodPost, "/api/v1/notes", `{"text":"hi"}`, true, http.StatusBadRequest, "invalid_request"},
+		"empty body":      {http.MethodPost, "/api/v1/notes", `{"title":"hi"}`, true, http.StatusUnprocessableEntity, "invalid_note"},
+		"invalid limit":   {http.MethodGet, "/api/v1/images?limit=1000", "", true, http.StatusBadRequest, "invalid_request"},
+	} {
+		req := httptest.NewRequest(tc.method, "http://example.com"+tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Sec-Fetch-Site", "same-origin")
+		if tc.session {
+			req.AddCookie(&http.Cookie{
+				Name:  "sessionID",
+				Value: sessionID,
+			})
+		}
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		resp := w.Result()
+
+		if got, want := resp.StatusCode, tc.wantStatus; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", name, got, want)
+		}
+
+		var body struct {
+			Error apiError `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if got, want := body.Error.Code, tc.wantCode; got != want {
+			t.Errorf("%s: body.Error.Code = %q, want = %q", name, got, want)
+		}
+	}
+}
+
+func TestAPIUploadInvalidImage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	file, _ := mw.CreateFormFile("image", "notes.txt")
+	_, _ = file.Write([]byte("This is not an image."))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/api/v1/images", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := body.Error.Code, "invalid_request"; got != want {
+		t.Errorf("body.Error.Code = %q, want = %q", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/google/uuid"
+)
+
+// The scopes which can be granted to API tokens.
+const (
+	scopeNotesRead   = "notes:read"
+	scopeNotesWrite  = "notes:write"
+	scopeImagesRead  = "images:read"
+	scopeImagesWrite = "images:write"
+)
+
+// apiTokenScopes is the set of scopes which can be granted to API tokens, in display order.
+//
+//nolint:gochecknoglobals // lookup table
+var apiTokenScopes = []string{scopeNotesRead, scopeNotesWrite, scopeImagesRead, scopeImagesWrite}
+
+// apiTokenRoutes maps the routes which can be used with API tokens to the scope they require. Any other route under an
+// authenticated prefix requires a session, so a token can't be used to e.g. create more tokens or register passkeys.
+//
+//nolint:gochecknoglobals // lookup table
+var apiTokenRoutes = map[string]string{
+	"POST /admin/new":               scopeNotesWrite,
+	"POST /admin/note/{id}/edit":    scopeNotesWrite,
+	"POST /admin/note/{id}/publish": scopeNotesWrite,
+	"POST /admin/note/{id}/delete":  scopeNotesWrite,
+	"POST /admin/note/{id}/restore": scopeNotesWrite,
+	"POST /admin/images/download":   scopeImagesWrite,
+	"POST /admin/images/upload":     scopeImagesWrite,
+	"GET /api/v1/notes":             scopeNotesRead,
+	"GET /api/v1/notes/{id}":        scopeNotesRead,
+	"POST /api/v1/notes":            scopeNotesWrite,
+	"PUT /api/v1/notes/{id}":        scopeNotesWrite,
+	"DELETE /api/v1/notes/{id}":     scopeNotesWrite,
+	"GET /api/v1/images":            scopeImagesRead,
+	"POST /api/v1/images":           scopeImagesWrite,
+	"GET /micropub":                 scopeNotesRead,
+	"POST /micropub":                scopeNotesWrite,
+	"POST /micropub/media":          scopeImagesWrite,
+}
+
+// handleAPITokensPage renders the admin page for managing API tokens.
+func handleAPITokensPage(queries *db.Queries, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tokens, err := queries.APITokens(r.Context())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve api tokens: %w", err)
+		}
+
+		return htmlResponse(w, t, "tokens.gohtml", &apiTokensPage{Tokens: tokens, Scopes: apiTokenScopes})
+	}
+}
+
+// handleCreateAPIToken creates a new API token and renders the tokens page with the token's value, which is only ever
+// shown this once.
+func handleCreateAPIToken(queries *db.Queries, tokens *tokenHasher, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" {
+			http.Error(w, "A token name is required.", http.StatusBadRequest)
+			return nil
+		}
+
+		scopes := r.Form["scope"]
+		if len(scopes) == 0 {
+			http.Error(w, "At least one scope is required.", http.StatusBadRequest)
+			return nil
+		}
+
+		for _, scope := range scopes {
+			if !slices.Contains(apiTokenScopes, scope) {
+				http.Error(w, "Invalid scope.", http.StatusBadRequest)
+				return nil
+			}
+		}
+
+		now := time.Now()
+		var expiresAt sql.NullTime
+		if s := r.FormValue("expires_in"); s != "" {
+			days, err := strconv.Atoi(s)
+			if err != nil || days <= 0 {
+				http.Error(w, "Invalid expiry.", http.StatusBadRequest)
+				return nil
+			}
+			expiresAt = sql.NullTime{Time: now.AddDate(0, 0, days), Valid: true}
+		}
+
+		token, err := createAPIToken(r.Context(), queries, tokens, name, scopes, now, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		apiTokens, err := queries.APITokens(r.Context())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve api tokens: %w", err)
+		}
+
+		return htmlResponse(w, t, "tokens.gohtml", &apiTokensPage{Tokens: apiTokens, Scopes: apiTokenScopes, NewToken: token})
+	}
+}
+
+// handleRevokeAPIToken deletes an API token.
+func handleRevokeAPIToken(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := queries.DeleteAPIToken(r.Context(), r.PathValue("id")); err != nil {
+			return fmt.Errorf("failed to delete api token: %w", err)
+		}
+
+		http.Redirect(w, r, baseURL.JoinPath("admin", "tokens").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+type apiTokensPage struct {
+	Tokens   []db.APIToken
+	Scopes   []string
+	NewToken string
+}
+
+// createAPIToken creates a new API token with the given name and scopes and returns its value.
+func createAPIToken(ctx context.Context, queries *db.Queries, tokens *tokenHasher, name string, scopes []string, createdAt time.Time, expiresAt sql.NullTime) (string, error) {
+	token := rand.Text()
+	if err := queries.CreateAPIToken(ctx, uuid.NewString(), tokens.hash(token), name, strings.Join(scopes, " "), createdAt, expiresAt); err != nil {
+		return "", fmt.Errorf("failed to create api token: %w", err)
+	}
+	return token, nil
+}
+
+// tokenAllows returns true if a token with the given scopes can be used for the route with the given pattern, either via
+// the scope the route requires or via an IndieAuth scope which allows it.
+func tokenAllows(scopes []string, pattern string) bool {
+	for _, scope := range scopes {
+		if scope == apiTokenRoutes[pattern] || slices.Contains(indieAuthRoutes[pattern], scope) {
+			return true
+		}
+	}
+	return false
+}
+
+type apiTokenScopesKey struct{}
+
+// withAPITokenScopes returns a copy of the request with the scopes of its API token in the context.
+func withAPITokenScopes(r *http.Request, scopes []string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), apiTokenScopesKey{}, scopes))
+}
+
+// apiTokenScopesFrom returns the scopes of the request's API token, if the request was authenticated with one.
+func apiTokenScopesFrom(r *http.Request) ([]string, bool) {
+	scopes, ok := r.Context().Value(apiTokenScopesKey{}).([]string)
+	return scopes, ok
+}
+
+// errInvalidAPIToken is returned when a bearer token is unknown or expired.
+var errInvalidAPIToken = errors.New("invalid api token")
+
+// authenticateAPIToken returns the scopes of the request's bearer token and records the token as having been used.
+func authenticateAPIToken(r *http.Request, queries *db.Queries, tokens *tokenHasher, token string) ([]string, error) {
+	now := time.Now()
+	apiToken, err := queries.APITokenByHash(r.Context(), tokens.hash(token), sql.NullTime{Time: now, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errInvalidAPIToken
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to retrieve api token: %w", err)
+	}
+
+	if err := queries.UpdateAPITokenUsage(r.Context(), sql.NullTime{Time: now, Valid: true}, apiToken.APITokenID); err != nil {
+		return nil, fmt.Errorf("failed to update api token usage: %w", err)
+	}
+
+	return strings.Fields(apiToken.Scopes), nil
+}
+
+// maxTokenFormSize is the largest form-encoded request body which is parsed for an access token.
+const maxTokenFormSize = 1 << 20
+
+// bearerToken returns the bearer token from the request's Authorization header, if any. Micropub clients may instead send
+// the token as a parameter of a form-encoded body, as allowed by RFC 6750.
+func bearerToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token), true
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); r.Method == http.MethodPost &&
+		mediaType == "application/x-www-form-urlencoded" && isMicropubRequest(r) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxTokenFormSize)
+		if token := r.PostFormValue("access_token"); token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// bypassCSRFForBearerTokens serves requests with bearer tokens directly and all others via the CSRF-protected handler.
+// Browsers never attach an Authorization header to cross-origin requests on their own, and requests with bearer tokens
+// are authenticated by the token alone, so they aren't vulnerable to CSRF.
+func bypassCSRFForBearerTokens(protected, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := bearerToken(w, r); ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAPITokensCreate(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{"name": {"CI"}, "scope": {scopeNotesWrite}, "expires_in": {"30"}}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/tokens", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	m := regexp.MustCompile(`<pre><code>([A-Z2-7]+)</code></pre>`).FindSubmatch(body)
+	if m == nil {
+		t.Fatalf("body = %q, want new token", body)
+	}
+	token := string(m[1])
+
+	tokens, err := app.queries.APITokens(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(tokens), 1; got != want {
+		t.Fatalf("len(tokens) = %d, want = %d", got, want)
+	}
+
+	if got, want := tokens[0].TokenHash, app.tokens.hash(token); got != want {
+		t.Errorf("tokens[0].TokenHash = %q, want = %q", got, want)
+	}
+
+	if got, want := tokens[0].Scopes, scopeNotesWrite; got != want {
+		t.Errorf("tokens[0].Scopes = %q, want = %q", got, want)
+	}
+
+	if got, want := tokens[0].ExpiresAt.Valid, true; got != want {
+		t.Errorf("tokens[0].ExpiresAt.Valid = %v, want = %v", got, want)
+	}
+}
+
+func TestAPITokensAuthentication(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	createToken := func(scopes string, expiresAt sql.NullTime) string {
+		token := uuid.NewString()
+		if err := app.queries.CreateAPIToken(t.Context(), uuid.NewString(), app.tokens.hash(token), "test", scopes, time.Now(), expiresAt); err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	notesToken := createToken(scopeNotesWrite, sql.NullTime{})
+	imagesToken := createToken(scopeImagesWrite, sql.NullTime{})
+	expiredToken := createToken(scopeNotesWrite, sql.NullTime{Time: time.Now().Add(-1 * time.Hour), Valid: true})
+
+	for name, tc := range map[string]struct {
+		method, path, token string
+		want                int
+	}{
+		"valid":              {http.MethodPost, "/admin/new", notesToken, http.StatusSeeOther},
+		"wrong scope":        {http.MethodPost, "/admin/new", imagesToken, http.StatusForbidden},
+		"session-only route": {http.MethodGet, "/admin/tokens", notesToken, http.StatusForbidden},
+		"expired":            {http.MethodPost, "/admin/new", expiredToken, http.StatusUnauthorized},
+		"unknown":            {http.MethodPost, "/admin/new", uuid.NewString(), http.StatusUnauthorized},
+	} {
+		form := url.Values{"body": {"Posted from a script."}}
+		req := httptest.NewRequest(tc.method, "http://example.com"+tc.path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		// Bearer tokens aren't subject to CSRF protection.
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		if got, want := w.Result().StatusCode, tc.want; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", name, got, want)
+		}
+	}
+
+	tokens, err := app.queries.APITokens(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range tokens {
+		if token.TokenHash == app.tokens.hash(notesToken) && !token.LastUsedAt.Valid {
+			t.Error("token.LastUsedAt.Valid = false, want = true")
+		}
+	}
+}
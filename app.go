@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,15 +17,17 @@ import (
 	"github.com/CAFxX/httpcompression"
 	"github.com/codahale/yellhole-go/internal/db"
 	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/codahale/yellhole-go/internal/mastodon"
 	sloghttp "github.com/samber/slog-http"
 	"github.com/valyala/bytebufferpool"
 )
 
-// newApp constructs an application handler given the various application inputs.
-func newApp(ctx context.Context, logger *slog.Logger, queries *db.Queries, images *imgstore.Store, baseURL, author, title, description, lang, buildTag string, requestLog bool) (http.Handler, error) {
-	u, err := url.Parse(baseURL)
+// newApp constructs an application handler given the configuration and the various application inputs. The client is
+// used for requests to URLs given by third parties, such as Webmention sources and ActivityPub actors.
+func newApp(ctx context.Context, logger *slog.Logger, cfg *Config, queries *db.Queries, images *imgstore.Store, client *http.Client, tokenKey []byte, actorKey *rsa.PrivateKey, buildTag string, requestLog bool) (http.Handler, error) {
+	u, err := url.Parse(cfg.BaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse base URL %q: %w", baseURL, err)
+		return nil, fmt.Errorf("failed to parse base URL %q: %w", cfg.BaseURL, err)
 	}
 
 	// Ensure the base URL always ends in a slash.
@@ -32,9 +35,76 @@ func newApp(ctx context.Context, logger *slog.Logger, queries *db.Queries, image
 		u.Path += "/"
 	}
 
+	// Parse hashtags for any notes created before hashtags were supported.
+	if err := backfillNoteTags(ctx, queries); err != nil {
+		return nil, fmt.Errorf("failed to backfill note tags: %w", err)
+	}
+
+	policy := sessionPolicy{IdleTimeout: time.Duration(cfg.SessionIdleTimeout), Lifetime: time.Duration(cfg.SessionLifetime)}
+
 	// Set up a purgeTicker to purge old sessions every five minutes.
 	purgeTicker := time.NewTicker(5 * time.Minute)
-	go purgeOldRows(ctx, logger, queries, purgeTicker)
+	go purgeOldRows(ctx, logger, queries, policy, purgeTicker)
+
+	// Construct the instance's ActivityPub actor.
+	actor := &actor{username: cfg.Username, name: cfg.Author, summary: cfg.Description, baseURL: u, key: actorKey}
+
+	// Cross-post notes to Mastodon, if configured.
+	var targets []syndicationTarget
+	if cfg.MastodonURL != "" {
+		server, err := url.Parse(cfg.MastodonURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Mastodon URL %q: %w", cfg.MastodonURL, err)
+		}
+
+		mastodonClient := &mastodon.Client{Server: server, Token: cfg.MastodonToken, HTTP: &http.Client{Timeout: 30 * time.Second}, PollInterval: 1 * time.Second}
+		targets = append(targets, &mastodonTarget{client: mastodonClient, images: images, baseURL: u, lang: cfg.Lang})
+	}
+
+	// Notify WebSub subscribers of new notes via an external hub or the built-in hub, if configured.
+	ws := newWebSub(u, cfg.WebSubHub, cfg.WebSubBuiltinHub, client)
+
+	// Set up an announceTicker to run publish hooks for newly-visible notes every minute.
+	hooks := []publishHook{webmentionHook(queries), activityPubHook(queries, actor), syndicationHook(queries, targets), webSubHook(queries, ws)}
+	announceTicker := time.NewTicker(1 * time.Minute)
+	go runPeriodically(ctx, logger, announceTicker, "announcing notes", func(ctx context.Context) error {
+		return announceNotes(ctx, logger, queries, hooks)
+	})
+
+	// Set up a verifyTicker to verify received Webmentions and a sendTicker to send queued Webmentions every minute.
+	verifyTicker := time.NewTicker(1 * time.Minute)
+	go runPeriodically(ctx, logger, verifyTicker, "verifying webmentions", func(ctx context.Context) error {
+		return verifyWebmentions(ctx, logger, queries, client)
+	})
+	sendTicker := time.NewTicker(1 * time.Minute)
+	go runPeriodically(ctx, logger, sendTicker, "sending webmentions", func(ctx context.Context) error {
+		return sendWebmentions(ctx, logger, queries, client, u)
+	})
+
+	// Set up a deliverTicker to deliver queued ActivityPub activities every minute.
+	deliverTicker := time.NewTicker(1 * time.Minute)
+	go runPeriodically(ctx, logger, deliverTicker, "delivering activities", func(ctx context.Context) error {
+		return deliverActivities(ctx, logger, queries, client, actor)
+	})
+
+	// Set up a syndicateTicker to syndicate queued notes every minute.
+	syndicateTicker := time.NewTicker(1 * time.Minute)
+	go runPeriodically(ctx, logger, syndicateTicker, "syndicating notes", func(ctx context.Context) error {
+		return syndicateNotes(ctx, logger, queries, targets)
+	})
+
+	// Set up a hubTicker to send hub notifications every minute and, if the built-in hub is enabled, a
+	// subscriptionTicker to verify hub subscriptions every minute.
+	hubTicker := time.NewTicker(1 * time.Minute)
+	go runPeriodically(ctx, logger, hubTicker, "notifying hubs", func(ctx context.Context) error {
+		return notifyHubs(ctx, logger, queries, ws)
+	})
+	if cfg.WebSubBuiltinHub {
+		subscriptionTicker := time.NewTicker(1 * time.Minute)
+		go runPeriodically(ctx, logger, subscriptionTicker, "verifying hub subscriptions", func(ctx context.Context) error {
+			return verifyHubSubscriptions(ctx, logger, queries, ws)
+		})
+	}
 
 	// Load the embedded public assets.
 	assetPaths, assetHashes, assets, err := loadAssets()
@@ -43,20 +113,31 @@ func newApp(ctx context.Context, logger *slog.Logger, queries *db.Queries, image
 	}
 
 	// Load the embedded templates.
-	templates, err := loadTemplates(author, title, description, lang, buildTag, u, assetHashes)
+	templates, err := loadTemplates(cfg, buildTag, u, assetHashes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load templates: %w", err)
 	}
 
+	// Hash session tokens with the token key.
+	tokens := &tokenHasher{key: tokenKey}
+
 	// Construct a route map of handlers.
 	mux := http.NewServeMux()
-	addRoutes(mux, author, title, description, u, logger, queries, templates, images, assets, assetPaths)
+	addRoutes(mux, cfg, u, ws, logger, queries, actor, client, tokens, policy, templates, images, assets, assetPaths)
+
+	// Distribute feeds to WebSub subscribers from the route map.
+	ws.feeds = mux
 
-	// Require authentication for all /admin requests.
-	handler := requireAuthentication(queries, mux, u, "/admin")
+	// Require authentication for all /admin, API, and Micropub requests.
+	handler := requireAuthentication(queries, tokens, policy, mux, u, "/admin", apiPrefix, micropubPrefix)
 
-	// Protect from CSRF attacks.
-	handler = http.NewCrossOriginProtection().Handler(handler)
+	// Protect from CSRF attacks, except for requests authenticated with API tokens, the IndieAuth endpoints used by
+	// clients, and the Webmention, ActivityPub inbox, and WebSub hub endpoints, none of which rely on cookies.
+	csrf := http.NewCrossOriginProtection()
+	for _, pattern := range []string{"POST /indieauth/auth", "POST /indieauth/token", "POST /indieauth/introspect", "POST /indieauth/revoke", "POST /webmention", "POST /activitypub/inbox", "POST /websub"} {
+		csrf.AddInsecureBypassPattern(pattern)
+	}
+	handler = bypassCSRFForBearerTokens(csrf.Handler(handler), handler)
 
 	// Add compression for responses.
 	compress, err := httpcompression.DefaultAdapter(httpcompression.ContentTypes([]string{"text/html", "text/css", "text/javascript"}, false))
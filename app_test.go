@@ -1,22 +1,32 @@
 package main
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
 	"log/slog"
 	"net/http"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/codahale/yellhole-go/internal/db"
 	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/google/uuid"
 )
 
 type testApp struct {
 	queries *db.Queries
+	tokens  *tokenHasher
 	tempDir string
 	t       *testing.T
 	http.Handler
 }
 
+// testSessionPolicy is the session policy used by test apps.
+var testSessionPolicy = sessionPolicy{IdleTimeout: 7 * 24 * time.Hour, Lifetime: 30 * 24 * time.Hour} //nolint:gochecknoglobals // test fixture
+
 func newTestApp(t *testing.T) *testApp {
 	logger := slog.New(slog.DiscardHandler)
 	t.Helper()
@@ -47,10 +57,45 @@ func newTestApp(t *testing.T) *testApp {
 		}
 	})
 
-	app, err := newApp(t.Context(), logger, queries, images, "http://example.com", "Test Man", "Test Yell", "Gotta go fast.", "en", "00000000", false)
+	tokenKey, err := loadTokenKey(filepath.Join(tempDir, "token.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		BaseURL:            "http://example.com",
+		Author:             "Test Man",
+		Username:           "notes",
+		Title:              "Test Yell",
+		Description:        "Gotta go fast.",
+		Lang:               "en",
+		SessionIdleTimeout: duration(testSessionPolicy.IdleTimeout),
+		SessionLifetime:    duration(testSessionPolicy.Lifetime),
+	}
+
+	app, err := newApp(t.Context(), logger, cfg, queries, images, http.DefaultClient, tokenKey, testActorKey(), "00000000", false)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	return &testApp{queries, tempDir, t, app}
+	return &testApp{queries, &tokenHasher{key: tokenKey}, tempDir, t, app}
+}
+
+// testActorKey returns the actor key used by test apps. It's generated once, since generating RSA keys is slow.
+var testActorKey = sync.OnceValue(func() *rsa.PrivateKey { //nolint:gochecknoglobals // test fixture
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+func createTestAPIToken(t *testing.T, app *testApp, scopes string) string {
+	t.Helper()
+
+	token := uuid.NewString()
+	if err := app.queries.CreateAPIToken(t.Context(), uuid.NewString(), app.tokens.hash(token), "test", scopes, time.Now(), sql.NullTime{}); err != nil {
+		t.Fatal(err)
+	}
+	return token
 }
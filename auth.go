@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,23 +20,28 @@ import (
 	sloghttp "github.com/samber/slog-http"
 )
 
-func handleRegisterPage(queries *db.Queries, t *template.Template, baseURL *url.URL) appHandler {
+func handleRegisterPage(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, t *template.Template, baseURL *url.URL) appHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		// Ensure we only register one passkey.
+		// Additional passkeys are registered from the passkeys page, which requires a session.
 		registered, err := queries.HasWebauthnCredential(r.Context())
 		if err != nil {
 			return fmt.Errorf("failed to check for existing webauthn credential: %w", err)
 		}
-		if registered {
-			http.Redirect(w, r, baseURL.JoinPath("login").String(), http.StatusSeeOther)
-			return nil
-		}
 
-		// Ensure the session isn't authenticated.
-		auth, err := isAuthenticated(r, queries)
+		auth, err := isAuthenticated(r, queries, tokens, policy)
 		if err != nil {
 			return fmt.Errorf("failed to check authentication status in register page: %w", err)
 		}
+
+		if registered {
+			if auth {
+				http.Redirect(w, r, baseURL.JoinPath("admin", "passkeys").String(), http.StatusSeeOther)
+			} else {
+				http.Redirect(w, r, baseURL.JoinPath("login").String(), http.StatusSeeOther)
+			}
+			return nil
+		}
+
 		if auth {
 			http.Redirect(w, r, baseURL.JoinPath("admin").String(), http.StatusSeeOther)
 			return nil
@@ -45,24 +52,26 @@ func handleRegisterPage(queries *db.Queries, t *template.Template, baseURL *url.
 	}
 }
 
-func handleRegisterStart(queries *db.Queries, author, title string, baseURL *url.URL) appHandler {
+func handleRegisterStart(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, author, title string, baseURL *url.URL) appHandler {
 	webAuthn := newWebauthn(title, baseURL)
 
 	return func(w http.ResponseWriter, r *http.Request) error {
-		// Ensure we only register one passkey.
-		registered, err := queries.HasWebauthnCredential(r.Context())
+		// Ensure only authenticated sessions can register additional passkeys.
+		credentials, ok, err := registrationCredentials(r, queries, tokens, policy)
 		if err != nil {
-			return fmt.Errorf("failed to check for existing webauthn credential: %w", err)
+			return err
 		}
-		if registered {
-			http.Error(w, "You already have a passkey registered.", http.StatusBadRequest)
+		if !ok {
+			http.Error(w, "You must be logged in to register another passkey.", http.StatusUnauthorized)
 			return nil
 		}
 
-		// Create a new webauthn attestation challenge.
+		// Create a new webauthn attestation challenge, excluding any already-registered passkeys.
+		user := webauthnUser{author, credentials}
 		creation, session, err := webAuthn.BeginRegistration(
-			webauthnUser{author, []*db.JSONCredential{}},
+			user,
 			webauthn.WithCredentialParameters(webauthn.CredentialParametersRecommendedL3()),
+			webauthn.WithExclusions(webauthn.Credentials(user.WebAuthnCredentials()).CredentialDescriptors()),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to begin webauthn registration: %w", err)
@@ -70,7 +79,7 @@ func handleRegisterStart(queries *db.Queries, author, title string, baseURL *url
 
 		// Store the webauthn session data in the DB.
 		regSessionID := uuid.NewString()
-		if err := queries.CreateWebauthnSession(r.Context(), regSessionID, db.JSON(*session), time.Now()); err != nil {
+		if err := queries.CreateWebauthnSession(r.Context(), tokens.hash(regSessionID), db.JSON(*session), time.Now()); err != nil {
 			return fmt.Errorf("failed to create webauthn session: %w", err)
 		}
 
@@ -82,17 +91,17 @@ func handleRegisterStart(queries *db.Queries, author, title string, baseURL *url
 	}
 }
 
-func handleRegisterFinish(logger *slog.Logger, queries *db.Queries, author, title string, baseURL *url.URL) appHandler {
+func handleRegisterFinish(logger *slog.Logger, queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, author, title string, baseURL *url.URL) appHandler {
 	webAuthn := newWebauthn(title, baseURL)
 
 	return func(w http.ResponseWriter, r *http.Request) error {
-		// Ensure we only register one passkey.
-		registered, err := queries.HasWebauthnCredential(r.Context())
+		// Ensure only authenticated sessions can register additional passkeys.
+		credentials, ok, err := registrationCredentials(r, queries, tokens, policy)
 		if err != nil {
-			return fmt.Errorf("failed to check for existing webauthn credential: %w", err)
+			return err
 		}
-		if registered {
-			http.Error(w, "You already have a passkey registered.", http.StatusBadRequest)
+		if !ok {
+			http.Error(w, "You must be logged in to register another passkey.", http.StatusUnauthorized)
 			return nil
 		}
 
@@ -103,13 +112,13 @@ func handleRegisterFinish(logger *slog.Logger, queries *db.Queries, author, titl
 		}
 
 		// Read, delete, and decode the webauthn session data.
-		session, err := queries.DeleteWebauthnSession(r.Context(), regSessionID.Value, time.Now().Add(-1*time.Minute))
+		session, err := queries.DeleteWebauthnSession(r.Context(), tokens.hash(regSessionID.Value), time.Now().Add(-1*time.Minute))
 		if err != nil {
 			return fmt.Errorf("failed to retrieve and delete webauthn session: %w", err)
 		}
 
 		// Validate the attestation response.
-		cred, err := webAuthn.FinishRegistration(webauthnUser{author, []*db.JSONCredential{}}, session.Data, r)
+		cred, err := webAuthn.FinishRegistration(webauthnUser{author, credentials}, session.Data, r)
 		if err != nil {
 			// If the attestation is invalid, respond with verified=false.
 			logger.ErrorContext(r.Context(), "unable to finish passkey registration", "err", err, "id", sloghttp.GetRequestID(r))
@@ -117,7 +126,8 @@ func handleRegisterFinish(logger *slog.Logger, queries *db.Queries, author, titl
 		}
 
 		// Store the new credential in the database.
-		if err := queries.CreateWebauthnCredential(r.Context(), db.JSON(cred), time.Now()); err != nil {
+		nickname := strings.TrimSpace(r.URL.Query().Get("nickname"))
+		if err := queries.CreateWebauthnCredential(r.Context(), uuid.NewString(), nickname, db.JSON(cred), time.Now()); err != nil {
 			return fmt.Errorf("failed to create webauthn credential: %w", err)
 		}
 
@@ -129,7 +139,7 @@ func handleRegisterFinish(logger *slog.Logger, queries *db.Queries, author, titl
 	}
 }
 
-func handleLoginPage(queries *db.Queries, t *template.Template, baseURL *url.URL) appHandler {
+func handleLoginPage(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, t *template.Template, baseURL *url.URL) appHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		// Redirect to registration if no credentials exist.
 		registered, err := queries.HasWebauthnCredential(r.Context())
@@ -142,27 +152,28 @@ func handleLoginPage(queries *db.Queries, t *template.Template, baseURL *url.URL
 		}
 
 		// Ensure the session isn't authenticated.
-		auth, err := isAuthenticated(r, queries)
+		auth, err := isAuthenticated(r, queries, tokens, policy)
 		if err != nil {
 			return fmt.Errorf("failed to check authentication status in login page: %w", err)
 		}
 
+		next := loginNext(r, baseURL)
 		if auth {
-			http.Redirect(w, r, baseURL.JoinPath("admin").String(), http.StatusSeeOther)
+			http.Redirect(w, r, next, http.StatusSeeOther)
 			return nil
 		}
 
 		// Respond with the login page.
-		return htmlResponse(w, t, "login.gohtml", nil)
+		return htmlResponse(w, t, "login.gohtml", next)
 	}
 }
 
-func handleLoginStart(queries *db.Queries, author, title string, baseURL *url.URL) appHandler {
+func handleLoginStart(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, author, title string, baseURL *url.URL) appHandler {
 	webAuthn := newWebauthn(title, baseURL)
 
 	return func(w http.ResponseWriter, r *http.Request) error {
 		// Ensure the request isn't already authenticated.
-		auth, err := isAuthenticated(r, queries)
+		auth, err := isAuthenticated(r, queries, tokens, policy)
 		if err != nil {
 			return fmt.Errorf("failed to check authentication status in login start: %w", err)
 		}
@@ -186,7 +197,7 @@ func handleLoginStart(queries *db.Queries, author, title string, baseURL *url.UR
 
 		// Store the challenge in the database.
 		loginSessionID := uuid.NewString()
-		if err := queries.CreateWebauthnSession(r.Context(), loginSessionID, db.JSON(*session), time.Now()); err != nil {
+		if err := queries.CreateWebauthnSession(r.Context(), tokens.hash(loginSessionID), db.JSON(*session), time.Now()); err != nil {
 			return fmt.Errorf("failed to create webauthn login session: %w", err)
 		}
 
@@ -198,12 +209,12 @@ func handleLoginStart(queries *db.Queries, author, title string, baseURL *url.UR
 	}
 }
 
-func handleLoginFinish(logger *slog.Logger, queries *db.Queries, author, title string, baseURL *url.URL) appHandler {
+func handleLoginFinish(logger *slog.Logger, queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, author, title string, baseURL *url.URL) appHandler {
 	webAuthn := newWebauthn(title, baseURL)
 
 	return func(w http.ResponseWriter, r *http.Request) error {
 		// Ensure the request isn't already authenticated.
-		auth, err := isAuthenticated(r, queries)
+		auth, err := isAuthenticated(r, queries, tokens, policy)
 		if err != nil {
 			return fmt.Errorf("failed to check authentication status in login finish: %w", err)
 		}
@@ -226,25 +237,34 @@ func handleLoginFinish(logger *slog.Logger, queries *db.Queries, author, title s
 		}
 
 		// Find and delete it from the database.
-		session, err := queries.DeleteWebauthnSession(r.Context(), loginSessionID.Value, time.Now().Add(-1*time.Minute))
+		session, err := queries.DeleteWebauthnSession(r.Context(), tokens.hash(loginSessionID.Value), time.Now().Add(-1*time.Minute))
 		if err != nil {
 			return fmt.Errorf("failed to retrieve and delete webauthn login session: %w", err)
 		}
 
 		// Validate the webauthn challenge.
-		_, err = webAuthn.FinishLogin(webauthnUser{author, credentials}, session.Data, r)
+		cred, err := webAuthn.FinishLogin(webauthnUser{author, credentials}, session.Data, r)
 		if err != nil {
 			// Respond with verified=false if the challenge response was invalid.
 			logger.ErrorContext(r.Context(), "unable to finish passkey login", "err", err, "id", sloghttp.GetRequestID(r))
 			return jsonResponse(w, map[string]bool{"verified": false})
 		}
 
+		// Record the use of the passkey, along with its updated sign count.
+		for _, c := range credentials {
+			if bytes.Equal(c.CredentialData.Data.ID, cred.ID) {
+				if err := queries.UpdateWebauthnCredentialUsage(r.Context(), db.JSON(cred), sql.NullTime{Time: time.Now(), Valid: true}, c.WebauthnCredentialID); err != nil {
+					return fmt.Errorf("failed to update webauthn credential usage: %w", err)
+				}
+			}
+		}
+
 		// Create a new web session and assign a session cookie.
 		sessionID := uuid.NewString()
-		if err := queries.CreateSession(r.Context(), sessionID, time.Now()); err != nil {
+		if err := queries.CreateSession(r.Context(), tokens.hash(sessionID), r.UserAgent(), remoteIP(r), time.Now()); err != nil {
 			return fmt.Errorf("failed to create session: %w", err)
 		}
-		http.SetCookie(w, secureCookie(baseURL, "sessionID", sessionID, 60*60*24*7))
+		http.SetCookie(w, secureCookie(baseURL, "sessionID", sessionID, policy.cookieMaxAge()))
 
 		// Delete the login session ID cookie.
 		http.SetCookie(w, secureCookie(baseURL, "loginSessionID", "", -1))
@@ -254,10 +274,67 @@ func handleLoginFinish(logger *slog.Logger, queries *db.Queries, author, title s
 	}
 }
 
-func requireAuthentication(queries *db.Queries, h http.Handler, baseURL *url.URL, prefix string) http.Handler {
+// loginNext returns the URL to go to after logging in, which is either the URL given by ?next= or the admin page. Only
+// URLs under the base URL are allowed, to avoid redirecting to other sites.
+func loginNext(r *http.Request, baseURL *url.URL) string {
+	if next := r.FormValue("next"); strings.HasPrefix(next, baseURL.String()) {
+		return next
+	}
+	return baseURL.JoinPath("admin").String()
+}
+
+// registrationCredentials returns the existing webauthn credentials and whether the request is allowed to register a
+// new passkey. The first passkey can be registered by anyone; additional passkeys require an authenticated session.
+func registrationCredentials(r *http.Request, queries *db.Queries, tokens *tokenHasher, policy sessionPolicy) ([]db.WebauthnCredential, bool, error) {
+	credentials, err := queries.WebauthnCredentials(r.Context())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to retrieve webauthn credentials for registration: %w", err)
+	}
+
+	if len(credentials) == 0 {
+		return credentials, true, nil
+	}
+
+	auth, err := isAuthenticated(r, queries, tokens, policy)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check authentication status for registration: %w", err)
+	}
+	return credentials, auth, nil
+}
+
+func requireAuthentication(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, mux *http.ServeMux, baseURL *url.URL, prefixes ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.RequestURI, prefix) {
-			auth, err := isAuthenticated(r, queries)
+		// Match the parsed path rather than the request URI, which may be in absolute form (e.g. http://host/admin).
+		if slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(r.URL.Path, prefix) }) {
+			// Requests with bearer tokens are authenticated by the token alone, and only for the routes it's scoped for.
+			if token, ok := bearerToken(w, r); ok {
+				scopes, err := authenticateAPIToken(r, queries, tokens, token)
+				if errors.Is(err, errInvalidAPIToken) {
+					slog.InfoContext(r.Context(), "invalid api token", "uri", r.RequestURI, "id", sloghttp.GetRequestID(r))
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					denyRequest(w, r, http.StatusUnauthorized, "invalid_token", "The API token is invalid or expired.")
+					return
+				} else if err != nil {
+					slog.ErrorContext(r.Context(), "error handling request", "err", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+
+				_, pattern := mux.Handler(r)
+				if !tokenAllows(scopes, pattern) {
+					slog.InfoContext(r.Context(), "insufficient api token scope", "uri", r.RequestURI, "id", sloghttp.GetRequestID(r))
+					if scope, ok := apiTokenRoutes[pattern]; ok {
+						w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+					}
+					denyRequest(w, r, http.StatusForbidden, "insufficient_scope", "The API token can't be used for this request.")
+					return
+				}
+
+				mux.ServeHTTP(w, withAPITokenScopes(r, scopes))
+				return
+			}
+
+			auth, err := isAuthenticated(r, queries, tokens, policy)
 			if err != nil {
 				slog.ErrorContext(r.Context(), "error handling request", "err", err)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -266,14 +343,43 @@ func requireAuthentication(queries *db.Queries, h http.Handler, baseURL *url.URL
 
 			if !auth {
 				slog.InfoContext(r.Context(), "unauthenticated request", "uri", r.RequestURI, "id", sloghttp.GetRequestID(r))
+				if isAPIRequest(r) || isMicropubRequest(r) {
+					denyRequest(w, r, http.StatusUnauthorized, "unauthorized", "Authentication is required.")
+					return
+				}
 				http.Redirect(w, r, baseURL.JoinPath("login").String(), http.StatusSeeOther)
 				return
 			}
+
+			// Record the session as having been seen and refresh the session cookie.
+			if cookie, err := r.Cookie("sessionID"); err == nil {
+				if err := queries.TouchSession(r.Context(), sql.NullTime{Time: time.Now(), Valid: true}, tokens.hash(cookie.Value)); err != nil {
+					slog.ErrorContext(r.Context(), "error handling request", "err", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				http.SetCookie(w, secureCookie(baseURL, "sessionID", cookie.Value, policy.cookieMaxAge()))
+			}
 		}
-		h.ServeHTTP(w, r)
+		mux.ServeHTTP(w, r)
 	})
 }
 
+// denyRequest responds to a request which isn't authenticated or authorized. API and Micropub requests get structured
+// errors.
+func denyRequest(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if isAPIRequest(r) {
+		_ = apiResponse(w, status, map[string]*apiError{"error": {Status: status, Code: code, Message: message}})
+		return
+	}
+
+	if isMicropubRequest(r) {
+		writeOAuthError(w, &apiError{Status: status, Code: code, Message: message})
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
 func secureCookie(baseURL *url.URL, name, value string, maxAge int) *http.Cookie {
 	return &http.Cookie{
 		Name:     name,
@@ -286,7 +392,7 @@ func secureCookie(baseURL *url.URL, name, value string, maxAge int) *http.Cookie
 	}
 }
 
-func isAuthenticated(r *http.Request, queries *db.Queries) (bool, error) {
+func isAuthenticated(r *http.Request, queries *db.Queries, tokens *tokenHasher, policy sessionPolicy) (bool, error) {
 	cookie, err := r.Cookie("sessionID")
 	if err != nil && !errors.Is(err, http.ErrNoCookie) {
 		return false, fmt.Errorf("failed to get session cookie: %w", err)
@@ -296,10 +402,11 @@ func isAuthenticated(r *http.Request, queries *db.Queries) (bool, error) {
 		return false, nil
 	}
 
-	return queries.SessionExists(r.Context(), cookie.Value, time.Now().AddDate(0, 0, -7))
+	now := time.Now()
+	return queries.SessionExists(r.Context(), tokens.hash(cookie.Value), policy.idleExpiry(now), policy.lifetimeExpiry(now))
 }
 
-func purgeOldRows(ctx context.Context, logger *slog.Logger, queries *db.Queries, ticker *time.Ticker) {
+func purgeOldRows(ctx context.Context, logger *slog.Logger, queries *db.Queries, policy sessionPolicy, ticker *time.Ticker) {
 	purge := func(ctx context.Context, name string, expiry time.Time, f func(context.Context, time.Time) (sql.Result, error)) {
 		res, err := f(ctx, expiry)
 		if err != nil {
@@ -321,8 +428,15 @@ func purgeOldRows(ctx context.Context, logger *slog.Logger, queries *db.Queries,
 			ticker.Stop()
 			return
 		case <-ticker.C:
-			purge(ctx, "sessions", time.Now().AddDate(0, 0, -7), queries.PurgeSessions)
+			purge(ctx, "sessions", time.Now(), func(ctx context.Context, now time.Time) (sql.Result, error) {
+				return queries.PurgeSessions(ctx, policy.idleExpiry(now), policy.lifetimeExpiry(now))
+			})
 			purge(ctx, "challenges", time.Now().Add(-5*time.Minute), queries.PurgeWebauthnSessions)
+			purge(ctx, "authorization codes", time.Now().Add(-indieAuthCodeTTL), queries.PurgeIndieauthCodes)
+			purge(ctx, "activity deliveries", time.Now().Add(-activityDeliveryTTL), queries.PurgeActivityDeliveries)
+			purge(ctx, "hub subscriptions", time.Now(), func(ctx context.Context, now time.Time) (sql.Result, error) {
+				return queries.PurgeHubSubscriptions(ctx, sql.NullTime{Time: now, Valid: true})
+			})
 		}
 	}
 }
@@ -342,13 +456,13 @@ func newWebauthn(title string, baseURL *url.URL) *webauthn.WebAuthn {
 
 type webauthnUser struct {
 	name        string
-	credentials []*db.JSONCredential
+	credentials []db.WebauthnCredential
 }
 
 func (w webauthnUser) WebAuthnCredentials() []webauthn.Credential {
 	creds := make([]webauthn.Credential, len(w.credentials))
 	for i := range w.credentials {
-		creds[i] = *w.credentials[i].Data
+		creds[i] = *w.credentials[i].CredentialData.Data
 	}
 	return creds
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 )
 
 func TestRegisterPage(t *testing.T) {
@@ -36,7 +37,7 @@ func TestRegisterPageWithCredential(t *testing.T) {
 	t.Parallel()
 
 	app := newTestApp(t)
-	if err := app.queries.CreateWebauthnCredential(t.Context(), &db.JSONCredential{
+	if err := app.queries.CreateWebauthnCredential(t.Context(), "test", "Test", &db.JSONCredential{
 		Data: &webauthn.Credential{ID: []byte("test-id")},
 	}, time.Now()); err != nil {
 		t.Fatal(err)
@@ -61,7 +62,7 @@ func TestRegistrationFlowWithCredential(t *testing.T) {
 	t.Parallel()
 
 	app := newTestApp(t)
-	if err := app.queries.CreateWebauthnCredential(t.Context(), &db.JSONCredential{
+	if err := app.queries.CreateWebauthnCredential(t.Context(), "test", "Test", &db.JSONCredential{
 		Data: &webauthn.Credential{ID: []byte("test-id")},
 	}, time.Now()); err != nil {
 		t.Fatal(err)
@@ -73,16 +74,51 @@ func TestRegistrationFlowWithCredential(t *testing.T) {
 
 	resp := w.Result()
 
-	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+	if got, want := resp.StatusCode, http.StatusUnauthorized; got != want {
 		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
 	}
 }
 
+func TestRegisterPageWithSession(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	if err := app.queries.CreateWebauthnCredential(t.Context(), "test", "Test", &db.JSONCredential{
+		Data: &webauthn.Credential{ID: []byte("test-id")},
+	}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/register", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/admin/passkeys"; got != want {
+		t.Errorf("resp.Header.Get(\"Location\") = %q, want = %q", got, want)
+	}
+}
+
 func TestLoginPage(t *testing.T) {
 	t.Parallel()
 
 	app := newTestApp(t)
-	if err := app.queries.CreateWebauthnCredential(t.Context(), &db.JSONCredential{
+	if err := app.queries.CreateWebauthnCredential(t.Context(), "test", "Test", &db.JSONCredential{
 		Data: &webauthn.Credential{ID: []byte("test-id")},
 	}, time.Now()); err != nil {
 		t.Fatal(err)
@@ -222,7 +258,7 @@ func TestRegistrationAndLoginFlow(t *testing.T) {
 
 	// Check session ID.
 	sessionID := finishResp.Cookies()[0].Value
-	loggedIn, err := app.queries.SessionExists(t.Context(), sessionID, time.Now().Add(-30*time.Minute))
+	loggedIn, err := app.queries.SessionExists(t.Context(), app.tokens.hash(sessionID), testSessionPolicy.idleExpiry(time.Now()), testSessionPolicy.lifetimeExpiry(time.Now()))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -230,4 +266,36 @@ func TestRegistrationAndLoginFlow(t *testing.T) {
 	if !loggedIn {
 		t.Errorf("loggedIn = false, want = true")
 	}
+
+	// Check that the raw session ID isn't stored.
+	rawStored, err := app.queries.SessionExists(t.Context(), sessionID, testSessionPolicy.idleExpiry(time.Now()), testSessionPolicy.lifetimeExpiry(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rawStored {
+		t.Errorf("rawStored = true, want = false")
+	}
+}
+
+func TestRequireAuthenticationAbsoluteForm(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	for _, requestURI := range []string{"/admin", "http://example.com/admin", "/admin?x=/"} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/admin", nil)
+		req.RequestURI = requestURI
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		resp := w.Result()
+		if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+			t.Errorf("%q: resp.StatusCode = %d, want = %d", requestURI, got, want)
+		}
+
+		if got, want := resp.Header.Get("Location"), "http://example.com/login"; got != want {
+			t.Errorf(`%q: resp.Header.Get("Location") = %v, want = %v`, requestURI, got, want)
+		}
+	}
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errNonPublicAddress is returned when dialing an address which isn't on the public internet.
+var errNonPublicAddress = errors.New("non-public address")
+
+// newPublicClient returns an HTTP client for requests to URLs given by third parties, which refuses to connect to
+// loopback, private, link-local, or other non-public addresses. The check happens at dial time, after name
+// resolution, so it applies to redirects and can't be bypassed with DNS records which resolve to internal addresses.
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkPublicAddress,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		Timeout: timeout,
+	}
+}
+
+// checkPublicAddress is a net.Dialer control function which returns an error if the resolved address isn't public.
+func checkPublicAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("failed to parse address %q: %w", address, err)
+	}
+
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("refusing to connect to %s: %w", addrPort.Addr(), errNonPublicAddress)
+	}
+	return nil
+}
+
+// cgnatPrefix is the shared address space used for carrier-grade NAT (RFC 6598).
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublicAddr returns true if addr is a globally routable unicast address.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnatPrefix.Contains(addr)
+}
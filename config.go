@@ -1,38 +1,253 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/Xuanwo/go-locale"
+	"golang.org/x/text/language"
 )
 
-// loadConfig loads the app configuration from the command line arguments and environment variables.
-func loadConfig(args []string, lookupEnv func(string) (string, bool)) (addr, baseURL, dataDir, author, title, description, lang string, err error) {
-	env := func(key, defaultValue string) string {
-		s, ok := lookupEnv(key)
-		if !ok {
-			return defaultValue
-		}
-		return s
-	}
+// Config is the app configuration.
+type Config struct {
+	Addr               string   `json:"addr" toml:"addr"`
+	BaseURL            string   `json:"base_url" toml:"base_url"`
+	DataDir            string   `json:"data_dir" toml:"data_dir"`
+	Author             string   `json:"author" toml:"author"`
+	Username           string   `json:"username" toml:"username"`
+	Title              string   `json:"title" toml:"title"`
+	Description        string   `json:"description" toml:"description"`
+	Lang               string   `json:"lang" toml:"lang"`
+	MastodonURL        string   `json:"mastodon_url" toml:"mastodon_url"`
+	MastodonToken      string   `json:"mastodon_token" toml:"mastodon_token"`
+	WebSubHub          string   `json:"websub_hub" toml:"websub_hub"`
+	WebSubBuiltinHub   bool     `json:"websub_builtin_hub" toml:"websub_builtin_hub"`
+	SessionIdleTimeout duration `json:"session_idle_timeout" toml:"session_idle_timeout"`
+	SessionLifetime    duration `json:"session_lifetime" toml:"session_lifetime"`
+	CompleteFeed       bool     `json:"complete_feed" toml:"complete_feed"`
+}
 
+// loadConfig loads the app configuration from the command line arguments, environment variables, and the
+// configuration file given by the -config flag, in that order of precedence. Unset options have default values.
+func loadConfig(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	// Default to the system's language, or English if it can't be detected.
 	detectedLang, err := locale.Detect()
-	if err != nil {
-		return "", "", "", "", "", "", "", err
+	if errors.Is(err, locale.ErrNotDetected) {
+		detectedLang = language.English
+	} else if err != nil {
+		return nil, err
 	}
 
+	defaults := Config{
+		Addr:               "127.0.0.1:3000",
+		BaseURL:            "http://localhost:3000/",
+		DataDir:            "./data",
+		Author:             "Luther Blissett",
+		Username:           "notes",
+		Title:              "Yellhole",
+		Description:        "Obscurantist filth.",
+		Lang:               detectedLang.String(),
+		SessionIdleTimeout: duration(7 * 24 * time.Hour),
+		SessionLifetime:    duration(30 * 24 * time.Hour),
+	}
+
+	var path string
+	cfg := defaults
 	cmd := flag.NewFlagSet("yellhole", flag.ContinueOnError)
-	cmd.StringVar(&addr, "addr", env("ADDR", "127.0.0.1:3000"), "the address on which to listen")
-	cmd.StringVar(&baseURL, "base_url", env("BASE_URL", "http://localhost:3000/"), "the base URL of the server")
-	cmd.StringVar(&dataDir, "data_dir", env("DATA_DIR", "./data"), "the directory in which all persistent data is stored")
-	cmd.StringVar(&author, "author", env("AUTHOR", "Luther Blissett"), "the author of the yellhole instance")
-	cmd.StringVar(&title, "title", env("TITLE", "Yellhole"), "the title of the yellhole instance")
-	cmd.StringVar(&description, "description", env("DESCRIPTION", "Obscurantist filth."), "the description of the yellhole instance")
-	cmd.StringVar(&lang, "lang", detectedLang.String(), "the language of the notes")
+	cmd.StringVar(&path, "config", "", "the path of a TOML or JSON configuration file")
+	cmd.StringVar(&cfg.Addr, "addr", cfg.Addr, "the address on which to listen")
+	cmd.StringVar(&cfg.BaseURL, "base_url", cfg.BaseURL, "the base URL of the server")
+	cmd.StringVar(&cfg.DataDir, "data_dir", cfg.DataDir, "the directory in which all persistent data is stored")
+	cmd.StringVar(&cfg.Author, "author", cfg.Author, "the author of the yellhole instance")
+	cmd.StringVar(&cfg.Username, "username", cfg.Username, "the username of the yellhole instance's fediverse account")
+	cmd.StringVar(&cfg.Title, "title", cfg.Title, "the title of the yellhole instance")
+	cmd.StringVar(&cfg.Description, "description", cfg.Description, "the description of the yellhole instance")
+	cmd.StringVar(&cfg.Lang, "lang", cfg.Lang, "the language of the notes")
+	cmd.StringVar(&cfg.MastodonURL, "mastodon_url", cfg.MastodonURL, "the URL of a Mastodon server to cross-post notes to")
+	cmd.StringVar(&cfg.MastodonToken, "mastodon_token", cfg.MastodonToken, "the access token of the Mastodon account to cross-post notes to")
+	cmd.StringVar(&cfg.WebSubHub, "websub_hub", cfg.WebSubHub, "the URL of a WebSub hub to notify of new notes")
+	cmd.BoolVar(&cfg.WebSubBuiltinHub, "websub_builtin_hub", cfg.WebSubBuiltinHub, "act as a WebSub hub for the instance's feeds")
+	cmd.TextVar(&cfg.SessionIdleTimeout, "session_idle_timeout", cfg.SessionIdleTimeout, "how long an unused session lasts")
+	cmd.TextVar(&cfg.SessionLifetime, "session_lifetime", cfg.SessionLifetime, "how long a session lasts, regardless of use")
+	cmd.BoolVar(&cfg.CompleteFeed, "complete_feed", cfg.CompleteFeed, "include all notes in the Atom feed (for small instances)")
 
 	if err := cmd.Parse(args); err != nil {
-		return "", "", "", "", "", "", "", err
+		return nil, err
+	}
+
+	// Record the flags which were set, then start over from the defaults and apply the configuration file, environment
+	// variables, and flags in turn. The flags are bound to cfg, so setting them via the flag set updates it.
+	flags := make(map[string]string)
+	cmd.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	cfg = defaults
+
+	if path != "" {
+		if err := cfg.load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range []struct{ flag, env string }{
+		{"addr", "ADDR"},
+		{"base_url", "BASE_URL"},
+		{"data_dir", "DATA_DIR"},
+		{"author", "AUTHOR"},
+		{"username", "FEDIVERSE_USERNAME"},
+		{"title", "TITLE"},
+		{"description", "DESCRIPTION"},
+		{"mastodon_url", "MASTODON_URL"},
+		{"mastodon_token", "MASTODON_TOKEN"},
+		{"websub_hub", "WEBSUB_HUB"},
+		{"websub_builtin_hub", "WEBSUB_BUILTIN_HUB"},
+		{"session_idle_timeout", "SESSION_IDLE_TIMEOUT"},
+		{"session_lifetime", "SESSION_LIFETIME"},
+		{"complete_feed", "COMPLETE_FEED"},
+	} {
+		v, ok := lookupEnv(e.env)
+		if !ok || v == "" {
+			continue
+		}
+
+		if err := cmd.Lookup(e.flag).Value.Set(v); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", e.env, err)
+		}
+	}
+
+	for name, v := range flags {
+		if err := cmd.Set(name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// load sets the options in the given TOML or JSON configuration file, as determined by its extension.
+func (cfg *Config) load(path string) (err error) {
+	ext := filepath.Ext(path)
+	if ext != ".toml" && ext != ".json" {
+		return fmt.Errorf("unsupported configuration file format %q: must be .toml or .json", ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open configuration file: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	if ext == ".toml" {
+		md, err := toml.NewDecoder(f).Decode(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse configuration file %q: %w", path, err)
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("failed to parse configuration file %q: unknown option %q", path, undecoded[0].String())
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to parse configuration file %q: %w", path, err)
 	}
+	return nil
+}
+
+// validate returns an error describing each invalid option, if any.
+func (cfg *Config) validate() error {
+	var errs []error
+
+	if _, port, err := net.SplitHostPort(cfg.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("invalid addr %q: %w", cfg.Addr, err))
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		errs = append(errs, fmt.Errorf("invalid addr %q: invalid port %q", cfg.Addr, port))
+	}
+
+	if !isAbsoluteURL(cfg.BaseURL) {
+		errs = append(errs, fmt.Errorf("invalid base_url %q: must be an absolute http or https URL", cfg.BaseURL))
+	}
+
+	if _, err := language.Parse(cfg.Lang); err != nil {
+		errs = append(errs, fmt.Errorf("invalid lang %q: %w", cfg.Lang, err))
+	}
+
+	if cfg.MastodonURL != "" && !isAbsoluteURL(cfg.MastodonURL) {
+		errs = append(errs, fmt.Errorf("invalid mastodon_url %q: must be an absolute http or https URL", cfg.MastodonURL))
+	}
+
+	if cfg.WebSubHub != "" && !isAbsoluteURL(cfg.WebSubHub) {
+		errs = append(errs, fmt.Errorf("invalid websub_hub %q: must be an absolute http or https URL", cfg.WebSubHub))
+	}
+
+	if cfg.WebSubHub != "" && cfg.WebSubBuiltinHub {
+		errs = append(errs, errors.New("websub_hub and websub_builtin_hub are mutually exclusive"))
+	}
+
+	if cfg.SessionIdleTimeout <= 0 || cfg.SessionLifetime <= 0 {
+		errs = append(errs, fmt.Errorf("session durations must be positive: idle timeout %s, lifetime %s", cfg.SessionIdleTimeout, cfg.SessionLifetime))
+	}
+
+	return errors.Join(errs...)
+}
+
+// isAbsoluteURL returns true if s is an absolute http or https URL without a query or fragment.
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.RawQuery == "" && u.Fragment == ""
+}
 
-	return addr, baseURL, dataDir, author, title, description, lang, nil
+// checkConfig loads the app configuration and writes the effective configuration to w as JSON, with secrets redacted.
+func checkConfig(w io.Writer, args []string, lookupEnv func(string) (string, bool)) error {
+	cfg, err := loadConfig(args, lookupEnv)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MastodonToken != "" {
+		cfg.MastodonToken = "REDACTED"
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// duration is a time.Duration which is represented as text, e.g. "168h0m0s".
+type duration time.Duration
+
+func (d duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "yellhole.json")
+	if err := os.WriteFile(path, []byte(`{
+		"author": "File Author",
+		"title": "File Title",
+		"description": "File Description",
+		"session_lifetime": "48h"
+	}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := mapEnv(map[string]string{
+		"TITLE":         "Env Title",
+		"DESCRIPTION":   "Env Description",
+		"COMPLETE_FEED": "1",
+	})
+
+	cfg, err := loadConfig([]string{"-config", path, "-description", "Flag Description", "-lang", "de"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, v := range map[string][2]string{
+		"Addr":        {cfg.Addr, "127.0.0.1:3000"},
+		"Author":      {cfg.Author, "File Author"},
+		"Title":       {cfg.Title, "Env Title"},
+		"Description": {cfg.Description, "Flag Description"},
+		"Lang":        {cfg.Lang, "de"},
+	} {
+		if got, want := v[0], v[1]; got != want {
+			t.Errorf("%s = %q, want = %q", name, got, want)
+		}
+	}
+
+	if got, want := time.Duration(cfg.SessionLifetime), 48*time.Hour; got != want {
+		t.Errorf("SessionLifetime = %s, want = %s", got, want)
+	}
+
+	if got, want := time.Duration(cfg.SessionIdleTimeout), 7*24*time.Hour; got != want {
+		t.Errorf("SessionIdleTimeout = %s, want = %s", got, want)
+	}
+
+	if got, want := cfg.CompleteFeed, true; got != want {
+		t.Errorf("CompleteFeed = %v, want = %v", got, want)
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "yellhole.toml")
+	if err := os.WriteFile(path, []byte(`
+author = "File Author"
+title = "File Title"
+description = "File Description"
+session_lifetime = "48h"
+complete_feed = true
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := mapEnv(map[string]string{
+		"TITLE":       "Env Title",
+		"DESCRIPTION": "Env Description",
+	})
+
+	cfg, err := loadConfig([]string{"-config", path, "-description", "Flag Description"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, v := range map[string][2]string{
+		"Addr":        {cfg.Addr, "127.0.0.1:3000"},
+		"Author":      {cfg.Author, "File Author"},
+		"Title":       {cfg.Title, "Env Title"},
+		"Description": {cfg.Description, "Flag Description"},
+	} {
+		if got, want := v[0], v[1]; got != want {
+			t.Errorf("%s = %q, want = %q", name, got, want)
+		}
+	}
+
+	if got, want := time.Duration(cfg.SessionLifetime), 48*time.Hour; got != want {
+		t.Errorf("SessionLifetime = %s, want = %s", got, want)
+	}
+
+	if got, want := cfg.CompleteFeed, true; got != want {
+		t.Errorf("CompleteFeed = %v, want = %v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`colour = "red"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadConfig([]string{"-config", path}, mapEnv(nil)); err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Errorf("loadConfig() = %v, want = /.*colour.*/", err)
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-addr", "localhost"}, "invalid addr"},
+		{[]string{"-addr", "localhost:http"}, "invalid port"},
+		{[]string{"-base_url", "/notes/"}, "invalid base_url"},
+		{[]string{"-base_url", "ftp://example.com/"}, "invalid base_url"},
+		{[]string{"-lang", "not a language"}, "invalid lang"},
+		{[]string{"-websub_hub", "https://hub.example/", "-websub_builtin_hub"}, "mutually exclusive"},
+		{[]string{"-session_lifetime", "-1h"}, "must be positive"},
+		{[]string{"-config", "yellhole.yaml"}, "must be .toml or .json"},
+	} {
+		_, err := loadConfig(tc.args, mapEnv(nil))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("loadConfig(%v) = %v, want = /.*%s.*/", tc.args, err, tc.want)
+		}
+	}
+
+	for key, value := range map[string]string{"SESSION_LIFETIME": "forever", "COMPLETE_FEED": "yes"} {
+		if _, err := loadConfig(nil, mapEnv(map[string]string{key: value})); err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("loadConfig() = %v, want = /.*%s.*/", err, key)
+		}
+	}
+}
+
+func TestLoadConfigFalseEnv(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadConfig(nil, mapEnv(map[string]string{"COMPLETE_FEED": "false", "WEBSUB_BUILTIN_HUB": "0"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.CompleteFeed, false; got != want {
+		t.Errorf("CompleteFeed = %v, want = %v", got, want)
+	}
+
+	if got, want := cfg.WebSubBuiltinHub, false; got != want {
+		t.Errorf("WebSubBuiltinHub = %v, want = %v", got, want)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "yellhole.toml")
+	if err := os.WriteFile(path, []byte(`
+base_url = "https://file.example/"
+title = "File Title"
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := checkConfig(&out, []string{"-config", path, "-mastodon_token", "secret", "-lang", "en"}, mapEnv(map[string]string{"BASE_URL": "https://example.com/"})); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(out.Bytes(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.BaseURL, "https://example.com/"; got != want {
+		t.Errorf("BaseURL = %q, want = %q", got, want)
+	}
+
+	if got, want := cfg.Title, "File Title"; got != want {
+		t.Errorf("Title = %q, want = %q", got, want)
+	}
+
+	if got, want := cfg.MastodonToken, "REDACTED"; got != want {
+		t.Errorf("MastodonToken = %q, want = %q", got, want)
+	}
+
+	if got, want := time.Duration(cfg.SessionIdleTimeout), 7*24*time.Hour; got != want {
+		t.Errorf("SessionIdleTimeout = %s, want = %s", got, want)
+	}
+}
+
+func mapEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
@@ -1,21 +1,36 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/imgstore"
 	"github.com/codahale/yellhole-go/internal/markdown"
 	"github.com/gorilla/feeds"
 	"github.com/valyala/bytebufferpool"
 )
 
+const (
+	// feedPageSize is the number of notes in each page of a feed.
+	feedPageSize = 20
+
+	// noLimit is a query limit which returns all rows. SQLite treats negative limits as unlimited.
+	noLimit = -1
+)
+
 func handleHomePage(queries *db.Queries, t *template.Template) appHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		n, err := strconv.ParseInt(r.FormValue("n"), 10, 8)
@@ -26,23 +41,23 @@ func handleHomePage(queries *db.Queries, t *template.Template) appHandler {
 		var notes []db.Note
 		noteID := r.FormValue("id")
 		if noteID == "" {
-			notes, err = queries.RecentNotes(r.Context(), n)
+			notes, err = queries.RecentNotes(r.Context(), time.Now(), n)
 			if err != nil {
 				return fmt.Errorf("failed to retrieve recent notes: %w", err)
 			}
 		} else {
-			notes, err = queries.RecentNotesOlderThan(r.Context(), noteID, n)
+			notes, err = queries.RecentNotesOlderThan(r.Context(), noteID, time.Now(), n)
 			if err != nil {
 				return fmt.Errorf("failed to retrieve recent notes older than note %q: %w", noteID, err)
 			}
 		}
 
-		weeks, err := queries.WeeksWithNotes(r.Context())
+		weeks, err := queries.WeeksWithNotes(r.Context(), time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to retrieve weeks with notes: %w", err)
 		}
 
-		return htmlResponse(w, t, "feed.gohtml", &feedPage{false, notes, weeks})
+		return htmlResponse(w, t, "feed.gohtml", &feedPage{Notes: notes, Weeks: weeks})
 	}
 }
 
@@ -63,51 +78,288 @@ func handleWeekPage(queries *db.Queries, t *template.Template) appHandler {
 		var notes []db.Note
 		noteID := r.FormValue("id")
 		if noteID == "" {
-			notes, err = queries.NotesByDate(r.Context(), start, end, n)
+			notes, err = queries.NotesByDate(r.Context(), start, end, time.Now(), n)
 			if err != nil {
 				return fmt.Errorf("failed to retrieve notes by date: %w", err)
 			}
 		} else {
-			notes, err = queries.NotesByDateOlderThan(r.Context(), start, end, noteID, n)
+			notes, err = queries.NotesByDateOlderThan(r.Context(), start, end, noteID, time.Now(), n)
 			if err != nil {
 				return fmt.Errorf("failed to retrieve notes by date older than note %q: %w", noteID, err)
 			}
 		}
 
-		weeks, err := queries.WeeksWithNotes(r.Context())
+		weeks, err := queries.WeeksWithNotes(r.Context(), time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to retrieve weeks with notes for week page: %w", err)
 		}
 
-		return htmlResponse(w, t, "feed.gohtml", &feedPage{false, notes, weeks})
+		return htmlResponse(w, t, "feed.gohtml", &feedPage{Notes: notes, Weeks: weeks})
 	}
 }
 
-func handleNotePage(queries *db.Queries, t *template.Template) appHandler {
+func handleNotePage(queries *db.Queries, actor *actor, t *template.Template) appHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		note, err := queries.NoteByID(r.Context(), r.PathValue("id"))
+		note, err := queries.NoteByID(r.Context(), r.PathValue("id"), time.Now())
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				http.NotFound(w, r)
-				return nil
+				return handleMissingNote(w, r, queries)
 			}
 			return fmt.Errorf("failed to retrieve note by ID: %w", err)
 		}
 
-		weeks, err := queries.WeeksWithNotes(r.Context())
+		// Respond with an ActivityStreams object for fediverse servers looking up the note by URL.
+		w.Header().Add("Vary", "Accept")
+		if acceptsActivityJSON(r) {
+			object, err := actor.note(&note)
+			if err != nil {
+				return err
+			}
+			object.Context = activityStreamsContext
+			return activityResponse(w, activityJSONType, object)
+		}
+
+		weeks, err := queries.WeeksWithNotes(r.Context(), time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to retrieve weeks with notes for note page: %w", err)
 		}
 
-		return htmlResponse(w, t, "feed.gohtml", &feedPage{true, []db.Note{note}, weeks})
+		webmentions, err := queries.ApprovedWebmentionsByNote(r.Context(), note.NoteID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve webmentions for note page: %w", err)
+		}
+
+		syndications, err := queries.SyndicationsByNote(r.Context(), note.NoteID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve syndications for note page: %w", err)
+		}
+
+		return htmlResponse(w, t, "feed.gohtml", &feedPage{Single: true, Notes: []db.Note{note}, Weeks: weeks, Webmentions: webmentions, Syndications: syndications})
+	}
+}
+
+// handleMissingNote responds with 410 Gone if the requested note was deleted and 404 Not Found otherwise.
+func handleMissingNote(w http.ResponseWriter, r *http.Request, queries *db.Queries) error {
+	deleted, err := queries.NoteIsDeleted(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return fmt.Errorf("failed to check if note is deleted: %w", err)
+	}
+
+	if deleted {
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+		return nil
 	}
+
+	http.NotFound(w, r)
+	return nil
 }
 
-func handleAtomFeed(queries *db.Queries, author, title, description string, baseURL *url.URL) appHandler {
+// handleAtomFeed renders the Atom subscription feed. In full-history mode, the feed contains every note and is marked as
+// complete. Otherwise, it contains the most recent notes, a link to the next page of older notes, and a link to the most
+// recent weekly archive document, per RFC 5005.
+func handleAtomFeed(queries *db.Queries, images *imgstore.Store, author, title, description string, baseURL *url.URL, hub string, completeFeed bool) appHandler {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		notes, err := queries.RecentNotes(r.Context(), 20)
+		feedURL := baseURL.JoinPath("atom.xml")
+		feed := feeds.Feed{
+			Title:       title,
+			Link:        &feeds.Link{Href: baseURL.String()},
+			Description: description,
+			Author:      &feeds.Author{Name: author},
+		}
+
+		// In full-history mode, include all notes.
+		if completeFeed {
+			notes, err := queries.RecentNotes(r.Context(), time.Now(), noLimit)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve all notes for atom feed: %w", err)
+			}
+
+			if err := addFeedItems(&feed, notes, images, baseURL); err != nil {
+				return err
+			}
+
+			atom := newAtomHistoryFeed(&feed, hubLinks(hub, feeds.AtomLink{Href: feedURL.String(), Rel: "self"})...)
+			atom.Complete = &struct{}{}
+			return writeFeed(w, atom, "application/atom+xml")
+		}
+
+		// Otherwise, page through the notes.
+		var (
+			notes []db.Note
+			err   error
+		)
+		selfURL := *feedURL
+		noteID := r.FormValue("id")
+		if noteID == "" {
+			notes, err = queries.RecentNotes(r.Context(), time.Now(), feedPageSize)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve recent notes for atom feed: %w", err)
+			}
+		} else {
+			notes, err = queries.RecentNotesOlderThan(r.Context(), noteID, time.Now(), feedPageSize)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve recent notes older than note %q for atom feed: %w", noteID, err)
+			}
+			selfURL.RawQuery = url.Values{"id": {noteID}}.Encode()
+		}
+
+		if err := addFeedItems(&feed, notes, images, baseURL); err != nil {
+			return err
+		}
+
+		links := hubLinks(hub,
+			feeds.AtomLink{Href: selfURL.String(), Rel: "self"},
+			feeds.AtomLink{Href: feedURL.String(), Rel: "current"},
+			feeds.AtomLink{Href: feedURL.String(), Rel: "first"},
+		)
+
+		// If the page is full, link to the next page of older notes.
+		if len(notes) == feedPageSize {
+			nextURL := *feedURL
+			nextURL.RawQuery = url.Values{"id": {notes[len(notes)-1].NoteID}}.Encode()
+			links = append(links, feeds.AtomLink{Href: nextURL.String(), Rel: "next"})
+		}
+
+		// Link to the most recent archive document, if any.
+		weeks, err := archivedWeeks(r.Context(), queries, time.Now())
 		if err != nil {
-			return fmt.Errorf("failed to retrieve recent notes for atom feed: %w", err)
+			return err
+		}
+
+		if len(weeks) > 0 {
+			links = append(links, feeds.AtomLink{Href: archiveURL(baseURL, weeks[0]), Rel: "prev-archive"})
+		}
+
+		return writeFeed(w, newAtomHistoryFeed(&feed, links...), "application/atom+xml")
+	}
+}
+
+// handleAtomArchive renders an Atom archive document of all the notes in a completed week, per RFC 5005.
+func handleAtomArchive(queries *db.Queries, images *imgstore.Store, author, title, description string, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		weeks, err := archivedWeeks(r.Context(), queries, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// Only completed weeks with notes have archive documents.
+		i := slices.Index(weeks, r.PathValue("start"))
+		if i < 0 {
+			http.NotFound(w, r)
+			return nil
+		}
+
+		start, err := time.ParseInLocation("2006-01-02", weeks[i], time.Local)
+		if err != nil {
+			return fmt.Errorf("failed to parse week start date %q: %w", weeks[i], err)
+		}
+
+		notes, err := queries.NotesByDate(r.Context(), start, start.AddDate(0, 0, 7), time.Now(), noLimit)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve notes by date for atom archive: %w", err)
+		}
+
+		feed := feeds.Feed{
+			Title:       title,
+			Link:        &feeds.Link{Href: baseURL.JoinPath("notes", weeks[i]).String()},
+			Description: description,
+			Author:      &feeds.Author{Name: author},
+		}
+
+		if err := addFeedItems(&feed, notes, images, baseURL); err != nil {
+			return err
+		}
+
+		links := []feeds.AtomLink{
+			{Href: archiveURL(baseURL, weeks[i]), Rel: "self"},
+			{Href: baseURL.JoinPath("atom.xml").String(), Rel: "current"},
+		}
+
+		// Weeks are ordered newest first.
+		if i > 0 {
+			links = append(links, feeds.AtomLink{Href: archiveURL(baseURL, weeks[i-1]), Rel: "next-archive"})
+		}
+		if i < len(weeks)-1 {
+			links = append(links, feeds.AtomLink{Href: archiveURL(baseURL, weeks[i+1]), Rel: "prev-archive"})
+		}
+
+		atom := newAtomHistoryFeed(&feed, links...)
+		atom.Archive = &struct{}{}
+		return writeFeed(w, atom, "application/atom+xml")
+	}
+}
+
+// archivedWeeks returns the start dates of the completed weeks with notes, newest first. The current week is excluded,
+// since archive documents must not change once published.
+func archivedWeeks(ctx context.Context, queries *db.Queries, now time.Time) ([]string, error) {
+	weeks, err := queries.WeeksWithNotes(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve weeks with notes for atom archives: %w", err)
+	}
+
+	starts := make([]string, 0, len(weeks))
+	for _, week := range weeks {
+		start, err := time.ParseInLocation("2006-01-02", week.StartDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse week start date %q: %w", week.StartDate, err)
+		}
+
+		if !start.AddDate(0, 0, 7).After(now) {
+			starts = append(starts, week.StartDate)
+		}
+	}
+	return starts, nil
+}
+
+// archiveURL returns the URL of the Atom archive document for the week starting on the given date.
+func archiveURL(baseURL *url.URL, start string) string {
+	return baseURL.JoinPath("notes", start, "atom.xml").String()
+}
+
+// atomHistoryFeed is an Atom feed with the additional links and elements of RFC 5005 feed paging and archiving.
+type atomHistoryFeed struct {
+	*feeds.AtomFeed
+	XMLNSFH  string           `xml:"xmlns:fh,attr"`
+	Links    []feeds.AtomLink `xml:"link"`
+	Complete *struct{}        `xml:"fh:complete"`
+	Archive  *struct{}        `xml:"fh:archive"`
+}
+
+// newAtomHistoryFeed returns an Atom version of the feed with the given links.
+func newAtomHistoryFeed(feed *feeds.Feed, links ...feeds.AtomLink) *atomHistoryFeed {
+	atom := (&feeds.Atom{Feed: feed}).AtomFeed()
+
+	// The Links field shadows the embedded feed's single link, so include it with the others.
+	alternate := *atom.Link
+	alternate.Rel = "alternate"
+	atom.Link = nil
+
+	return &atomHistoryFeed{
+		AtomFeed: atom,
+		XMLNSFH:  "http://purl.org/syndication/history/1.0",
+		Links:    append([]feeds.AtomLink{alternate}, links...),
+	}
+}
+
+// FeedXml implements feeds.XmlFeed.
+func (f *atomHistoryFeed) FeedXml() any {
+	return f
+}
+
+// hubLinks returns the given links with a link to the WebSub hub, if any.
+func hubLinks(hub string, links ...feeds.AtomLink) []feeds.AtomLink {
+	if hub != "" {
+		links = append(links, feeds.AtomLink{Href: hub, Rel: "hub"})
+	}
+	return links
+}
+
+// handleRSSFeed renders an RSS 2.0 feed of the most recent notes.
+func handleRSSFeed(queries *db.Queries, images *imgstore.Store, author, title, description string, baseURL *url.URL, hub string) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		notes, err := queries.RecentNotes(r.Context(), time.Now(), feedPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve recent notes for RSS feed: %w", err)
 		}
 
 		feed := feeds.Feed{
@@ -117,46 +369,259 @@ func handleAtomFeed(queries *db.Queries, author, title, description string, base
 			Author:      &feeds.Author{Name: author},
 		}
 
-		if len(notes) > 0 {
-			feed.Updated = notes[0].CreatedAt
+		if err := addFeedItems(&feed, notes, images, baseURL); err != nil {
+			return err
+		}
+
+		links := hubLinks(hub, feeds.AtomLink{Href: baseURL.JoinPath("rss.xml").String(), Rel: "self", Type: "application/rss+xml"})
+		return writeFeed(w, newRSSAtomFeed(&feed, links...), "application/rss+xml")
+	}
+}
+
+// rssAtomFeed is an RSS feed with Atom links, e.g. to itself and its WebSub hub.
+type rssAtomFeed struct {
+	XMLName          xml.Name `xml:"rss"`
+	Version          string   `xml:"version,attr"`
+	ContentNamespace string   `xml:"xmlns:content,attr"`
+	AtomNamespace    string   `xml:"xmlns:atom,attr"`
+	Channel          *rssAtomChannel
+}
+
+type rssAtomChannel struct {
+	*feeds.RssFeed
+	Links []rssAtomLink `xml:"atom:link"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// newRSSAtomFeed returns an RSS version of the feed with the given links.
+func newRSSAtomFeed(feed *feeds.Feed, links ...feeds.AtomLink) *rssAtomFeed {
+	rss := (&feeds.Rss{Feed: feed}).RssFeed()
+	channel := &rssAtomChannel{RssFeed: rss}
+	for _, link := range links {
+		channel.Links = append(channel.Links, rssAtomLink{Href: link.Href, Rel: link.Rel, Type: link.Type})
+	}
+
+	return &rssAtomFeed{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		AtomNamespace:    "http://www.w3.org/2005/Atom",
+		Channel:          channel,
+	}
+}
+
+// FeedXml implements feeds.XmlFeed.
+func (f *rssAtomFeed) FeedXml() any {
+	return f
+}
+
+// addFeedItems adds the given notes to the feed as items and sets the feed's updated time to the most recent update.
+func addFeedItems(feed *feeds.Feed, notes []db.Note, images *imgstore.Store, baseURL *url.URL) error {
+	for _, note := range notes {
+		item, err := noteFeedItem(&note, images, baseURL)
+		if err != nil {
+			return err
+		}
+
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return nil
+}
+
+// noteFeedItem converts a note into a feed item. If the note contains an image from the image store, the first such
+// image is included as an enclosure.
+func noteFeedItem(note *db.Note, images *imgstore.Store, baseURL *url.URL) (*feeds.Item, error) {
+	html, err := markdown.HTML(note.Body, baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert markdown to HTML for note %s: %w", note.NoteID, err)
+	}
+
+	enclosure, err := noteEnclosure(note, images, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	title, err := noteTitle(note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &feeds.Item{
+		Id:        note.NoteID,
+		Title:     title,
+		Link:      &feeds.Link{Href: baseURL.JoinPath("note", note.NoteID).String()},
+		Content:   string(html),
+		Created:   note.CreatedAt,
+		Updated:   noteUpdated(note),
+		Enclosure: enclosure,
+	}, nil
+}
+
+// noteEnclosure returns an enclosure for the first image in the note which is a feed image from the image store, if any.
+func noteEnclosure(note *db.Note, images *imgstore.Store, baseURL *url.URL) (*feeds.Enclosure, error) {
+	imageURLs, err := markdown.Images(note.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract images for note %s: %w", note.NoteID, err)
+	}
+
+	feedImagesURL := baseURL.JoinPath("images", "feed").String() + "/"
+	for _, u := range imageURLs {
+		imageURL := baseURL.ResolveReference(u)
+		filename, ok := strings.CutPrefix(imageURL.String(), feedImagesURL)
+		if !ok {
+			continue
+		}
+
+		mimeType, length, err := images.FeedImageInfo(filename)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get image info for note %s: %w", note.NoteID, err)
+		}
+
+		return &feeds.Enclosure{
+			Url:    imageURL.String(),
+			Length: strconv.FormatInt(length, 10),
+			Type:   mimeType,
+		}, nil
+	}
+	return nil, nil
+}
+
+// writeFeed writes the given feed as XML with the given content type.
+func writeFeed(w http.ResponseWriter, feed feeds.XmlFeed, contentType string) error {
+	b := bytebufferpool.Get()
+	defer bytebufferpool.Put(b)
+
+	if err := feeds.WriteXML(feed, b); err != nil {
+		return fmt.Errorf("failed to write XML for feed: %w", err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(b.B); err != nil {
+		return fmt.Errorf("failed to write feed response: %w", err)
+	}
+	return nil
+}
+
+// handleJSONFeed renders a JSON Feed 1.1 document of the most recent notes.
+func handleJSONFeed(queries *db.Queries, images *imgstore.Store, author, title, description string, baseURL *url.URL, hub string) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		notes, err := queries.RecentNotes(r.Context(), time.Now(), feedPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve recent notes for JSON feed: %w", err)
+		}
+
+		feedAuthor := &feeds.JSONAuthor{Name: author, Url: baseURL.String()}
+		feed := feeds.JSONFeed{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       title,
+			HomePageUrl: baseURL.String(),
+			FeedUrl:     baseURL.JoinPath("feed.json").String(),
+			Description: description,
+			Authors:     []*feeds.JSONAuthor{feedAuthor},
+			Items:       make([]*feeds.JSONItem, 0, len(notes)),
+		}
+
+		if hub != "" {
+			feed.Hubs = []*feeds.JSONHub{{Type: "WebSub", Url: hub}}
 		}
 
 		for _, note := range notes {
-			html, err := markdown.HTML(note.Body)
+			feedItem, err := noteFeedItem(&note, images, baseURL)
+			if err != nil {
+				return err
+			}
+
+			text, err := markdown.Text(note.Body)
 			if err != nil {
-				return fmt.Errorf("failed to convert markdown to HTML for note %s: %w", note.NoteID, err)
+				return fmt.Errorf("failed to convert markdown to text for note %s: %w", note.NoteID, err)
+			}
+
+			item := &feeds.JSONItem{
+				Id:            feedItem.Id,
+				Title:         feedItem.Title,
+				Url:           feedItem.Link.Href,
+				ContentHTML:   feedItem.Content,
+				ContentText:   text,
+				PublishedDate: &feedItem.Created,
 			}
 
-			noteURL := baseURL.JoinPath("note", note.NoteID).String()
-			feed.Items = append(feed.Items, &feeds.Item{
-				Id:      note.NoteID,
-				Title:   note.NoteID,
-				Link:    &feeds.Link{Href: noteURL},
-				Content: string(html),
-				Created: note.CreatedAt,
-			})
+			if note.UpdatedAt.Valid {
+				item.ModifiedDate = &feedItem.Updated
+			}
+
+			if feedItem.Enclosure != nil {
+				item.Image = feedItem.Enclosure.Url
+			}
+
+			feed.Items = append(feed.Items, item)
 		}
 
 		b := bytebufferpool.Get()
 		defer bytebufferpool.Put(b)
 
-		if err := feeds.WriteXML(&feeds.Atom{Feed: &feed}, b); err != nil {
-			return fmt.Errorf("failed to write XML for atom feed: %w", err)
+		if err := json.NewEncoder(b).Encode(&feed); err != nil {
+			return fmt.Errorf("failed to encode JSON feed: %w", err)
 		}
 
-		w.Header().Set("Content-Type", "application/atom+xml")
-		_, err = w.Write(b.B)
-		if err != nil {
-			return fmt.Errorf("failed to write atom feed response: %w", err)
+		w.Header().Set("Content-Type", "application/feed+json")
+		if _, err := w.Write(b.B); err != nil {
+			return fmt.Errorf("failed to write JSON feed response: %w", err)
 		}
 		return nil
 	}
 }
 
+// noteTitle returns the note's title, if it has one, or a title derived from its body.
+func noteTitle(note *db.Note) (string, error) {
+	if note.Title != "" {
+		return note.Title, nil
+	}
+
+	title, err := markdown.Title(note.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive title for note %s: %w", note.NoteID, err)
+	}
+	return title, nil
+}
+
+// noteUpdated returns the time the note was last updated or, if it has never been updated, the time it was created.
+func noteUpdated(note *db.Note) time.Time {
+	if note.UpdatedAt.Valid {
+		return note.UpdatedAt.Time
+	}
+	return note.CreatedAt
+}
+
 type feedPage struct {
-	Single bool
-	Notes  []db.Note
-	Weeks  []db.WeeksWithNotesRow
+	Single       bool
+	Notes        []db.Note
+	Weeks        []db.WeeksWithNotesRow
+	Query        string
+	Snippets     map[string]template.HTML
+	Tag          string
+	Webmentions  []db.Webmention
+	Syndications []db.Syndication
+}
+
+// WebmentionsOfType returns the page's Webmentions of the given type.
+func (p *feedPage) WebmentionsOfType(typ string) []db.Webmention {
+	var webmentions []db.Webmention
+	for _, wm := range p.Webmentions {
+		if wm.MentionType == typ {
+			webmentions = append(webmentions, wm)
+		}
+	}
+	return webmentions
 }
 
 func (p *feedPage) LastNoteID() string {
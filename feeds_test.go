@@ -1,15 +1,22 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
 	"html"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/codahale/yellhole-go/internal/microformats"
 	"github.com/google/uuid"
+	nethtml "golang.org/x/net/html"
 )
 
 func TestFeedsHomePageEmpty(t *testing.T) {
@@ -33,7 +40,7 @@ func TestFeedsHomePageNote(t *testing.T) {
 
 	app := newTestApp(t)
 
-	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "It's a *test*.", time.Now()); err != nil {
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "It's a *test*.", time.Now()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -58,12 +65,12 @@ func TestFeedsWeeksPage(t *testing.T) {
 
 	app := newTestApp(t)
 
-	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "This one's in March.",
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "This one's in March.",
 		time.Date(2025, 3, 10, 10, 2, 0, 0, time.Local)); err != nil {
 		t.Fatal(err)
 	}
 
-	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "This one's in April.",
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "This one's in April.",
 		time.Date(2025, 4, 10, 10, 2, 0, 0, time.Local),
 	); err != nil {
 		t.Fatal(err)
@@ -111,7 +118,7 @@ func TestFeedsNotePage(t *testing.T) {
 	app := newTestApp(t)
 
 	noteID := uuid.NewString()
-	if err := app.queries.CreateNote(t.Context(), noteID, "An example.", time.Now()); err != nil {
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "An example.", time.Now()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -149,12 +156,80 @@ func TestFeedsNotePage404(t *testing.T) {
 	}
 }
 
+func TestFeedsNotePageDraft(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateDraft(t.Context(), noteID, "", "A draft.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/note/"+noteID, nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/atom.xml", nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, notWant := string(body), "A draft"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
+func TestFeedsNotePageDeleted(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "An example.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.DeleteNote(t.Context(), sql.NullTime{Time: time.Now(), Valid: true}, noteID); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/note/"+noteID, nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusGone; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, notWant := string(body), "An example"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
 func TestFeedsAtomFeed(t *testing.T) {
 	t.Parallel()
 
 	app := newTestApp(t)
 
-	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "It's a *test*.", time.Now()); err != nil {
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "It's a *test*.", time.Now()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -177,3 +252,428 @@ func TestFeedsAtomFeed(t *testing.T) {
 		t.Errorf("resp.Header.Get(\"Content-Type\") = %q, want = %q", got, want)
 	}
 }
+
+func TestFeedsAtomFeedUpdated(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "It's a *test*.",
+		time.Date(2025, 3, 10, 10, 2, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.UpdateNote(t.Context(), "", "It's an *edited* test.", sql.NullTime{
+		Time:  time.Date(2025, 3, 11, 10, 2, 0, 0, time.UTC),
+		Valid: true,
+	}, noteID); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/atom.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := string(body), "<updated>2025-03-11T10:02:00Z</updated>"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	if got, want := string(body), html.EscapeString("<em>edited</em>"); !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
+
+func TestFeedsNotePageScheduled(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "From the future.", time.Now().Add(1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/note/"+noteID, nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, notWant := string(body), "From the future"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
+func TestFeedsJSONFeed(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	if err := os.WriteFile(filepath.Join(app.tempDir, "images", "feed", "cat.png"), []byte("not really a cat"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "It's a *test*.\n\n![a cat](/images/feed/cat.png)", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/feed.json", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Content-Type"), "application/feed+json"; got != want {
+		t.Errorf("resp.Header.Get(\"Content-Type\") = %q, want = %q", got, want)
+	}
+
+	var feed struct {
+		Version string `json:"version"`
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			ID          string `json:"id"`
+			URL         string `json:"url"`
+			ContentHTML string `json:"content_html"`
+			ContentText string `json:"content_text"`
+			Image       string `json:"image"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := feed.Version, "https://jsonfeed.org/version/1.1"; got != want {
+		t.Errorf("feed.Version = %q, want = %q", got, want)
+	}
+
+	if got, want := feed.FeedURL, "http://example.com/feed.json"; got != want {
+		t.Errorf("feed.FeedURL = %q, want = %q", got, want)
+	}
+
+	if got, want := len(feed.Items), 1; got != want {
+		t.Fatalf("len(feed.Items) = %d, want = %d", got, want)
+	}
+
+	item := feed.Items[0]
+	if got, want := item.ID, noteID; got != want {
+		t.Errorf("item.ID = %q, want = %q", got, want)
+	}
+
+	if got, want := item.URL, "http://example.com/note/"+noteID; got != want {
+		t.Errorf("item.URL = %q, want = %q", got, want)
+	}
+
+	if got, want := item.ContentHTML, "It&rsquo;s a <em>test</em>."; !strings.Contains(got, want) {
+		t.Errorf("item.ContentHTML = %q, want = /.*%s.*/", got, want)
+	}
+
+	if got, want := item.ContentText, "It’s a test. a cat"; got != want {
+		t.Errorf("item.ContentText = %q, want = %q", got, want)
+	}
+
+	if got, want := item.Image, "http://example.com/images/feed/cat.png"; got != want {
+		t.Errorf("item.Image = %q, want = %q", got, want)
+	}
+}
+
+func TestFeedsRSSFeed(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	if err := os.WriteFile(filepath.Join(app.tempDir, "images", "feed", "cat.webp"), []byte("not really a cat"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "It's a *cat*.\n\n![a cat](http://example.com/images/feed/cat.webp)", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/rss.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Content-Type"), "application/rss+xml"; got != want {
+		t.Errorf("resp.Header.Get(\"Content-Type\") = %q, want = %q", got, want)
+	}
+
+	if got, want := string(body), `<enclosure url="http://example.com/images/feed/cat.webp" length="16" type="image/webp"></enclosure>`; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
+
+func TestFeedsRSSFeedLaterImage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	if err := os.WriteFile(filepath.Join(app.tempDir, "images", "feed", "cat.webp"), []byte("not really a cat"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither a remote image nor a missing feed image is used as the enclosure.
+	body := "It's a *cat*.\n\n![a dog](https://dogs.example/dog.png)\n\n![a ghost](/images/feed/ghost.webp)\n\n![a cat](/images/feed/cat.webp)"
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", body, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/rss.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp, _ := io.ReadAll(w.Result().Body)
+
+	if got, want := string(resp), `<enclosure url="http://example.com/images/feed/cat.webp" length="16" type="image/webp"></enclosure>`; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
+
+func TestFeedsAtomFeedPaged(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	// Create a note in a week long past and a page's worth of notes in the current week.
+	oldNoteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), oldNoteID, "", "An old note.", time.Now().AddDate(0, 0, -30)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 20 {
+		if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "A new note.", time.Now().Add(time.Duration(-i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	weeks, err := app.queries.WeeksWithNotes(t.Context(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldWeek := weeks[len(weeks)-1].StartDate
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/atom.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	for _, want := range []string{
+		`xmlns:fh="http://purl.org/syndication/history/1.0"`,
+		`<link href="http://example.com/atom.xml" rel="current"></link>`,
+		`<link href="http://example.com/notes/` + oldWeek + `/atom.xml" rel="prev-archive"></link>`,
+		`rel="next"></link>`,
+	} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+
+	if got, notWant := string(body), oldNoteID; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/notes/"+oldWeek+"/atom.xml", nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+	body, _ = io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	for _, want := range []string{
+		"<fh:archive></fh:archive>",
+		"<id>" + oldNoteID + "</id>",
+		`<link href="http://example.com/atom.xml" rel="current"></link>`,
+	} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+}
+
+func TestFeedsAtomArchiveCurrentWeek(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "A new note.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	weeks, err := app.queries.WeeksWithNotes(t.Context(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/notes/"+weeks[0].StartDate+"/atom.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+}
+
+func TestFeedsNoteTitles(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	titledID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), titledID, "An Explicit Title", "It's a *test*.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "# A Heading\n\nIt's a *test*.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/atom.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{"<title>An Explicit Title</title>", "<title>A Heading</title>"} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/note/"+titledID, nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+	body, _ = io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		"<title>An Explicit Title - Test Yell</title>",
+		`<meta property="og:title" content="An Explicit Title">`,
+	} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+}
+
+func TestFeedsMicroformats(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "A Title", "It's a *test*.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "Another test.", time.Now().Add(-2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(path string) []*microformats.Item {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		doc, err := nethtml.Parse(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return microformats.Parse(doc, &url.URL{Scheme: "http", Host: "example.com", Path: path})
+	}
+
+	// The home page has an h-entry for each note, plus the site-wide h-card.
+	items := parse("/")
+
+	var entries, cards int
+	for _, item := range items {
+		switch {
+		case item.HasType("h-entry"):
+			entries++
+		case item.HasType("h-card"):
+			cards++
+			if got, want := item.String("name"), "Test Man"; got != want {
+				t.Errorf("h-card name = %q, want = %q", got, want)
+			}
+
+			if got, want := item.String("url"), "http://example.com/"; got != want {
+				t.Errorf("h-card url = %q, want = %q", got, want)
+			}
+		}
+	}
+
+	if got, want := entries, 2; got != want {
+		t.Errorf("entries = %d, want = %d", got, want)
+	}
+
+	if got, want := cards, 1; got != want {
+		t.Errorf("cards = %d, want = %d", got, want)
+	}
+
+	// A note page has a complete h-entry.
+	entry := microformats.Find(parse("/note/"+noteID), "h-entry")
+	if entry == nil {
+		t.Fatal("no h-entry found")
+	}
+
+	for name, want := range map[string]string{
+		"name":    "A Title",
+		"content": "It’s a test.",
+		"url":     "http://example.com/note/" + noteID,
+		"author":  "Test Man",
+	} {
+		if got := entry.String(name); got != want {
+			t.Errorf("h-entry %s = %q, want = %q", name, got, want)
+		}
+	}
+
+	if _, err := time.Parse(time.RFC3339, entry.String("published")); err != nil {
+		t.Errorf("h-entry published = %q, want = RFC 3339 timestamp", entry.String("published"))
+	}
+
+	author := entry.Item("author")
+	if author == nil || !author.HasType("h-card") {
+		t.Fatalf("h-entry author = %v, want = h-card", author)
+	}
+
+	if got, want := author.String("url"), "http://example.com/"; got != want {
+		t.Errorf("author url = %q, want = %q", got, want)
+	}
+}
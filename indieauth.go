@@ -0,0 +1,328 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+)
+
+// indieAuthCodeTTL is how long an authorization code can be redeemed for after it's issued.
+const indieAuthCodeTTL = 10 * time.Minute
+
+// indieAuthScopes maps the IndieAuth scopes which can be granted to clients to their descriptions.
+//
+//nolint:gochecknoglobals // lookup table
+var indieAuthScopes = map[string]string{
+	"profile": "See your name and URL.",
+	"create":  "Create new notes.",
+	"update":  "Edit notes.",
+	"delete":  "Delete and restore notes.",
+	"media":   "Upload images.",
+}
+
+// indieAuthRoutes maps the routes which can be used with tokens issued via IndieAuth to the IndieAuth scopes which allow
+// them. Tokens issued via IndieAuth can only be used with Micropub, which checks the scope required for each action.
+//
+//nolint:gochecknoglobals // lookup table
+var indieAuthRoutes = map[string][]string{
+	"GET /micropub":        {"create", "update", "delete", "media"},
+	"POST /micropub":       {"create", "update", "delete"},
+	"POST /micropub/media": {"media"},
+}
+
+// handleIndieAuthMetadata responds with the IndieAuth server metadata.
+func handleIndieAuthMetadata(baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, _ *http.Request) error {
+		return jsonResponse(w, map[string]any{
+			"issuer":                 baseURL.String(),
+			"authorization_endpoint": baseURL.JoinPath("indieauth", "auth").String(),
+			"token_endpoint":         baseURL.JoinPath("indieauth", "token").String(),
+			"introspection_endpoint": baseURL.JoinPath("indieauth", "introspect").String(),
+			"introspection_endpoint_auth_methods_supported":  []string{"Bearer"},
+			"revocation_endpoint":                            baseURL.JoinPath("indieauth", "revoke").String(),
+			"revocation_endpoint_auth_methods_supported":     []string{"none"},
+			"scopes_supported":                               slices.Sorted(maps.Keys(indieAuthScopes)),
+			"response_types_supported":                       []string{"code"},
+			"grant_types_supported":                          []string{"authorization_code"},
+			"code_challenge_methods_supported":               []string{"S256"},
+			"authorization_response_iss_parameter_supported": true,
+		})
+	}
+}
+
+// handleIndieAuthPage renders the consent page for an IndieAuth authorization request. Unauthenticated users are sent to
+// the login page first.
+func handleIndieAuthPage(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, t *template.Template, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		req, err := parseIndieAuthRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil //nolint:nilerr // the error is handled here
+		}
+
+		auth, err := isAuthenticated(r, queries, tokens, policy)
+		if err != nil {
+			return fmt.Errorf("failed to check authentication status for indieauth: %w", err)
+		}
+
+		if !auth {
+			next := baseURL.JoinPath("indieauth", "auth")
+			next.RawQuery = r.URL.RawQuery
+
+			login := baseURL.JoinPath("login")
+			login.RawQuery = url.Values{"next": {next.String()}}.Encode()
+			http.Redirect(w, r, login.String(), http.StatusSeeOther)
+			return nil
+		}
+
+		return htmlResponse(w, t, "indieauth.gohtml", req)
+	}
+}
+
+// handleIndieAuthApprove issues an authorization code for the scopes the user approved, or denies the request, and
+// redirects back to the client.
+func handleIndieAuthApprove(queries *db.Queries, tokens *tokenHasher, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		req, err := parseIndieAuthRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil //nolint:nilerr // the error is handled here
+		}
+
+		redirect, _ := url.Parse(req.RedirectURI)
+		params := redirect.Query()
+		params.Set("state", req.State)
+		params.Set("iss", baseURL.String())
+
+		if r.FormValue("decision") != "approve" {
+			params.Set("error", "access_denied")
+		} else {
+			// Only grant the requested scopes which were approved.
+			scopes := slices.DeleteFunc(r.Form["approved_scope"], func(scope string) bool {
+				return !slices.Contains(req.Scopes, scope)
+			})
+
+			code := rand.Text()
+			if err := queries.CreateIndieauthCode(r.Context(), tokens.hash(code), req.ClientID, req.RedirectURI, req.CodeChallenge, strings.Join(scopes, " "), time.Now()); err != nil {
+				return fmt.Errorf("failed to create indieauth code: %w", err)
+			}
+			params.Set("code", code)
+		}
+
+		redirect.RawQuery = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+		return nil
+	}
+}
+
+// handleIndieAuthProfile redeems an authorization code for the user's profile URL, for clients which only need to
+// authenticate the user.
+func handleIndieAuthProfile(queries *db.Queries, tokens *tokenHasher, author string, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		code, err := redeemIndieAuthCode(r, queries, tokens)
+		if err != nil {
+			return err
+		}
+
+		return jsonResponse(w, indieAuthResponse(author, baseURL, strings.Fields(code.Scope)))
+	}
+}
+
+// handleIndieAuthToken redeems an authorization code for an access token. For compatibility with older clients, it also
+// revokes tokens given action=revoke.
+func handleIndieAuthToken(queries *db.Queries, tokens *tokenHasher, author string, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if r.PostFormValue("action") == "revoke" {
+			return revokeIndieAuthToken(w, r, queries, tokens)
+		}
+
+		code, err := redeemIndieAuthCode(r, queries, tokens)
+		if err != nil {
+			return err
+		}
+
+		scopes := strings.Fields(code.Scope)
+		if len(scopes) == 0 {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_grant", Message: "The authorization code wasn't issued with any scopes."}
+		}
+
+		token, err := createAPIToken(r.Context(), queries, tokens, code.ClientID, scopes, time.Now(), sql.NullTime{})
+		if err != nil {
+			return err
+		}
+
+		resp := indieAuthResponse(author, baseURL, scopes)
+		resp["access_token"] = token
+		resp["token_type"] = "Bearer"
+		resp["scope"] = code.Scope
+		return jsonResponse(w, resp)
+	}
+}
+
+// handleIndieAuthIntrospect responds with the status of a token. The request must itself be authorized with a valid
+// token.
+func handleIndieAuthIntrospect(queries *db.Queries, tokens *tokenHasher, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		bearer, ok := bearerToken(w, r)
+		if !ok {
+			return &apiError{Status: http.StatusUnauthorized, Code: "invalid_token", Message: "A bearer token is required."}
+		}
+
+		if _, err := authenticateAPIToken(r, queries, tokens, bearer); errors.Is(err, errInvalidAPIToken) {
+			return &apiError{Status: http.StatusUnauthorized, Code: "invalid_token", Message: "The bearer token is invalid or expired."}
+		} else if err != nil {
+			return err
+		}
+
+		token, err := queries.APITokenByHash(r.Context(), tokens.hash(r.PostFormValue("token")), sql.NullTime{Time: time.Now(), Valid: true})
+		if errors.Is(err, sql.ErrNoRows) {
+			return jsonResponse(w, map[string]bool{"active": false})
+		} else if err != nil {
+			return fmt.Errorf("failed to retrieve api token for introspection: %w", err)
+		}
+
+		resp := map[string]any{
+			"active":    true,
+			"me":        baseURL.String(),
+			"client_id": token.Name,
+			"scope":     token.Scopes,
+			"iat":       token.CreatedAt.Unix(),
+		}
+		if token.ExpiresAt.Valid {
+			resp["exp"] = token.ExpiresAt.Time.Unix()
+		}
+		return jsonResponse(w, resp)
+	}
+}
+
+// handleIndieAuthRevoke revokes a token.
+func handleIndieAuthRevoke(queries *db.Queries, tokens *tokenHasher) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return revokeIndieAuthToken(w, r, queries, tokens)
+	}
+}
+
+// revokeIndieAuthToken deletes the token given in the request's form. As per RFC 7009, unknown tokens are ignored.
+func revokeIndieAuthToken(w http.ResponseWriter, r *http.Request, queries *db.Queries, tokens *tokenHasher) error {
+	if err := queries.DeleteAPITokenByHash(r.Context(), tokens.hash(r.PostFormValue("token"))); err != nil {
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// redeemIndieAuthCode redeems the authorization code in the request, checking that it was issued to the same client and
+// redirect URI and that the PKCE code verifier matches the original code challenge.
+func redeemIndieAuthCode(r *http.Request, queries *db.Queries, tokens *tokenHasher) (db.IndieauthCode, error) {
+	invalidGrant := &apiError{Status: http.StatusBadRequest, Code: "invalid_grant", Message: "The authorization code is invalid."}
+
+	if grantType := r.PostFormValue("grant_type"); grantType != "authorization_code" {
+		return db.IndieauthCode{}, &apiError{Status: http.StatusBadRequest, Code: "unsupported_grant_type", Message: fmt.Sprintf("Unsupported grant type %q.", grantType)}
+	}
+
+	code, err := queries.DeleteIndieauthCode(r.Context(), tokens.hash(r.PostFormValue("code")), time.Now().Add(-indieAuthCodeTTL))
+	if errors.Is(err, sql.ErrNoRows) {
+		return code, invalidGrant
+	} else if err != nil {
+		return code, fmt.Errorf("failed to redeem indieauth code: %w", err)
+	}
+
+	if code.ClientID != r.PostFormValue("client_id") || code.RedirectURI != r.PostFormValue("redirect_uri") {
+		return code, invalidGrant
+	}
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(challenge[:])), []byte(code.CodeChallenge)) != 1 {
+		return code, invalidGrant
+	}
+
+	return code, nil
+}
+
+// indieAuthResponse returns the user's profile URL and, if the profile scope was granted, their profile information.
+func indieAuthResponse(author string, baseURL *url.URL, scopes []string) map[string]any {
+	resp := map[string]any{"me": baseURL.String()}
+	if slices.Contains(scopes, "profile") {
+		resp["profile"] = map[string]string{"name": author, "url": baseURL.String()}
+	}
+	return resp
+}
+
+// indieAuthRequest is a validated IndieAuth authorization request.
+type indieAuthRequest struct {
+	ClientID      string
+	RedirectURI   string
+	State         string
+	CodeChallenge string
+	Scopes        []string
+}
+
+// Scope returns the request's scopes as a space-separated list.
+func (req *indieAuthRequest) Scope() string {
+	return strings.Join(req.Scopes, " ")
+}
+
+// ScopeDescriptions returns the descriptions of the request's scopes, for the consent page.
+func (req *indieAuthRequest) ScopeDescriptions() map[string]string {
+	descriptions := make(map[string]string, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		descriptions[scope] = indieAuthScopes[scope]
+	}
+	return descriptions
+}
+
+// parseIndieAuthRequest parses and validates an IndieAuth authorization request. The redirect URI must be on the same
+// host as the client ID, since clients with redirect URIs elsewhere would require fetching the client's metadata. Unknown
+// scopes are ignored.
+func parseIndieAuthRequest(r *http.Request) (*indieAuthRequest, error) {
+	if responseType := r.FormValue("response_type"); responseType != "code" {
+		return nil, fmt.Errorf("unsupported response type %q", responseType)
+	}
+
+	clientID, err := url.Parse(r.FormValue("client_id"))
+	if err != nil || (clientID.Scheme != "https" && clientID.Scheme != "http") || clientID.Host == "" {
+		return nil, errors.New("invalid client ID")
+	}
+
+	redirectURI, err := url.Parse(r.FormValue("redirect_uri"))
+	if err != nil || redirectURI.Scheme != clientID.Scheme || redirectURI.Host != clientID.Host {
+		return nil, errors.New("invalid redirect URI")
+	}
+
+	if method := r.FormValue("code_challenge_method"); method != "S256" {
+		return nil, fmt.Errorf("unsupported code challenge method %q", method)
+	}
+
+	req := &indieAuthRequest{
+		ClientID:      r.FormValue("client_id"),
+		RedirectURI:   r.FormValue("redirect_uri"),
+		State:         r.FormValue("state"),
+		CodeChallenge: r.FormValue("code_challenge"),
+	}
+
+	if req.State == "" || req.CodeChallenge == "" {
+		return nil, errors.New("a state and code challenge are required")
+	}
+
+	for _, scope := range strings.Fields(r.FormValue("scope")) {
+		if _, ok := indieAuthScopes[scope]; ok && !slices.Contains(req.Scopes, scope) {
+			req.Scopes = append(req.Scopes, scope)
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIndieAuthMetadata(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/oauth-authorization-server", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	var metadata struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := metadata.Issuer, "http://example.com/"; got != want {
+		t.Errorf("metadata.Issuer = %q, want = %q", got, want)
+	}
+
+	if got, want := metadata.AuthorizationEndpoint, "http://example.com/indieauth/auth"; got != want {
+		t.Errorf("metadata.AuthorizationEndpoint = %q, want = %q", got, want)
+	}
+
+	if got, want := metadata.TokenEndpoint, "http://example.com/indieauth/token"; got != want {
+		t.Errorf("metadata.TokenEndpoint = %q, want = %q", got, want)
+	}
+}
+
+func TestIndieAuthLoginRedirect(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	params := testIndieAuthParams("verifier")
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/indieauth/auth?"+params.Encode(), nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	login, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := login.Path, "/login"; got != want {
+		t.Errorf("login.Path = %q, want = %q", got, want)
+	}
+
+	if got, want := login.Query().Get("next"), "http://example.com/indieauth/auth?"+params.Encode(); got != want {
+		t.Errorf("next = %q, want = %q", got, want)
+	}
+}
+
+func TestIndieAuthFlow(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Render the consent page.
+	params := testIndieAuthParams("verifier")
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/indieauth/auth?"+params.Encode(), nil)
+	req.AddCookie(&http.Cookie{Name: "sessionID", Value: sessionID})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	for _, want := range []string{"https://app.example.org/", "Create new notes.", "Delete and restore notes."} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+
+	// Approve only the create scope.
+	form := testIndieAuthParams("verifier")
+	form.Set("decision", "approve")
+	form.Set("approved_scope", "create")
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/admin/indieauth/approve", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.AddCookie(&http.Cookie{Name: "sessionID", Value: sessionID})
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+
+	if got, want := resp.StatusCode, http.StatusFound; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	redirect, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := redirect.Query().Get("state"), "xyzzy"; got != want {
+		t.Errorf("state = %q, want = %q", got, want)
+	}
+
+	if got, want := redirect.Query().Get("iss"), "http://example.com/"; got != want {
+		t.Errorf("iss = %q, want = %q", got, want)
+	}
+
+	// Redeem the code for a token.
+	redeem := func(verifier string) *http.Response {
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {redirect.Query().Get("code")},
+			"client_id":     {"https://app.example.org/"},
+			"redirect_uri":  {"https://app.example.org/callback"},
+			"code_verifier": {verifier},
+		}
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/indieauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp = redeem("verifier")
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Scope       string `json:"scope"`
+		Me          string `json:"me"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := token.Scope, "create"; got != want {
+		t.Errorf("token.Scope = %q, want = %q", got, want)
+	}
+
+	if got, want := token.Me, "http://example.com/"; got != want {
+		t.Errorf("token.Me = %q, want = %q", got, want)
+	}
+
+	// Codes can only be redeemed once.
+	if got, want := redeem("verifier").StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// The token can create notes via Micropub, but not delete them.
+	micropub := func(form url.Values) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/micropub", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp = micropub(url.Values{"h": {"entry"}, "content": {"Hello from an app."}})
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	resp = micropub(url.Values{"action": {"delete"}, "url": {resp.Header.Get("Location")}})
+	if got, want := resp.StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// Introspect and revoke the token.
+	introspect := func() bool {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/indieauth/introspect", strings.NewReader(url.Values{"token": {token.AccessToken}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+createTestAPIToken(t, app, scopeNotesRead))
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		var resp struct {
+			Active bool `json:"active"`
+		}
+		if err := json.NewDecoder(w.Result().Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Active
+	}
+
+	if got, want := introspect(), true; got != want {
+		t.Errorf("active = %v, want = %v", got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/indieauth/revoke", strings.NewReader(url.Values{"token": {token.AccessToken}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := introspect(), false; got != want {
+		t.Errorf("active = %v, want = %v", got, want)
+	}
+}
+
+func testIndieAuthParams(verifier string) url.Values {
+	challenge := sha256.Sum256([]byte(verifier))
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"https://app.example.org/"},
+		"redirect_uri":          {"https://app.example.org/callback"},
+		"state":                 {"xyzzy"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"scope":                 {"create delete"},
+	}
+}
@@ -24,12 +24,75 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.aPITokenByHashStmt, err = db.PrepareContext(ctx, aPITokenByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query APITokenByHash: %w", err)
+	}
+	if q.aPITokensStmt, err = db.PrepareContext(ctx, aPITokens); err != nil {
+		return nil, fmt.Errorf("error preparing query APITokens: %w", err)
+	}
+	if q.activateHubSubscriptionStmt, err = db.PrepareContext(ctx, activateHubSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query ActivateHubSubscription: %w", err)
+	}
+	if q.activeHubSubscriptionsStmt, err = db.PrepareContext(ctx, activeHubSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query ActiveHubSubscriptions: %w", err)
+	}
+	if q.allNoteBodiesStmt, err = db.PrepareContext(ctx, allNoteBodies); err != nil {
+		return nil, fmt.Errorf("error preparing query AllNoteBodies: %w", err)
+	}
+	if q.announceNoteStmt, err = db.PrepareContext(ctx, announceNote); err != nil {
+		return nil, fmt.Errorf("error preparing query AnnounceNote: %w", err)
+	}
+	if q.approveWebmentionStmt, err = db.PrepareContext(ctx, approveWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query ApproveWebmention: %w", err)
+	}
+	if q.approvedWebmentionsByNoteStmt, err = db.PrepareContext(ctx, approvedWebmentionsByNote); err != nil {
+		return nil, fmt.Errorf("error preparing query ApprovedWebmentionsByNote: %w", err)
+	}
+	if q.backfillCompletedStmt, err = db.PrepareContext(ctx, backfillCompleted); err != nil {
+		return nil, fmt.Errorf("error preparing query BackfillCompleted: %w", err)
+	}
+	if q.clearHubSubscriptionRequestStmt, err = db.PrepareContext(ctx, clearHubSubscriptionRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearHubSubscriptionRequest: %w", err)
+	}
+	if q.completeBackfillStmt, err = db.PrepareContext(ctx, completeBackfill); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteBackfill: %w", err)
+	}
+	if q.completePublishHookStmt, err = db.PrepareContext(ctx, completePublishHook); err != nil {
+		return nil, fmt.Errorf("error preparing query CompletePublishHook: %w", err)
+	}
+	if q.completedPublishHooksStmt, err = db.PrepareContext(ctx, completedPublishHooks); err != nil {
+		return nil, fmt.Errorf("error preparing query CompletedPublishHooks: %w", err)
+	}
+	if q.countFollowersStmt, err = db.PrepareContext(ctx, countFollowers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountFollowers: %w", err)
+	}
+	if q.createAPITokenStmt, err = db.PrepareContext(ctx, createAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAPIToken: %w", err)
+	}
+	if q.createActivityDeliveryStmt, err = db.PrepareContext(ctx, createActivityDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateActivityDelivery: %w", err)
+	}
+	if q.createDraftStmt, err = db.PrepareContext(ctx, createDraft); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateDraft: %w", err)
+	}
+	if q.createFollowerStmt, err = db.PrepareContext(ctx, createFollower); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFollower: %w", err)
+	}
+	if q.createHubSubscriptionStmt, err = db.PrepareContext(ctx, createHubSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateHubSubscription: %w", err)
+	}
 	if q.createImageStmt, err = db.PrepareContext(ctx, createImage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateImage: %w", err)
 	}
+	if q.createIndieauthCodeStmt, err = db.PrepareContext(ctx, createIndieauthCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateIndieauthCode: %w", err)
+	}
 	if q.createNoteStmt, err = db.PrepareContext(ctx, createNote); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateNote: %w", err)
 	}
+	if q.createNoteTagStmt, err = db.PrepareContext(ctx, createNoteTag); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateNoteTag: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
@@ -39,27 +102,138 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createWebauthnSessionStmt, err = db.PrepareContext(ctx, createWebauthnSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateWebauthnSession: %w", err)
 	}
+	if q.createWebmentionStmt, err = db.PrepareContext(ctx, createWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebmention: %w", err)
+	}
+	if q.deleteAPITokenStmt, err = db.PrepareContext(ctx, deleteAPIToken); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAPIToken: %w", err)
+	}
+	if q.deleteAPITokenByHashStmt, err = db.PrepareContext(ctx, deleteAPITokenByHash); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAPITokenByHash: %w", err)
+	}
+	if q.deleteAllSessionsStmt, err = db.PrepareContext(ctx, deleteAllSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAllSessions: %w", err)
+	}
+	if q.deleteFollowerStmt, err = db.PrepareContext(ctx, deleteFollower); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFollower: %w", err)
+	}
+	if q.deleteHubSubscriptionStmt, err = db.PrepareContext(ctx, deleteHubSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteHubSubscription: %w", err)
+	}
+	if q.deleteIndieauthCodeStmt, err = db.PrepareContext(ctx, deleteIndieauthCode); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteIndieauthCode: %w", err)
+	}
+	if q.deleteNoteStmt, err = db.PrepareContext(ctx, deleteNote); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteNote: %w", err)
+	}
+	if q.deleteNoteTagsStmt, err = db.PrepareContext(ctx, deleteNoteTags); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteNoteTags: %w", err)
+	}
+	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
+	}
+	if q.deleteWebauthnCredentialStmt, err = db.PrepareContext(ctx, deleteWebauthnCredential); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebauthnCredential: %w", err)
+	}
 	if q.deleteWebauthnSessionStmt, err = db.PrepareContext(ctx, deleteWebauthnSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteWebauthnSession: %w", err)
 	}
+	if q.deleteWebmentionStmt, err = db.PrepareContext(ctx, deleteWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebmention: %w", err)
+	}
+	if q.deletedNotesStmt, err = db.PrepareContext(ctx, deletedNotes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletedNotes: %w", err)
+	}
+	if q.draftsStmt, err = db.PrepareContext(ctx, drafts); err != nil {
+		return nil, fmt.Errorf("error preparing query Drafts: %w", err)
+	}
+	if q.dueActivityDeliveriesStmt, err = db.PrepareContext(ctx, dueActivityDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query DueActivityDeliveries: %w", err)
+	}
+	if q.dueHubNotificationsStmt, err = db.PrepareContext(ctx, dueHubNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query DueHubNotifications: %w", err)
+	}
+	if q.dueOutgoingWebmentionsStmt, err = db.PrepareContext(ctx, dueOutgoingWebmentions); err != nil {
+		return nil, fmt.Errorf("error preparing query DueOutgoingWebmentions: %w", err)
+	}
+	if q.dueSyndicationsStmt, err = db.PrepareContext(ctx, dueSyndications); err != nil {
+		return nil, fmt.Errorf("error preparing query DueSyndications: %w", err)
+	}
+	if q.editableNoteByIDStmt, err = db.PrepareContext(ctx, editableNoteByID); err != nil {
+		return nil, fmt.Errorf("error preparing query EditableNoteByID: %w", err)
+	}
+	if q.followersStmt, err = db.PrepareContext(ctx, followers); err != nil {
+		return nil, fmt.Errorf("error preparing query Followers: %w", err)
+	}
 	if q.hasWebauthnCredentialStmt, err = db.PrepareContext(ctx, hasWebauthnCredential); err != nil {
 		return nil, fmt.Errorf("error preparing query HasWebauthnCredential: %w", err)
 	}
+	if q.hubSubscriptionStmt, err = db.PrepareContext(ctx, hubSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query HubSubscription: %w", err)
+	}
+	if q.invalidateWebmentionStmt, err = db.PrepareContext(ctx, invalidateWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query InvalidateWebmention: %w", err)
+	}
 	if q.noteByIDStmt, err = db.PrepareContext(ctx, noteByID); err != nil {
 		return nil, fmt.Errorf("error preparing query NoteByID: %w", err)
 	}
+	if q.noteExistsStmt, err = db.PrepareContext(ctx, noteExists); err != nil {
+		return nil, fmt.Errorf("error preparing query NoteExists: %w", err)
+	}
+	if q.noteIsDeletedStmt, err = db.PrepareContext(ctx, noteIsDeleted); err != nil {
+		return nil, fmt.Errorf("error preparing query NoteIsDeleted: %w", err)
+	}
+	if q.noteRevisionsStmt, err = db.PrepareContext(ctx, noteRevisions); err != nil {
+		return nil, fmt.Errorf("error preparing query NoteRevisions: %w", err)
+	}
 	if q.notesByDateStmt, err = db.PrepareContext(ctx, notesByDate); err != nil {
 		return nil, fmt.Errorf("error preparing query NotesByDate: %w", err)
 	}
 	if q.notesByDateOlderThanStmt, err = db.PrepareContext(ctx, notesByDateOlderThan); err != nil {
 		return nil, fmt.Errorf("error preparing query NotesByDateOlderThan: %w", err)
 	}
+	if q.notesByTagStmt, err = db.PrepareContext(ctx, notesByTag); err != nil {
+		return nil, fmt.Errorf("error preparing query NotesByTag: %w", err)
+	}
+	if q.notesByTagOlderThanStmt, err = db.PrepareContext(ctx, notesByTagOlderThan); err != nil {
+		return nil, fmt.Errorf("error preparing query NotesByTagOlderThan: %w", err)
+	}
+	if q.outgoingWebmentionsStmt, err = db.PrepareContext(ctx, outgoingWebmentions); err != nil {
+		return nil, fmt.Errorf("error preparing query OutgoingWebmentions: %w", err)
+	}
+	if q.pendingHubSubscriptionsStmt, err = db.PrepareContext(ctx, pendingHubSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query PendingHubSubscriptions: %w", err)
+	}
+	if q.pendingWebmentionsStmt, err = db.PrepareContext(ctx, pendingWebmentions); err != nil {
+		return nil, fmt.Errorf("error preparing query PendingWebmentions: %w", err)
+	}
+	if q.publishDraftStmt, err = db.PrepareContext(ctx, publishDraft); err != nil {
+		return nil, fmt.Errorf("error preparing query PublishDraft: %w", err)
+	}
+	if q.purgeActivityDeliveriesStmt, err = db.PrepareContext(ctx, purgeActivityDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeActivityDeliveries: %w", err)
+	}
+	if q.purgeHubSubscriptionsStmt, err = db.PrepareContext(ctx, purgeHubSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeHubSubscriptions: %w", err)
+	}
+	if q.purgeIndieauthCodesStmt, err = db.PrepareContext(ctx, purgeIndieauthCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeIndieauthCodes: %w", err)
+	}
 	if q.purgeSessionsStmt, err = db.PrepareContext(ctx, purgeSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query PurgeSessions: %w", err)
 	}
 	if q.purgeWebauthnSessionsStmt, err = db.PrepareContext(ctx, purgeWebauthnSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query PurgeWebauthnSessions: %w", err)
 	}
+	if q.queueHubNotificationStmt, err = db.PrepareContext(ctx, queueHubNotification); err != nil {
+		return nil, fmt.Errorf("error preparing query QueueHubNotification: %w", err)
+	}
+	if q.queueOutgoingWebmentionStmt, err = db.PrepareContext(ctx, queueOutgoingWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query QueueOutgoingWebmention: %w", err)
+	}
+	if q.queueSyndicationStmt, err = db.PrepareContext(ctx, queueSyndication); err != nil {
+		return nil, fmt.Errorf("error preparing query QueueSyndication: %w", err)
+	}
 	if q.recentImagesStmt, err = db.PrepareContext(ctx, recentImages); err != nil {
 		return nil, fmt.Errorf("error preparing query RecentImages: %w", err)
 	}
@@ -69,12 +243,75 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.recentNotesOlderThanStmt, err = db.PrepareContext(ctx, recentNotesOlderThan); err != nil {
 		return nil, fmt.Errorf("error preparing query RecentNotesOlderThan: %w", err)
 	}
+	if q.requestHubUnsubscriptionStmt, err = db.PrepareContext(ctx, requestHubUnsubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query RequestHubUnsubscription: %w", err)
+	}
+	if q.requeueOutgoingWebmentionStmt, err = db.PrepareContext(ctx, requeueOutgoingWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueOutgoingWebmention: %w", err)
+	}
+	if q.rescheduleNoteStmt, err = db.PrepareContext(ctx, rescheduleNote); err != nil {
+		return nil, fmt.Errorf("error preparing query RescheduleNote: %w", err)
+	}
+	if q.restoreNoteStmt, err = db.PrepareContext(ctx, restoreNote); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreNote: %w", err)
+	}
+	if q.scheduledNotesStmt, err = db.PrepareContext(ctx, scheduledNotes); err != nil {
+		return nil, fmt.Errorf("error preparing query ScheduledNotes: %w", err)
+	}
+	if q.searchNotesStmt, err = db.PrepareContext(ctx, searchNotes); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchNotes: %w", err)
+	}
+	if q.searchNotesOlderThanStmt, err = db.PrepareContext(ctx, searchNotesOlderThan); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchNotesOlderThan: %w", err)
+	}
 	if q.sessionExistsStmt, err = db.PrepareContext(ctx, sessionExists); err != nil {
 		return nil, fmt.Errorf("error preparing query SessionExists: %w", err)
 	}
+	if q.sessionsStmt, err = db.PrepareContext(ctx, sessions); err != nil {
+		return nil, fmt.Errorf("error preparing query Sessions: %w", err)
+	}
+	if q.syndicationsByNoteStmt, err = db.PrepareContext(ctx, syndicationsByNote); err != nil {
+		return nil, fmt.Errorf("error preparing query SyndicationsByNote: %w", err)
+	}
+	if q.touchSessionStmt, err = db.PrepareContext(ctx, touchSession); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchSession: %w", err)
+	}
+	if q.unannouncedNotesStmt, err = db.PrepareContext(ctx, unannouncedNotes); err != nil {
+		return nil, fmt.Errorf("error preparing query UnannouncedNotes: %w", err)
+	}
+	if q.updateAPITokenUsageStmt, err = db.PrepareContext(ctx, updateAPITokenUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAPITokenUsage: %w", err)
+	}
+	if q.updateActivityDeliveryStmt, err = db.PrepareContext(ctx, updateActivityDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateActivityDelivery: %w", err)
+	}
+	if q.updateHubNotificationStmt, err = db.PrepareContext(ctx, updateHubNotification); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateHubNotification: %w", err)
+	}
+	if q.updateNoteStmt, err = db.PrepareContext(ctx, updateNote); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateNote: %w", err)
+	}
+	if q.updateOutgoingWebmentionStmt, err = db.PrepareContext(ctx, updateOutgoingWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateOutgoingWebmention: %w", err)
+	}
+	if q.updateSyndicationStmt, err = db.PrepareContext(ctx, updateSyndication); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSyndication: %w", err)
+	}
+	if q.updateWebauthnCredentialUsageStmt, err = db.PrepareContext(ctx, updateWebauthnCredentialUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebauthnCredentialUsage: %w", err)
+	}
+	if q.verifyWebmentionStmt, err = db.PrepareContext(ctx, verifyWebmention); err != nil {
+		return nil, fmt.Errorf("error preparing query VerifyWebmention: %w", err)
+	}
+	if q.webauthnCredentialExistsStmt, err = db.PrepareContext(ctx, webauthnCredentialExists); err != nil {
+		return nil, fmt.Errorf("error preparing query WebauthnCredentialExists: %w", err)
+	}
 	if q.webauthnCredentialsStmt, err = db.PrepareContext(ctx, webauthnCredentials); err != nil {
 		return nil, fmt.Errorf("error preparing query WebauthnCredentials: %w", err)
 	}
+	if q.webmentionsStmt, err = db.PrepareContext(ctx, webmentions); err != nil {
+		return nil, fmt.Errorf("error preparing query Webmentions: %w", err)
+	}
 	if q.weeksWithNotesStmt, err = db.PrepareContext(ctx, weeksWithNotes); err != nil {
 		return nil, fmt.Errorf("error preparing query WeeksWithNotes: %w", err)
 	}
@@ -83,16 +320,121 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 
 func (q *Queries) Close() error {
 	var err error
+	if q.aPITokenByHashStmt != nil {
+		if cerr := q.aPITokenByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing aPITokenByHashStmt: %w", cerr)
+		}
+	}
+	if q.aPITokensStmt != nil {
+		if cerr := q.aPITokensStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing aPITokensStmt: %w", cerr)
+		}
+	}
+	if q.activateHubSubscriptionStmt != nil {
+		if cerr := q.activateHubSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing activateHubSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.activeHubSubscriptionsStmt != nil {
+		if cerr := q.activeHubSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing activeHubSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.allNoteBodiesStmt != nil {
+		if cerr := q.allNoteBodiesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing allNoteBodiesStmt: %w", cerr)
+		}
+	}
+	if q.announceNoteStmt != nil {
+		if cerr := q.announceNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing announceNoteStmt: %w", cerr)
+		}
+	}
+	if q.approveWebmentionStmt != nil {
+		if cerr := q.approveWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing approveWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.approvedWebmentionsByNoteStmt != nil {
+		if cerr := q.approvedWebmentionsByNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing approvedWebmentionsByNoteStmt: %w", cerr)
+		}
+	}
+	if q.backfillCompletedStmt != nil {
+		if cerr := q.backfillCompletedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing backfillCompletedStmt: %w", cerr)
+		}
+	}
+	if q.clearHubSubscriptionRequestStmt != nil {
+		if cerr := q.clearHubSubscriptionRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearHubSubscriptionRequestStmt: %w", cerr)
+		}
+	}
+	if q.completeBackfillStmt != nil {
+		if cerr := q.completeBackfillStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeBackfillStmt: %w", cerr)
+		}
+	}
+	if q.completePublishHookStmt != nil {
+		if cerr := q.completePublishHookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completePublishHookStmt: %w", cerr)
+		}
+	}
+	if q.completedPublishHooksStmt != nil {
+		if cerr := q.completedPublishHooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completedPublishHooksStmt: %w", cerr)
+		}
+	}
+	if q.countFollowersStmt != nil {
+		if cerr := q.countFollowersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countFollowersStmt: %w", cerr)
+		}
+	}
+	if q.createAPITokenStmt != nil {
+		if cerr := q.createAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.createActivityDeliveryStmt != nil {
+		if cerr := q.createActivityDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createActivityDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.createDraftStmt != nil {
+		if cerr := q.createDraftStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createDraftStmt: %w", cerr)
+		}
+	}
+	if q.createFollowerStmt != nil {
+		if cerr := q.createFollowerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFollowerStmt: %w", cerr)
+		}
+	}
+	if q.createHubSubscriptionStmt != nil {
+		if cerr := q.createHubSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createHubSubscriptionStmt: %w", cerr)
+		}
+	}
 	if q.createImageStmt != nil {
 		if cerr := q.createImageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createImageStmt: %w", cerr)
 		}
 	}
+	if q.createIndieauthCodeStmt != nil {
+		if cerr := q.createIndieauthCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createIndieauthCodeStmt: %w", cerr)
+		}
+	}
 	if q.createNoteStmt != nil {
 		if cerr := q.createNoteStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createNoteStmt: %w", cerr)
 		}
 	}
+	if q.createNoteTagStmt != nil {
+		if cerr := q.createNoteTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createNoteTagStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
@@ -108,21 +450,146 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createWebauthnSessionStmt: %w", cerr)
 		}
 	}
+	if q.createWebmentionStmt != nil {
+		if cerr := q.createWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.deleteAPITokenStmt != nil {
+		if cerr := q.deleteAPITokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAPITokenStmt: %w", cerr)
+		}
+	}
+	if q.deleteAPITokenByHashStmt != nil {
+		if cerr := q.deleteAPITokenByHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAPITokenByHashStmt: %w", cerr)
+		}
+	}
+	if q.deleteAllSessionsStmt != nil {
+		if cerr := q.deleteAllSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAllSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteFollowerStmt != nil {
+		if cerr := q.deleteFollowerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFollowerStmt: %w", cerr)
+		}
+	}
+	if q.deleteHubSubscriptionStmt != nil {
+		if cerr := q.deleteHubSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteHubSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.deleteIndieauthCodeStmt != nil {
+		if cerr := q.deleteIndieauthCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteIndieauthCodeStmt: %w", cerr)
+		}
+	}
+	if q.deleteNoteStmt != nil {
+		if cerr := q.deleteNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteNoteStmt: %w", cerr)
+		}
+	}
+	if q.deleteNoteTagsStmt != nil {
+		if cerr := q.deleteNoteTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteNoteTagsStmt: %w", cerr)
+		}
+	}
+	if q.deleteSessionStmt != nil {
+		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebauthnCredentialStmt != nil {
+		if cerr := q.deleteWebauthnCredentialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebauthnCredentialStmt: %w", cerr)
+		}
+	}
 	if q.deleteWebauthnSessionStmt != nil {
 		if cerr := q.deleteWebauthnSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteWebauthnSessionStmt: %w", cerr)
 		}
 	}
+	if q.deleteWebmentionStmt != nil {
+		if cerr := q.deleteWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.deletedNotesStmt != nil {
+		if cerr := q.deletedNotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletedNotesStmt: %w", cerr)
+		}
+	}
+	if q.draftsStmt != nil {
+		if cerr := q.draftsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing draftsStmt: %w", cerr)
+		}
+	}
+	if q.dueActivityDeliveriesStmt != nil {
+		if cerr := q.dueActivityDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing dueActivityDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.dueHubNotificationsStmt != nil {
+		if cerr := q.dueHubNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing dueHubNotificationsStmt: %w", cerr)
+		}
+	}
+	if q.dueOutgoingWebmentionsStmt != nil {
+		if cerr := q.dueOutgoingWebmentionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing dueOutgoingWebmentionsStmt: %w", cerr)
+		}
+	}
+	if q.dueSyndicationsStmt != nil {
+		if cerr := q.dueSyndicationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing dueSyndicationsStmt: %w", cerr)
+		}
+	}
+	if q.editableNoteByIDStmt != nil {
+		if cerr := q.editableNoteByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing editableNoteByIDStmt: %w", cerr)
+		}
+	}
+	if q.followersStmt != nil {
+		if cerr := q.followersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing followersStmt: %w", cerr)
+		}
+	}
 	if q.hasWebauthnCredentialStmt != nil {
 		if cerr := q.hasWebauthnCredentialStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing hasWebauthnCredentialStmt: %w", cerr)
 		}
 	}
+	if q.hubSubscriptionStmt != nil {
+		if cerr := q.hubSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing hubSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.invalidateWebmentionStmt != nil {
+		if cerr := q.invalidateWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing invalidateWebmentionStmt: %w", cerr)
+		}
+	}
 	if q.noteByIDStmt != nil {
 		if cerr := q.noteByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing noteByIDStmt: %w", cerr)
 		}
 	}
+	if q.noteExistsStmt != nil {
+		if cerr := q.noteExistsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing noteExistsStmt: %w", cerr)
+		}
+	}
+	if q.noteIsDeletedStmt != nil {
+		if cerr := q.noteIsDeletedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing noteIsDeletedStmt: %w", cerr)
+		}
+	}
+	if q.noteRevisionsStmt != nil {
+		if cerr := q.noteRevisionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing noteRevisionsStmt: %w", cerr)
+		}
+	}
 	if q.notesByDateStmt != nil {
 		if cerr := q.notesByDateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing notesByDateStmt: %w", cerr)
@@ -133,6 +600,51 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing notesByDateOlderThanStmt: %w", cerr)
 		}
 	}
+	if q.notesByTagStmt != nil {
+		if cerr := q.notesByTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing notesByTagStmt: %w", cerr)
+		}
+	}
+	if q.notesByTagOlderThanStmt != nil {
+		if cerr := q.notesByTagOlderThanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing notesByTagOlderThanStmt: %w", cerr)
+		}
+	}
+	if q.outgoingWebmentionsStmt != nil {
+		if cerr := q.outgoingWebmentionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing outgoingWebmentionsStmt: %w", cerr)
+		}
+	}
+	if q.pendingHubSubscriptionsStmt != nil {
+		if cerr := q.pendingHubSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing pendingHubSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.pendingWebmentionsStmt != nil {
+		if cerr := q.pendingWebmentionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing pendingWebmentionsStmt: %w", cerr)
+		}
+	}
+	if q.publishDraftStmt != nil {
+		if cerr := q.publishDraftStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing publishDraftStmt: %w", cerr)
+		}
+	}
+	if q.purgeActivityDeliveriesStmt != nil {
+		if cerr := q.purgeActivityDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeActivityDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.purgeHubSubscriptionsStmt != nil {
+		if cerr := q.purgeHubSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeHubSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.purgeIndieauthCodesStmt != nil {
+		if cerr := q.purgeIndieauthCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeIndieauthCodesStmt: %w", cerr)
+		}
+	}
 	if q.purgeSessionsStmt != nil {
 		if cerr := q.purgeSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing purgeSessionsStmt: %w", cerr)
@@ -143,6 +655,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing purgeWebauthnSessionsStmt: %w", cerr)
 		}
 	}
+	if q.queueHubNotificationStmt != nil {
+		if cerr := q.queueHubNotificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing queueHubNotificationStmt: %w", cerr)
+		}
+	}
+	if q.queueOutgoingWebmentionStmt != nil {
+		if cerr := q.queueOutgoingWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing queueOutgoingWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.queueSyndicationStmt != nil {
+		if cerr := q.queueSyndicationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing queueSyndicationStmt: %w", cerr)
+		}
+	}
 	if q.recentImagesStmt != nil {
 		if cerr := q.recentImagesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing recentImagesStmt: %w", cerr)
@@ -158,16 +685,121 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing recentNotesOlderThanStmt: %w", cerr)
 		}
 	}
+	if q.requestHubUnsubscriptionStmt != nil {
+		if cerr := q.requestHubUnsubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requestHubUnsubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.requeueOutgoingWebmentionStmt != nil {
+		if cerr := q.requeueOutgoingWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueOutgoingWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.rescheduleNoteStmt != nil {
+		if cerr := q.rescheduleNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rescheduleNoteStmt: %w", cerr)
+		}
+	}
+	if q.restoreNoteStmt != nil {
+		if cerr := q.restoreNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreNoteStmt: %w", cerr)
+		}
+	}
+	if q.scheduledNotesStmt != nil {
+		if cerr := q.scheduledNotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing scheduledNotesStmt: %w", cerr)
+		}
+	}
+	if q.searchNotesStmt != nil {
+		if cerr := q.searchNotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchNotesStmt: %w", cerr)
+		}
+	}
+	if q.searchNotesOlderThanStmt != nil {
+		if cerr := q.searchNotesOlderThanStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchNotesOlderThanStmt: %w", cerr)
+		}
+	}
 	if q.sessionExistsStmt != nil {
 		if cerr := q.sessionExistsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing sessionExistsStmt: %w", cerr)
 		}
 	}
+	if q.sessionsStmt != nil {
+		if cerr := q.sessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sessionsStmt: %w", cerr)
+		}
+	}
+	if q.syndicationsByNoteStmt != nil {
+		if cerr := q.syndicationsByNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing syndicationsByNoteStmt: %w", cerr)
+		}
+	}
+	if q.touchSessionStmt != nil {
+		if cerr := q.touchSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchSessionStmt: %w", cerr)
+		}
+	}
+	if q.unannouncedNotesStmt != nil {
+		if cerr := q.unannouncedNotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unannouncedNotesStmt: %w", cerr)
+		}
+	}
+	if q.updateAPITokenUsageStmt != nil {
+		if cerr := q.updateAPITokenUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAPITokenUsageStmt: %w", cerr)
+		}
+	}
+	if q.updateActivityDeliveryStmt != nil {
+		if cerr := q.updateActivityDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateActivityDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.updateHubNotificationStmt != nil {
+		if cerr := q.updateHubNotificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateHubNotificationStmt: %w", cerr)
+		}
+	}
+	if q.updateNoteStmt != nil {
+		if cerr := q.updateNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateNoteStmt: %w", cerr)
+		}
+	}
+	if q.updateOutgoingWebmentionStmt != nil {
+		if cerr := q.updateOutgoingWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateOutgoingWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.updateSyndicationStmt != nil {
+		if cerr := q.updateSyndicationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSyndicationStmt: %w", cerr)
+		}
+	}
+	if q.updateWebauthnCredentialUsageStmt != nil {
+		if cerr := q.updateWebauthnCredentialUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebauthnCredentialUsageStmt: %w", cerr)
+		}
+	}
+	if q.verifyWebmentionStmt != nil {
+		if cerr := q.verifyWebmentionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing verifyWebmentionStmt: %w", cerr)
+		}
+	}
+	if q.webauthnCredentialExistsStmt != nil {
+		if cerr := q.webauthnCredentialExistsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing webauthnCredentialExistsStmt: %w", cerr)
+		}
+	}
 	if q.webauthnCredentialsStmt != nil {
 		if cerr := q.webauthnCredentialsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing webauthnCredentialsStmt: %w", cerr)
 		}
 	}
+	if q.webmentionsStmt != nil {
+		if cerr := q.webmentionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing webmentionsStmt: %w", cerr)
+		}
+	}
 	if q.weeksWithNotesStmt != nil {
 		if cerr := q.weeksWithNotesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing weeksWithNotesStmt: %w", cerr)
@@ -210,49 +842,207 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                           DBTX
-	tx                           *sql.Tx
-	createImageStmt              *sql.Stmt
-	createNoteStmt               *sql.Stmt
-	createSessionStmt            *sql.Stmt
-	createWebauthnCredentialStmt *sql.Stmt
-	createWebauthnSessionStmt    *sql.Stmt
-	deleteWebauthnSessionStmt    *sql.Stmt
-	hasWebauthnCredentialStmt    *sql.Stmt
-	noteByIDStmt                 *sql.Stmt
-	notesByDateStmt              *sql.Stmt
-	notesByDateOlderThanStmt     *sql.Stmt
-	purgeSessionsStmt            *sql.Stmt
-	purgeWebauthnSessionsStmt    *sql.Stmt
-	recentImagesStmt             *sql.Stmt
-	recentNotesStmt              *sql.Stmt
-	recentNotesOlderThanStmt     *sql.Stmt
-	sessionExistsStmt            *sql.Stmt
-	webauthnCredentialsStmt      *sql.Stmt
-	weeksWithNotesStmt           *sql.Stmt
+	db                                DBTX
+	tx                                *sql.Tx
+	aPITokenByHashStmt                *sql.Stmt
+	aPITokensStmt                     *sql.Stmt
+	activateHubSubscriptionStmt       *sql.Stmt
+	activeHubSubscriptionsStmt        *sql.Stmt
+	allNoteBodiesStmt                 *sql.Stmt
+	announceNoteStmt                  *sql.Stmt
+	approveWebmentionStmt             *sql.Stmt
+	approvedWebmentionsByNoteStmt     *sql.Stmt
+	backfillCompletedStmt             *sql.Stmt
+	clearHubSubscriptionRequestStmt   *sql.Stmt
+	completeBackfillStmt              *sql.Stmt
+	completePublishHookStmt           *sql.Stmt
+	completedPublishHooksStmt         *sql.Stmt
+	countFollowersStmt                *sql.Stmt
+	createAPITokenStmt                *sql.Stmt
+	createActivityDeliveryStmt        *sql.Stmt
+	createDraftStmt                   *sql.Stmt
+	createFollowerStmt                *sql.Stmt
+	createHubSubscriptionStmt         *sql.Stmt
+	createImageStmt                   *sql.Stmt
+	createIndieauthCodeStmt           *sql.Stmt
+	createNoteStmt                    *sql.Stmt
+	createNoteTagStmt                 *sql.Stmt
+	createSessionStmt                 *sql.Stmt
+	createWebauthnCredentialStmt      *sql.Stmt
+	createWebauthnSessionStmt         *sql.Stmt
+	createWebmentionStmt              *sql.Stmt
+	deleteAPITokenStmt                *sql.Stmt
+	deleteAPITokenByHashStmt          *sql.Stmt
+	deleteAllSessionsStmt             *sql.Stmt
+	deleteFollowerStmt                *sql.Stmt
+	deleteHubSubscriptionStmt         *sql.Stmt
+	deleteIndieauthCodeStmt           *sql.Stmt
+	deleteNoteStmt                    *sql.Stmt
+	deleteNoteTagsStmt                *sql.Stmt
+	deleteSessionStmt                 *sql.Stmt
+	deleteWebauthnCredentialStmt      *sql.Stmt
+	deleteWebauthnSessionStmt         *sql.Stmt
+	deleteWebmentionStmt              *sql.Stmt
+	deletedNotesStmt                  *sql.Stmt
+	draftsStmt                        *sql.Stmt
+	dueActivityDeliveriesStmt         *sql.Stmt
+	dueHubNotificationsStmt           *sql.Stmt
+	dueOutgoingWebmentionsStmt        *sql.Stmt
+	dueSyndicationsStmt               *sql.Stmt
+	editableNoteByIDStmt              *sql.Stmt
+	followersStmt                     *sql.Stmt
+	hasWebauthnCredentialStmt         *sql.Stmt
+	hubSubscriptionStmt               *sql.Stmt
+	invalidateWebmentionStmt          *sql.Stmt
+	noteByIDStmt                      *sql.Stmt
+	noteExistsStmt                    *sql.Stmt
+	noteIsDeletedStmt                 *sql.Stmt
+	noteRevisionsStmt                 *sql.Stmt
+	notesByDateStmt                   *sql.Stmt
+	notesByDateOlderThanStmt          *sql.Stmt
+	notesByTagStmt                    *sql.Stmt
+	notesByTagOlderThanStmt           *sql.Stmt
+	outgoingWebmentionsStmt           *sql.Stmt
+	pendingHubSubscriptionsStmt       *sql.Stmt
+	pendingWebmentionsStmt            *sql.Stmt
+	publishDraftStmt                  *sql.Stmt
+	purgeActivityDeliveriesStmt       *sql.Stmt
+	purgeHubSubscriptionsStmt         *sql.Stmt
+	purgeIndieauthCodesStmt           *sql.Stmt
+	purgeSessionsStmt                 *sql.Stmt
+	purgeWebauthnSessionsStmt         *sql.Stmt
+	queueHubNotificationStmt          *sql.Stmt
+	queueOutgoingWebmentionStmt       *sql.Stmt
+	queueSyndicationStmt              *sql.Stmt
+	recentImagesStmt                  *sql.Stmt
+	recentNotesStmt                   *sql.Stmt
+	recentNotesOlderThanStmt          *sql.Stmt
+	requestHubUnsubscriptionStmt      *sql.Stmt
+	requeueOutgoingWebmentionStmt     *sql.Stmt
+	rescheduleNoteStmt                *sql.Stmt
+	restoreNoteStmt                   *sql.Stmt
+	scheduledNotesStmt                *sql.Stmt
+	searchNotesStmt                   *sql.Stmt
+	searchNotesOlderThanStmt          *sql.Stmt
+	sessionExistsStmt                 *sql.Stmt
+	sessionsStmt                      *sql.Stmt
+	syndicationsByNoteStmt            *sql.Stmt
+	touchSessionStmt                  *sql.Stmt
+	unannouncedNotesStmt              *sql.Stmt
+	updateAPITokenUsageStmt           *sql.Stmt
+	updateActivityDeliveryStmt        *sql.Stmt
+	updateHubNotificationStmt         *sql.Stmt
+	updateNoteStmt                    *sql.Stmt
+	updateOutgoingWebmentionStmt      *sql.Stmt
+	updateSyndicationStmt             *sql.Stmt
+	updateWebauthnCredentialUsageStmt *sql.Stmt
+	verifyWebmentionStmt              *sql.Stmt
+	webauthnCredentialExistsStmt      *sql.Stmt
+	webauthnCredentialsStmt           *sql.Stmt
+	webmentionsStmt                   *sql.Stmt
+	weeksWithNotesStmt                *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                           tx,
-		tx:                           tx,
-		createImageStmt:              q.createImageStmt,
-		createNoteStmt:               q.createNoteStmt,
-		createSessionStmt:            q.createSessionStmt,
-		createWebauthnCredentialStmt: q.createWebauthnCredentialStmt,
-		createWebauthnSessionStmt:    q.createWebauthnSessionStmt,
-		deleteWebauthnSessionStmt:    q.deleteWebauthnSessionStmt,
-		hasWebauthnCredentialStmt:    q.hasWebauthnCredentialStmt,
-		noteByIDStmt:                 q.noteByIDStmt,
-		notesByDateStmt:              q.notesByDateStmt,
-		notesByDateOlderThanStmt:     q.notesByDateOlderThanStmt,
-		purgeSessionsStmt:            q.purgeSessionsStmt,
-		purgeWebauthnSessionsStmt:    q.purgeWebauthnSessionsStmt,
-		recentImagesStmt:             q.recentImagesStmt,
-		recentNotesStmt:              q.recentNotesStmt,
-		recentNotesOlderThanStmt:     q.recentNotesOlderThanStmt,
-		sessionExistsStmt:            q.sessionExistsStmt,
-		webauthnCredentialsStmt:      q.webauthnCredentialsStmt,
-		weeksWithNotesStmt:           q.weeksWithNotesStmt,
+		db:                                tx,
+		tx:                                tx,
+		aPITokenByHashStmt:                q.aPITokenByHashStmt,
+		aPITokensStmt:                     q.aPITokensStmt,
+		activateHubSubscriptionStmt:       q.activateHubSubscriptionStmt,
+		activeHubSubscriptionsStmt:        q.activeHubSubscriptionsStmt,
+		allNoteBodiesStmt:                 q.allNoteBodiesStmt,
+		announceNoteStmt:                  q.announceNoteStmt,
+		approveWebmentionStmt:             q.approveWebmentionStmt,
+		approvedWebmentionsByNoteStmt:     q.approvedWebmentionsByNoteStmt,
+		backfillCompletedStmt:             q.backfillCompletedStmt,
+		clearHubSubscriptionRequestStmt:   q.clearHubSubscriptionRequestStmt,
+		completeBackfillStmt:              q.completeBackfillStmt,
+		completePublishHookStmt:           q.completePublishHookStmt,
+		completedPublishHooksStmt:         q.completedPublishHooksStmt,
+		countFollowersStmt:                q.countFollowersStmt,
+		createAPITokenStmt:                q.createAPITokenStmt,
+		createActivityDeliveryStmt:        q.createActivityDeliveryStmt,
+		createDraftStmt:                   q.createDraftStmt,
+		createFollowerStmt:                q.createFollowerStmt,
+		createHubSubscriptionStmt:         q.createHubSubscriptionStmt,
+		createImageStmt:                   q.createImageStmt,
+		createIndieauthCodeStmt:           q.createIndieauthCodeStmt,
+		createNoteStmt:                    q.createNoteStmt,
+		createNoteTagStmt:                 q.createNoteTagStmt,
+		createSessionStmt:                 q.createSessionStmt,
+		createWebauthnCredentialStmt:      q.createWebauthnCredentialStmt,
+		createWebauthnSessionStmt:         q.createWebauthnSessionStmt,
+		createWebmentionStmt:              q.createWebmentionStmt,
+		deleteAPITokenStmt:                q.deleteAPITokenStmt,
+		deleteAPITokenByHashStmt:          q.deleteAPITokenByHashStmt,
+		deleteAllSessionsStmt:             q.deleteAllSessionsStmt,
+		deleteFollowerStmt:                q.deleteFollowerStmt,
+		deleteHubSubscriptionStmt:         q.deleteHubSubscriptionStmt,
+		deleteIndieauthCodeStmt:           q.deleteIndieauthCodeStmt,
+		deleteNoteStmt:                    q.deleteNoteStmt,
+		deleteNoteTagsStmt:                q.deleteNoteTagsStmt,
+		deleteSessionStmt:                 q.deleteSessionStmt,
+		deleteWebauthnCredentialStmt:      q.deleteWebauthnCredentialStmt,
+		deleteWebauthnSessionStmt:         q.deleteWebauthnSessionStmt,
+		deleteWebmentionStmt:              q.deleteWebmentionStmt,
+		deletedNotesStmt:                  q.deletedNotesStmt,
+		draftsStmt:                        q.draftsStmt,
+		dueActivityDeliveriesStmt:         q.dueActivityDeliveriesStmt,
+		dueHubNotificationsStmt:           q.dueHubNotificationsStmt,
+		dueOutgoingWebmentionsStmt:        q.dueOutgoingWebmentionsStmt,
+		dueSyndicationsStmt:               q.dueSyndicationsStmt,
+		editableNoteByIDStmt:              q.editableNoteByIDStmt,
+		followersStmt:                     q.followersStmt,
+		hasWebauthnCredentialStmt:         q.hasWebauthnCredentialStmt,
+		hubSubscriptionStmt:               q.hubSubscriptionStmt,
+		invalidateWebmentionStmt:          q.invalidateWebmentionStmt,
+		noteByIDStmt:                      q.noteByIDStmt,
+		noteExistsStmt:                    q.noteExistsStmt,
+		noteIsDeletedStmt:                 q.noteIsDeletedStmt,
+		noteRevisionsStmt:                 q.noteRevisionsStmt,
+		notesByDateStmt:                   q.notesByDateStmt,
+		notesByDateOlderThanStmt:          q.notesByDateOlderThanStmt,
+		notesByTagStmt:                    q.notesByTagStmt,
+		notesByTagOlderThanStmt:           q.notesByTagOlderThanStmt,
+		outgoingWebmentionsStmt:           q.outgoingWebmentionsStmt,
+		pendingHubSubscriptionsStmt:       q.pendingHubSubscriptionsStmt,
+		pendingWebmentionsStmt:            q.pendingWebmentionsStmt,
+		publishDraftStmt:                  q.publishDraftStmt,
+		purgeActivityDeliveriesStmt:       q.purgeActivityDeliveriesStmt,
+		purgeHubSubscriptionsStmt:         q.purgeHubSubscriptionsStmt,
+		purgeIndieauthCodesStmt:           q.purgeIndieauthCodesStmt,
+		purgeSessionsStmt:                 q.purgeSessionsStmt,
+		purgeWebauthnSessionsStmt:         q.purgeWebauthnSessionsStmt,
+		queueHubNotificationStmt:          q.queueHubNotificationStmt,
+		queueOutgoingWebmentionStmt:       q.queueOutgoingWebmentionStmt,
+		queueSyndicationStmt:              q.queueSyndicationStmt,
+		recentImagesStmt:                  q.recentImagesStmt,
+		recentNotesStmt:                   q.recentNotesStmt,
+		recentNotesOlderThanStmt:          q.recentNotesOlderThanStmt,
+		requestHubUnsubscriptionStmt:      q.requestHubUnsubscriptionStmt,
+		requeueOutgoingWebmentionStmt:     q.requeueOutgoingWebmentionStmt,
+		rescheduleNoteStmt:                q.rescheduleNoteStmt,
+		restoreNoteStmt:                   q.restoreNoteStmt,
+		scheduledNotesStmt:                q.scheduledNotesStmt,
+		searchNotesStmt:                   q.searchNotesStmt,
+		searchNotesOlderThanStmt:          q.searchNotesOlderThanStmt,
+		sessionExistsStmt:                 q.sessionExistsStmt,
+		sessionsStmt:                      q.sessionsStmt,
+		syndicationsByNoteStmt:            q.syndicationsByNoteStmt,
+		touchSessionStmt:                  q.touchSessionStmt,
+		unannouncedNotesStmt:              q.unannouncedNotesStmt,
+		updateAPITokenUsageStmt:           q.updateAPITokenUsageStmt,
+		updateActivityDeliveryStmt:        q.updateActivityDeliveryStmt,
+		updateHubNotificationStmt:         q.updateHubNotificationStmt,
+		updateNoteStmt:                    q.updateNoteStmt,
+		updateOutgoingWebmentionStmt:      q.updateOutgoingWebmentionStmt,
+		updateSyndicationStmt:             q.updateSyndicationStmt,
+		updateWebauthnCredentialUsageStmt: q.updateWebauthnCredentialUsageStmt,
+		verifyWebmentionStmt:              q.verifyWebmentionStmt,
+		webauthnCredentialExistsStmt:      q.webauthnCredentialExistsStmt,
+		webauthnCredentialsStmt:           q.webauthnCredentialsStmt,
+		webmentionsStmt:                   q.webmentionsStmt,
+		weeksWithNotesStmt:                q.weeksWithNotesStmt,
 	}
 }
@@ -0,0 +1,6 @@
+drop trigger note_revision_after_update;
+
+drop table note_revision;
+
+alter table note
+    drop column updated_at;
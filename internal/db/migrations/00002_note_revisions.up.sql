@@ -0,0 +1,22 @@
+alter table note
+    add column updated_at datetime;
+
+create table
+    note_revision
+(
+    note_id    text     not null references note (note_id) on delete cascade,
+    body       text     not null,
+    created_at datetime not null
+);
+
+create index idx_note_revision_note_id_created_at_desc on note_revision (note_id, created_at desc);
+
+-- Preserve the previous body of a note whenever it's edited.
+create trigger note_revision_after_update
+    after update of body
+    on note
+    when old.body <> new.body
+begin
+    insert into note_revision (note_id, body, created_at)
+    values (old.note_id, old.body, coalesce(old.updated_at, old.created_at));
+end;
@@ -0,0 +1,2 @@
+alter table note
+    drop column deleted_at;
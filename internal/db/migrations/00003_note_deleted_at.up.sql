@@ -0,0 +1,2 @@
+alter table note
+    add column deleted_at datetime;
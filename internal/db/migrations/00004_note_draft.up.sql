@@ -0,0 +1,2 @@
+alter table note
+    add column draft boolean not null default false;
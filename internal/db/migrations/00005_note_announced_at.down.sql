@@ -0,0 +1,4 @@
+drop table note_publish_hook;
+
+alter table note
+    drop column announced_at;
@@ -0,0 +1,16 @@
+alter table note
+    add column announced_at datetime;
+
+-- Existing notes have already been published.
+update note
+set announced_at = created_at
+where not draft;
+
+create table
+    note_publish_hook
+(
+    note_id      text     not null,
+    hook         text     not null,
+    completed_at datetime not null,
+    primary key (note_id, hook)
+);
@@ -0,0 +1,7 @@
+drop trigger note_search_after_delete;
+
+drop trigger note_search_after_update;
+
+drop trigger note_search_after_insert;
+
+drop table note_search;
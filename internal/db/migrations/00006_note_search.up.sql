@@ -0,0 +1,38 @@
+create virtual table
+    note_search using fts5
+(
+    note_id unindexed,
+    body
+);
+
+-- Keep the search index in sync with the note table.
+create trigger note_search_after_insert
+    after insert
+    on note
+begin
+    insert into note_search (note_id, body)
+    values (new.note_id, new.body);
+end;
+
+create trigger note_search_after_update
+    after update of body
+    on note
+begin
+    update note_search
+    set body = new.body
+    where note_id = old.note_id;
+end;
+
+create trigger note_search_after_delete
+    after delete
+    on note
+begin
+    delete
+    from note_search
+    where note_id = old.note_id;
+end;
+
+-- Index all existing notes.
+insert into note_search (note_id, body)
+select note_id, body
+from note;
@@ -0,0 +1,3 @@
+drop table backfill;
+
+drop table note_tag;
@@ -0,0 +1,17 @@
+create table
+    note_tag
+(
+    note_id text not null references note (note_id) on delete cascade,
+    tag     text not null,
+    primary key (note_id, tag)
+);
+
+create index idx_note_tag_tag on note_tag (tag);
+
+-- Record one-time backfills, like parsing the tags of existing notes, which are run by the application.
+create table
+    backfill
+(
+    name         text primary key not null,
+    completed_at datetime         not null
+);
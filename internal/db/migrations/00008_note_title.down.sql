@@ -0,0 +1,58 @@
+drop trigger note_search_after_insert;
+drop trigger note_search_after_update;
+drop trigger note_search_after_delete;
+drop table note_search;
+
+create virtual table
+    note_search using fts5
+(
+    note_id unindexed,
+    body
+);
+
+create trigger note_search_after_insert
+    after insert
+    on note
+begin
+    insert into note_search (note_id, body)
+    values (new.note_id, new.body);
+end;
+
+create trigger note_search_after_update
+    after update of body
+    on note
+begin
+    update note_search
+    set body = new.body
+    where note_id = old.note_id;
+end;
+
+create trigger note_search_after_delete
+    after delete
+    on note
+begin
+    delete
+    from note_search
+    where note_id = old.note_id;
+end;
+
+insert into note_search (note_id, body)
+select note_id, body
+from note;
+
+drop trigger note_revision_after_update;
+
+create trigger note_revision_after_update
+    after update of body
+    on note
+    when old.body <> new.body
+begin
+    insert into note_revision (note_id, body, created_at)
+    values (old.note_id, old.body, coalesce(old.updated_at, old.created_at));
+end;
+
+alter table note_revision
+    drop column title;
+
+alter table note
+    drop column title;
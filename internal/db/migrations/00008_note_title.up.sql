@@ -0,0 +1,62 @@
+alter table note
+    add column title text not null default '';
+
+alter table note_revision
+    add column title text not null default '';
+
+-- Preserve the previous title and body of a note whenever either is edited.
+drop trigger note_revision_after_update;
+
+create trigger note_revision_after_update
+    after update of title, body
+    on note
+    when old.title <> new.title or old.body <> new.body
+begin
+    insert into note_revision (note_id, title, body, created_at)
+    values (old.note_id, old.title, old.body, coalesce(old.updated_at, old.created_at));
+end;
+
+-- Index note titles as well as bodies.
+drop trigger note_search_after_insert;
+drop trigger note_search_after_update;
+drop trigger note_search_after_delete;
+drop table note_search;
+
+create virtual table
+    note_search using fts5
+(
+    note_id unindexed,
+    title,
+    body
+);
+
+create trigger note_search_after_insert
+    after insert
+    on note
+begin
+    insert into note_search (note_id, title, body)
+    values (new.note_id, new.title, new.body);
+end;
+
+create trigger note_search_after_update
+    after update of title, body
+    on note
+begin
+    update note_search
+    set title = new.title,
+        body  = new.body
+    where note_id = old.note_id;
+end;
+
+create trigger note_search_after_delete
+    after delete
+    on note
+begin
+    delete
+    from note_search
+    where note_id = old.note_id;
+end;
+
+insert into note_search (note_id, title, body)
+select note_id, title, body
+from note;
@@ -0,0 +1,10 @@
+drop index idx_webauthn_credential_id;
+
+alter table webauthn_credential
+    drop column last_used_at;
+
+alter table webauthn_credential
+    drop column nickname;
+
+alter table webauthn_credential
+    drop column webauthn_credential_id;
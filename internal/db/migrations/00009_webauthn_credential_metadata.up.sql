@@ -0,0 +1,14 @@
+alter table webauthn_credential
+    add column webauthn_credential_id text not null default '';
+
+alter table webauthn_credential
+    add column nickname text not null default '';
+
+alter table webauthn_credential
+    add column last_used_at datetime;
+
+update webauthn_credential
+set webauthn_credential_id = lower(hex(randomblob(16)))
+where webauthn_credential_id = '';
+
+create unique index idx_webauthn_credential_id on webauthn_credential (webauthn_credential_id);
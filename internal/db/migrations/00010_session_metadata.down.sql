@@ -0,0 +1,8 @@
+alter table session
+    drop column last_seen_at;
+
+alter table session
+    drop column ip_address;
+
+alter table session
+    drop column user_agent;
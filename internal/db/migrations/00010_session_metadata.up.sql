@@ -0,0 +1,11 @@
+alter table session
+    add column user_agent text not null default '';
+
+alter table session
+    add column ip_address text not null default '';
+
+alter table session
+    add column last_seen_at datetime;
+
+update session
+set last_seen_at = created_at;
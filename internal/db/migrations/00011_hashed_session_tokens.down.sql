@@ -0,0 +1,11 @@
+delete
+from session;
+
+delete
+from webauthn_session;
+
+alter table session
+    rename column session_hash to session_id;
+
+alter table webauthn_session
+    rename column webauthn_session_hash to webauthn_session_id;
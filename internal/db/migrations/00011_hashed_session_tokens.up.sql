@@ -0,0 +1,12 @@
+-- Existing sessions were stored with raw tokens, so invalidate them all.
+delete
+from session;
+
+delete
+from webauthn_session;
+
+alter table session
+    rename column session_id to session_hash;
+
+alter table webauthn_session
+    rename column webauthn_session_id to webauthn_session_hash;
@@ -0,0 +1,10 @@
+create table api_token
+(
+    api_token_id text primary key not null,
+    token_hash   text unique      not null,
+    name         text             not null,
+    scopes       text             not null,
+    created_at   datetime         not null,
+    expires_at   datetime,
+    last_used_at datetime
+);
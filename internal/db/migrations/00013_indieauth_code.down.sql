@@ -0,0 +1 @@
+drop table indieauth_code;
@@ -0,0 +1,9 @@
+create table indieauth_code
+(
+    code_hash      text primary key not null,
+    client_id      text             not null,
+    redirect_uri   text             not null,
+    code_challenge text             not null,
+    scope          text             not null,
+    created_at     datetime         not null
+);
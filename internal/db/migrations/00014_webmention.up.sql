@@ -0,0 +1,20 @@
+create table
+    webmention
+(
+    webmention_id text primary key not null,
+    note_id       text             not null references note (note_id) on delete cascade,
+    source        text             not null,
+    target        text             not null,
+    status        text             not null,
+    mention_type  text             not null default 'mention',
+    author_name   text             not null default '',
+    author_url    text             not null default '',
+    author_photo  text             not null default '',
+    content       text             not null default '',
+    created_at    datetime         not null,
+    verified_at   datetime,
+    approved_at   datetime,
+    unique (source, note_id)
+);
+
+create index idx_webmention_status on webmention (status);
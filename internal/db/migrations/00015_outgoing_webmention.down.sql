@@ -0,0 +1 @@
+drop table outgoing_webmention;
@@ -0,0 +1,15 @@
+create table
+    outgoing_webmention
+(
+    note_id         text     not null references note (note_id) on delete cascade,
+    target          text     not null,
+    status          text     not null,
+    attempts        integer  not null default 0,
+    last_error      text     not null default '',
+    next_attempt_at datetime not null,
+    sent_at         datetime,
+    created_at      datetime not null,
+    primary key (note_id, target)
+);
+
+create index idx_outgoing_webmention_status on outgoing_webmention (status, next_attempt_at);
@@ -0,0 +1,2 @@
+drop table activity_delivery;
+drop table follower;
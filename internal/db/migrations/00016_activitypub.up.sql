@@ -0,0 +1,24 @@
+create table
+    follower
+(
+    actor_id     text primary key not null,
+    inbox        text             not null,
+    shared_inbox text             not null default '',
+    created_at   datetime         not null
+);
+
+create table
+    activity_delivery
+(
+    activity_delivery_id text primary key not null,
+    inbox                text             not null,
+    activity             blob             not null,
+    status               text             not null,
+    attempts             integer          not null default 0,
+    last_error           text             not null default '',
+    next_attempt_at      datetime         not null,
+    delivered_at         datetime,
+    created_at           datetime         not null
+);
+
+create index idx_activity_delivery_status on activity_delivery (status, next_attempt_at);
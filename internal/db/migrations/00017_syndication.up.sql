@@ -0,0 +1,16 @@
+create table
+    syndication
+(
+    note_id         text     not null references note (note_id) on delete cascade,
+    target          text     not null,
+    status          text     not null,
+    attempts        integer  not null default 0,
+    last_error      text     not null default '',
+    next_attempt_at datetime not null,
+    url             text     not null default '',
+    syndicated_at   datetime,
+    created_at      datetime not null,
+    primary key (note_id, target)
+);
+
+create index idx_syndication_status on syndication (status, next_attempt_at);
@@ -0,0 +1,2 @@
+drop table hub_notification;
+drop table hub_subscription;
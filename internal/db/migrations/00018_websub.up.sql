@@ -0,0 +1,36 @@
+create table
+    hub_subscription
+(
+    callback              text     not null,
+    topic                 text     not null,
+    secret                text     not null default '',
+    status                text     not null,
+    lease_seconds         integer  not null,
+    expires_at            datetime,
+    created_at            datetime not null,
+    -- A subscription request which has yet to be verified, if any.
+    pending_mode          text     not null default '',
+    pending_secret        text     not null default '',
+    pending_lease_seconds integer  not null default 0,
+    primary key (callback, topic)
+);
+
+create index idx_hub_subscription_status on hub_subscription (status);
+
+create index idx_hub_subscription_pending_mode on hub_subscription (pending_mode);
+
+create table
+    hub_notification
+(
+    target          text     not null,
+    topic           text     not null,
+    status          text     not null,
+    attempts        integer  not null default 0,
+    last_error      text     not null default '',
+    next_attempt_at datetime not null,
+    notified_at     datetime,
+    created_at      datetime not null,
+    primary key (target, topic)
+);
+
+create index idx_hub_notification_status on hub_notification (status, next_attempt_at);
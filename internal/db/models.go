@@ -5,9 +5,68 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type ActivityDelivery struct {
+	ActivityDeliveryID string
+	Inbox              string
+	Activity           []byte
+	Status             string
+	Attempts           int64
+	LastError          string
+	NextAttemptAt      time.Time
+	DeliveredAt        sql.NullTime
+	CreatedAt          time.Time
+}
+
+type APIToken struct {
+	APITokenID string
+	TokenHash  string
+	Name       string
+	Scopes     string
+	CreatedAt  time.Time
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+}
+
+type Backfill struct {
+	Name        string
+	CompletedAt time.Time
+}
+
+type Follower struct {
+	ActorID     string
+	Inbox       string
+	SharedInbox string
+	CreatedAt   time.Time
+}
+
+type HubNotification struct {
+	Target        string
+	Topic         string
+	Status        string
+	Attempts      int64
+	LastError     string
+	NextAttemptAt time.Time
+	NotifiedAt    sql.NullTime
+	CreatedAt     time.Time
+}
+
+type HubSubscription struct {
+	Callback            string
+	Topic               string
+	Secret              string
+	Status              string
+	LeaseSeconds        int64
+	ExpiresAt           sql.NullTime
+	CreatedAt           time.Time
+	PendingMode         string
+	PendingSecret       string
+	PendingLeaseSeconds int64
+}
+
 type Image struct {
 	ImageID          string
 	Filename         string
@@ -16,24 +75,107 @@ type Image struct {
 	CreatedAt        time.Time
 }
 
+type IndieauthCode struct {
+	CodeHash      string
+	ClientID      string
+	RedirectURI   string
+	CodeChallenge string
+	Scope         string
+	CreatedAt     time.Time
+}
+
 type Note struct {
+	NoteID      string
+	Body        string
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+	DeletedAt   sql.NullTime
+	Draft       bool
+	AnnouncedAt sql.NullTime
+	Title       string
+}
+
+type NotePublishHook struct {
+	NoteID      string
+	Hook        string
+	CompletedAt time.Time
+}
+
+type NoteRevision struct {
 	NoteID    string
 	Body      string
 	CreatedAt time.Time
+	Title     string
+}
+
+type NoteSearch struct {
+	NoteID string
+	Title  string
+	Body   string
+}
+
+type NoteTag struct {
+	NoteID string
+	Tag    string
+}
+
+type OutgoingWebmention struct {
+	NoteID        string
+	Target        string
+	Status        string
+	Attempts      int64
+	LastError     string
+	NextAttemptAt time.Time
+	SentAt        sql.NullTime
+	CreatedAt     time.Time
 }
 
 type Session struct {
-	SessionID string
-	CreatedAt time.Time
+	SessionHash string
+	CreatedAt   time.Time
+	UserAgent   string
+	IPAddress   string
+	LastSeenAt  sql.NullTime
+}
+
+type Syndication struct {
+	NoteID        string
+	Target        string
+	Status        string
+	Attempts      int64
+	LastError     string
+	NextAttemptAt time.Time
+	URL           string
+	SyndicatedAt  sql.NullTime
+	CreatedAt     time.Time
 }
 
 type WebauthnCredential struct {
-	CredentialData *JSONCredential
-	CreatedAt      time.Time
+	CredentialData       *JSONCredential
+	CreatedAt            time.Time
+	WebauthnCredentialID string
+	Nickname             string
+	LastUsedAt           sql.NullTime
 }
 
 type WebauthnSession struct {
-	WebauthnSessionID string
-	SessionData       *JSONSessionData
-	CreatedAt         time.Time
+	WebauthnSessionHash string
+	SessionData         *JSONSessionData
+	CreatedAt           time.Time
+}
+
+type Webmention struct {
+	WebmentionID string
+	NoteID       string
+	Source       string
+	Target       string
+	Status       string
+	MentionType  string
+	AuthorName   string
+	AuthorURL    string
+	AuthorPhoto  string
+	Content      string
+	CreatedAt    time.Time
+	VerifiedAt   sql.NullTime
+	ApprovedAt   sql.NullTime
 }
@@ -1,28 +1,103 @@
 -- name: CreateNote :exec
-insert into note (note_id, body, created_at)
-values (:note_id, :body, :created_at);
+insert into note (note_id, title, body, created_at)
+values (:note_id, :title, :body, :created_at);
+
+-- name: CreateDraft :exec
+insert into note (note_id, title, body, created_at, draft)
+values (:note_id, :title, :body, :created_at, true);
+
+-- name: PublishDraft :exec
+update note
+set draft      = false,
+    created_at = :created_at,
+    updated_at = null
+where note_id = :note_id
+  and draft;
+
+-- name: Drafts :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is null
+  and draft
+order by created_at desc
+limit :limit;
 
 -- name: NoteByID :one
 select note_id,
        body,
-       created_at
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where note_id = :note_id
+  and deleted_at is null
+  and not draft
+  and created_at <= :now;
+
+-- name: EditableNoteByID :one
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where note_id = :note_id
+  and deleted_at is null;
+
+-- name: NoteExists :one
+select count(1) > 0
 from note
 where note_id = :note_id;
 
+-- name: NoteIsDeleted :one
+select count(1) > 0
+from note
+where note_id = :note_id
+  and deleted_at is not null;
+
 -- name: RecentNotes :many
 select note_id,
        body,
-       created_at
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
 from note
+where deleted_at is null
+  and not draft
+  and created_at <= :now
 order by created_at desc
 limit :limit;
 
 -- name: RecentNotesOlderThan :many
 select note_id,
        body,
-       created_at
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
 from note
-where created_at < (select n.created_at from note n where n.note_id = :note_id)
+where deleted_at is null
+  and not draft
+  and created_at < (select n.created_at from note n where n.note_id = :note_id)
+  and created_at <= :now
 order by created_at desc
 limit :limit;
 
@@ -30,30 +105,243 @@ limit :limit;
 select cast(date(datetime(created_at, 'weekday 0', '-7 days')) as text) as start_date,
        cast(date(datetime(created_at, 'weekday 0', '-1 day')) as text)  as end_date
 from note
+where deleted_at is null
+  and not draft
+  and created_at <= :now
 group by 1
 order by 1 desc;
 
 -- name: NotesByDate :many
 select note_id,
        body,
-       created_at
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
 from note
-where :start_date <= created_at
+where deleted_at is null
+  and not draft
+  and :start_date <= created_at
   and created_at < :end_date
+  and created_at <= :now
 order by created_at desc
 limit :limit;
 
 -- name: NotesByDateOlderThan :many
 select n.note_id,
        n.body,
-       n.created_at
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title
 from note n
-where :start_date <= n.created_at
+where n.deleted_at is null
+  and not n.draft
+  and :start_date <= n.created_at
   and n.created_at < :end_date
   and n.created_at < (select n2.created_at from note n2 where n2.note_id = :note_id)
+  and n.created_at <= :now
+order by n.created_at desc
+limit :limit;
+
+-- name: SearchNotes :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title,
+       cast(snippet(note_search, -1, char(2), char(3), '…', 24) as text) as snippet
+from note_search
+         join note n on n.note_id = note_search.note_id
+where note_search match :query
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at <= :now
+order by n.created_at desc
+limit :limit;
+
+-- name: SearchNotesOlderThan :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title,
+       cast(snippet(note_search, -1, char(2), char(3), '…', 24) as text) as snippet
+from note_search
+         join note n on n.note_id = note_search.note_id
+where note_search match :query
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at <= :now
+  and n.created_at < (select n2.created_at from note n2 where n2.note_id = :note_id)
+order by n.created_at desc
+limit :limit;
+
+-- name: NotesByTag :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title
+from note n
+         join note_tag t on t.note_id = n.note_id
+where t.tag = :tag
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at <= :now
+order by n.created_at desc
+limit :limit;
+
+-- name: NotesByTagOlderThan :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title
+from note n
+         join note_tag t on t.note_id = n.note_id
+where t.tag = :tag
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at < (select n2.created_at from note n2 where n2.note_id = :note_id)
+  and n.created_at <= :now
 order by n.created_at desc
 limit :limit;
 
+-- name: CreateNoteTag :exec
+insert into note_tag (note_id, tag)
+values (:note_id, :tag)
+on conflict do nothing;
+
+-- name: DeleteNoteTags :exec
+delete
+from note_tag
+where note_id = :note_id;
+
+-- name: BackfillCompleted :one
+select count(1) > 0
+from backfill
+where name = :name;
+
+-- name: CompleteBackfill :exec
+insert into backfill (name, completed_at)
+values (:name, :completed_at)
+on conflict (name) do nothing;
+
+-- name: AllNoteBodies :many
+select note_id, body
+from note;
+
+-- name: UpdateNote :exec
+update note
+set title      = :title,
+    body       = :body,
+    updated_at = :updated_at
+where note_id = :note_id;
+
+-- name: RescheduleNote :exec
+update note
+set created_at = :created_at
+where note_id = :note_id
+  and not draft
+  and announced_at is null;
+
+-- name: DeleteNote :exec
+update note
+set deleted_at = :deleted_at
+where note_id = :note_id
+  and deleted_at is null;
+
+-- name: RestoreNote :exec
+update note
+set deleted_at = null
+where note_id = :note_id;
+
+-- name: DeletedNotes :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is not null
+order by deleted_at desc
+limit :limit;
+
+-- name: ScheduledNotes :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is null
+  and not draft
+  and created_at > :now
+order by created_at;
+
+-- name: UnannouncedNotes :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where announced_at is null
+  and deleted_at is null
+  and not draft
+  and created_at <= :now
+order by created_at;
+
+-- name: AnnounceNote :exec
+update note
+set announced_at = :announced_at
+where note_id = :note_id;
+
+-- name: CompletedPublishHooks :many
+select hook
+from note_publish_hook
+where note_id = :note_id;
+
+-- name: CompletePublishHook :exec
+insert into note_publish_hook (note_id, hook, completed_at)
+values (:note_id, :hook, :completed_at)
+on conflict (note_id, hook) do nothing;
+
+-- name: NoteRevisions :many
+select note_id,
+       body,
+       created_at,
+       title
+from note_revision
+where note_id = :note_id
+order by created_at desc;
+
 -- name: RecentImages :many
 select *
 from image
@@ -69,44 +357,415 @@ insert into image (image_id,
 values (:image_id, :filename, :original_filename, :format, :created_at);
 
 -- name: CreateSession :exec
-insert into session (session_id, created_at)
-values (:session_id, :created_at);
+insert into session (session_hash, user_agent, ip_address, created_at, last_seen_at)
+values (:session_hash, :user_agent, :ip_address, :created_at, :created_at);
 
 -- name: SessionExists :one
 select count(1) > 0
 from session
-where session_id = :session_id
-  and created_at > :expiry;
+where session_hash = :session_hash
+  and last_seen_at > :idle_expiry
+  and created_at > :lifetime_expiry;
+
+-- name: TouchSession :exec
+update session
+set last_seen_at = :last_seen_at
+where session_hash = :session_hash;
+
+-- name: Sessions :many
+select session_hash,
+       created_at,
+       user_agent,
+       ip_address,
+       last_seen_at
+from session
+where last_seen_at > :idle_expiry
+  and created_at > :lifetime_expiry
+order by last_seen_at desc;
+
+-- name: DeleteSession :exec
+delete
+from session
+where session_hash = :session_hash;
+
+-- name: DeleteAllSessions :exec
+delete
+from session;
 
 -- name: PurgeSessions :execresult
 delete
 from session
-where created_at < :expiry;
+where last_seen_at < :idle_expiry
+   or created_at < :lifetime_expiry;
+
+-- name: CreateAPIToken :exec
+insert into api_token (api_token_id, token_hash, name, scopes, created_at, expires_at)
+values (:api_token_id, :token_hash, :name, :scopes, :created_at, :expires_at);
+
+-- name: APITokens :many
+select *
+from api_token
+order by created_at desc;
+
+-- name: APITokenByHash :one
+select *
+from api_token
+where token_hash = :token_hash
+  and (expires_at is null or expires_at > :now);
+
+-- name: UpdateAPITokenUsage :exec
+update api_token
+set last_used_at = :last_used_at
+where api_token_id = :api_token_id;
+
+-- name: DeleteAPIToken :exec
+delete
+from api_token
+where api_token_id = :api_token_id;
+
+-- name: DeleteAPITokenByHash :exec
+delete
+from api_token
+where token_hash = :token_hash;
 
 -- name: CreateWebauthnCredential :exec
-insert into webauthn_credential (credential_data, created_at)
-values (:credential_data, :created_at);
+insert into webauthn_credential (webauthn_credential_id, nickname, credential_data, created_at)
+values (:webauthn_credential_id, :nickname, :credential_data, :created_at);
 
 -- name: WebauthnCredentials :many
-select credential_data
-from webauthn_credential;
+select credential_data,
+       created_at,
+       webauthn_credential_id,
+       nickname,
+       last_used_at
+from webauthn_credential
+order by created_at;
+
+-- name: UpdateWebauthnCredentialUsage :exec
+update webauthn_credential
+set credential_data = :credential_data,
+    last_used_at    = :last_used_at
+where webauthn_credential_id = :webauthn_credential_id;
+
+-- name: DeleteWebauthnCredential :one
+delete
+from webauthn_credential
+where webauthn_credential_id = :webauthn_credential_id
+  and (select count(1) from webauthn_credential) > 1
+returning webauthn_credential_id;
+
+-- name: WebauthnCredentialExists :one
+select count(1) > 0
+from webauthn_credential
+where webauthn_credential_id = :webauthn_credential_id;
 
 -- name: HasWebauthnCredential :one
 select count(1) > 0
 from webauthn_credential;
 
 -- name: CreateWebauthnSession :exec
-insert into webauthn_session (webauthn_session_id, session_data, created_at)
-values (:webauthn_session_id, :session_data, :created_at);
+insert into webauthn_session (webauthn_session_hash, session_data, created_at)
+values (:webauthn_session_hash, :session_data, :created_at);
 
 -- name: DeleteWebauthnSession :one
 delete
 from webauthn_session
-where webauthn_session_id = :webauthn_session_id
+where webauthn_session_hash = :webauthn_session_hash
   and created_at > :expiry
 returning session_data;
 
 -- name: PurgeWebauthnSessions :execresult
 delete
 from webauthn_session
-where created_at < :expiry;
\ No newline at end of file
+where created_at < :expiry;
+
+-- name: CreateIndieauthCode :exec
+insert into indieauth_code (code_hash, client_id, redirect_uri, code_challenge, scope, created_at)
+values (:code_hash, :client_id, :redirect_uri, :code_challenge, :scope, :created_at);
+
+-- name: DeleteIndieauthCode :one
+delete
+from indieauth_code
+where code_hash = :code_hash
+  and created_at > :expiry
+returning *;
+
+-- name: PurgeIndieauthCodes :execresult
+delete
+from indieauth_code
+where created_at < :expiry;
+
+-- name: CreateWebmention :exec
+insert into webmention (webmention_id, note_id, source, target, status, created_at)
+values (:webmention_id, :note_id, :source, :target, 'pending', :created_at)
+on conflict (source, note_id) do update set target      = excluded.target,
+                                            status      = 'pending',
+                                            approved_at = null;
+
+-- name: PendingWebmentions :many
+select *
+from webmention
+where status = 'pending'
+order by created_at
+limit :limit;
+
+-- name: VerifyWebmention :exec
+update webmention
+set status       = 'verified',
+    mention_type = :mention_type,
+    author_name  = :author_name,
+    author_url   = :author_url,
+    author_photo = :author_photo,
+    content      = :content,
+    verified_at  = :verified_at
+where webmention_id = :webmention_id;
+
+-- name: InvalidateWebmention :exec
+update webmention
+set status      = 'invalid',
+    verified_at = :verified_at
+where webmention_id = :webmention_id;
+
+-- name: Webmentions :many
+select *
+from webmention
+order by created_at desc
+limit :limit;
+
+-- name: ApproveWebmention :execrows
+update webmention
+set approved_at = :approved_at
+where webmention_id = :webmention_id
+  and status = 'verified';
+
+-- name: DeleteWebmention :exec
+delete
+from webmention
+where webmention_id = :webmention_id;
+
+-- name: ApprovedWebmentionsByNote :many
+select *
+from webmention
+where note_id = :note_id
+  and status = 'verified'
+  and approved_at is not null
+order by created_at;
+
+-- name: QueueOutgoingWebmention :exec
+insert into outgoing_webmention (note_id, target, status, next_attempt_at, created_at)
+values (:note_id, :target, 'pending', :created_at, :created_at)
+on conflict (note_id, target) do nothing;
+
+-- name: RequeueOutgoingWebmention :exec
+insert into outgoing_webmention (note_id, target, status, next_attempt_at, created_at)
+values (:note_id, :target, 'pending', :created_at, :created_at)
+on conflict (note_id, target) do update set status          = 'pending',
+                                            attempts        = 0,
+                                            last_error      = '',
+                                            next_attempt_at = excluded.next_attempt_at,
+                                            sent_at         = null;
+
+-- name: DueOutgoingWebmentions :many
+select *
+from outgoing_webmention
+where status = 'pending'
+  and next_attempt_at <= :now
+order by next_attempt_at
+limit :limit;
+
+-- name: UpdateOutgoingWebmention :exec
+update outgoing_webmention
+set status          = :status,
+    attempts        = :attempts,
+    last_error      = :last_error,
+    next_attempt_at = :next_attempt_at,
+    sent_at         = :sent_at
+where note_id = :note_id
+  and target = :target;
+
+-- name: OutgoingWebmentions :many
+select *
+from outgoing_webmention
+order by created_at desc
+limit :limit;
+
+-- name: CreateFollower :exec
+insert into follower (actor_id, inbox, shared_inbox, created_at)
+values (:actor_id, :inbox, :shared_inbox, :created_at)
+on conflict (actor_id) do update set inbox        = excluded.inbox,
+                                     shared_inbox = excluded.shared_inbox;
+
+-- name: DeleteFollower :exec
+delete
+from follower
+where actor_id = :actor_id;
+
+-- name: CountFollowers :one
+select count(1)
+from follower;
+
+-- name: Followers :many
+select *
+from follower
+order by created_at;
+
+-- name: CreateActivityDelivery :exec
+insert into activity_delivery (activity_delivery_id, inbox, activity, status, next_attempt_at, created_at)
+values (:activity_delivery_id, :inbox, :activity, 'pending', :created_at, :created_at)
+on conflict (activity_delivery_id) do nothing;
+
+-- name: DueActivityDeliveries :many
+select *
+from activity_delivery
+where status = 'pending'
+  and next_attempt_at <= :now
+order by next_attempt_at
+limit :limit;
+
+-- name: UpdateActivityDelivery :exec
+update activity_delivery
+set status          = :status,
+    attempts        = :attempts,
+    last_error      = :last_error,
+    next_attempt_at = :next_attempt_at,
+    delivered_at    = :delivered_at
+where activity_delivery_id = :activity_delivery_id;
+
+-- name: PurgeActivityDeliveries :execresult
+delete
+from activity_delivery
+where status != 'pending'
+  and created_at < :expiry;
+
+-- name: QueueSyndication :exec
+insert into syndication (note_id, target, status, next_attempt_at, created_at)
+values (:note_id, :target, 'pending', :created_at, :created_at)
+on conflict (note_id, target) do nothing;
+
+-- name: DueSyndications :many
+select *
+from syndication
+where status = 'pending'
+  and next_attempt_at <= :now
+order by next_attempt_at
+limit :limit;
+
+-- name: UpdateSyndication :exec
+update syndication
+set status          = :status,
+    attempts        = :attempts,
+    last_error      = :last_error,
+    next_attempt_at = :next_attempt_at,
+    url             = :url,
+    syndicated_at   = :syndicated_at
+where note_id = :note_id
+  and target = :target;
+
+-- name: SyndicationsByNote :many
+select *
+from syndication
+where note_id = :note_id
+  and status = 'syndicated'
+order by target;
+
+-- name: CreateHubSubscription :exec
+insert into hub_subscription (callback, topic, status, lease_seconds, pending_mode, pending_secret, pending_lease_seconds,
+                              created_at)
+values (:callback, :topic, 'pending', 0, 'subscribe', :pending_secret, :pending_lease_seconds, :created_at)
+on conflict (callback, topic) do update set pending_mode          = 'subscribe',
+                                            pending_secret        = excluded.pending_secret,
+                                            pending_lease_seconds = excluded.pending_lease_seconds;
+
+-- name: RequestHubUnsubscription :exec
+update hub_subscription
+set pending_mode          = 'unsubscribe',
+    pending_secret        = '',
+    pending_lease_seconds = 0
+where callback = :callback
+  and topic = :topic;
+
+-- name: PendingHubSubscriptions :many
+select *
+from hub_subscription
+where pending_mode <> ''
+order by created_at
+limit :limit;
+
+-- name: ActivateHubSubscription :exec
+update hub_subscription
+set status                = 'active',
+    secret                = pending_secret,
+    lease_seconds         = pending_lease_seconds,
+    expires_at            = :expires_at,
+    pending_mode          = '',
+    pending_secret        = '',
+    pending_lease_seconds = 0
+where callback = :callback
+  and topic = :topic
+  and pending_mode = 'subscribe'
+  and pending_secret = :pending_secret
+  and pending_lease_seconds = :pending_lease_seconds;
+
+-- name: ClearHubSubscriptionRequest :exec
+update hub_subscription
+set pending_mode          = '',
+    pending_secret        = '',
+    pending_lease_seconds = 0
+where callback = :callback
+  and topic = :topic
+  and pending_mode = :pending_mode
+  and pending_secret = :pending_secret
+  and pending_lease_seconds = :pending_lease_seconds;
+
+-- name: DeleteHubSubscription :exec
+delete
+from hub_subscription
+where callback = :callback
+  and topic = :topic;
+
+-- name: HubSubscription :one
+select *
+from hub_subscription
+where callback = :callback
+  and topic = :topic;
+
+-- name: ActiveHubSubscriptions :many
+select *
+from hub_subscription
+where topic = :topic
+  and status = 'active'
+  and expires_at > :now
+order by created_at;
+
+-- name: PurgeHubSubscriptions :execresult
+delete
+from hub_subscription
+where status = 'active'
+  and expires_at <= :now;
+
+-- name: QueueHubNotification :exec
+insert into hub_notification (target, topic, status, next_attempt_at, created_at)
+values (:target, :topic, 'pending', :created_at, :created_at)
+on conflict (target, topic) do update set status          = 'pending',
+                                          attempts        = 0,
+                                          last_error      = '',
+                                          next_attempt_at = excluded.next_attempt_at,
+                                          notified_at     = null;
+
+-- name: DueHubNotifications :many
+select *
+from hub_notification
+where status = 'pending'
+  and next_attempt_at <= :now
+order by next_attempt_at
+limit :limit;
+
+-- name: UpdateHubNotification :exec
+update hub_notification
+set status          = :status,
+    attempts        = :attempts,
+    last_error      = :last_error,
+    next_attempt_at = :next_attempt_at,
+    notified_at     = :notified_at
+where target = :target
+  and topic = :topic;
@@ -11,6 +11,424 @@ import (
 	"time"
 )
 
+const aPITokenByHash = `-- name: APITokenByHash :one
+select api_token_id, token_hash, name, scopes, created_at, expires_at, last_used_at
+from api_token
+where token_hash = ?1
+  and (expires_at is null or expires_at > ?2)
+`
+
+func (q *Queries) APITokenByHash(ctx context.Context, tokenHash string, now sql.NullTime) (APIToken, error) {
+	row := q.queryRow(ctx, q.aPITokenByHashStmt, aPITokenByHash, tokenHash, now)
+	var i APIToken
+	err := row.Scan(
+		&i.APITokenID,
+		&i.TokenHash,
+		&i.Name,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const aPITokens = `-- name: APITokens :many
+select api_token_id, token_hash, name, scopes, created_at, expires_at, last_used_at
+from api_token
+order by created_at desc
+`
+
+func (q *Queries) APITokens(ctx context.Context) ([]APIToken, error) {
+	rows, err := q.query(ctx, q.aPITokensStmt, aPITokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIToken
+	for rows.Next() {
+		var i APIToken
+		if err := rows.Scan(
+			&i.APITokenID,
+			&i.TokenHash,
+			&i.Name,
+			&i.Scopes,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const activateHubSubscription = `-- name: ActivateHubSubscription :exec
+update hub_subscription
+set status                = 'active',
+    secret                = pending_secret,
+    lease_seconds         = pending_lease_seconds,
+    expires_at            = ?1,
+    pending_mode          = '',
+    pending_secret        = '',
+    pending_lease_seconds = 0
+where callback = ?2
+  and topic = ?3
+  and pending_mode = 'subscribe'
+  and pending_secret = ?4
+  and pending_lease_seconds = ?5
+`
+
+func (q *Queries) ActivateHubSubscription(ctx context.Context, expiresAt sql.NullTime, callback string, topic string, pendingSecret string, pendingLeaseSeconds int64) error {
+	_, err := q.exec(ctx, q.activateHubSubscriptionStmt, activateHubSubscription,
+		expiresAt,
+		callback,
+		topic,
+		pendingSecret,
+		pendingLeaseSeconds,
+	)
+	return err
+}
+
+const activeHubSubscriptions = `-- name: ActiveHubSubscriptions :many
+select callback, topic, secret, status, lease_seconds, expires_at, created_at, pending_mode, pending_secret, pending_lease_seconds
+from hub_subscription
+where topic = ?1
+  and status = 'active'
+  and expires_at > ?2
+order by created_at
+`
+
+func (q *Queries) ActiveHubSubscriptions(ctx context.Context, topic string, now sql.NullTime) ([]HubSubscription, error) {
+	rows, err := q.query(ctx, q.activeHubSubscriptionsStmt, activeHubSubscriptions, topic, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HubSubscription
+	for rows.Next() {
+		var i HubSubscription
+		if err := rows.Scan(
+			&i.Callback,
+			&i.Topic,
+			&i.Secret,
+			&i.Status,
+			&i.LeaseSeconds,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.PendingMode,
+			&i.PendingSecret,
+			&i.PendingLeaseSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const allNoteBodies = `-- name: AllNoteBodies :many
+select note_id, body
+from note
+`
+
+type AllNoteBodiesRow struct {
+	NoteID string
+	Body   string
+}
+
+func (q *Queries) AllNoteBodies(ctx context.Context) ([]AllNoteBodiesRow, error) {
+	rows, err := q.query(ctx, q.allNoteBodiesStmt, allNoteBodies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AllNoteBodiesRow
+	for rows.Next() {
+		var i AllNoteBodiesRow
+		if err := rows.Scan(&i.NoteID, &i.Body); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const announceNote = `-- name: AnnounceNote :exec
+update note
+set announced_at = ?1
+where note_id = ?2
+`
+
+func (q *Queries) AnnounceNote(ctx context.Context, announcedAt sql.NullTime, noteID string) error {
+	_, err := q.exec(ctx, q.announceNoteStmt, announceNote, announcedAt, noteID)
+	return err
+}
+
+const approveWebmention = `-- name: ApproveWebmention :execrows
+update webmention
+set approved_at = ?1
+where webmention_id = ?2
+  and status = 'verified'
+`
+
+func (q *Queries) ApproveWebmention(ctx context.Context, approvedAt sql.NullTime, webmentionID string) (int64, error) {
+	result, err := q.exec(ctx, q.approveWebmentionStmt, approveWebmention, approvedAt, webmentionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const approvedWebmentionsByNote = `-- name: ApprovedWebmentionsByNote :many
+select webmention_id, note_id, source, target, status, mention_type, author_name, author_url, author_photo, content, created_at, verified_at, approved_at
+from webmention
+where note_id = ?1
+  and status = 'verified'
+  and approved_at is not null
+order by created_at
+`
+
+func (q *Queries) ApprovedWebmentionsByNote(ctx context.Context, noteID string) ([]Webmention, error) {
+	rows, err := q.query(ctx, q.approvedWebmentionsByNoteStmt, approvedWebmentionsByNote, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webmention
+	for rows.Next() {
+		var i Webmention
+		if err := rows.Scan(
+			&i.WebmentionID,
+			&i.NoteID,
+			&i.Source,
+			&i.Target,
+			&i.Status,
+			&i.MentionType,
+			&i.AuthorName,
+			&i.AuthorURL,
+			&i.AuthorPhoto,
+			&i.Content,
+			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.ApprovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const backfillCompleted = `-- name: BackfillCompleted :one
+select count(1) > 0
+from backfill
+where name = ?1
+`
+
+func (q *Queries) BackfillCompleted(ctx context.Context, name string) (bool, error) {
+	row := q.queryRow(ctx, q.backfillCompletedStmt, backfillCompleted, name)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const clearHubSubscriptionRequest = `-- name: ClearHubSubscriptionRequest :exec
+update hub_subscription
+set pending_mode          = '',
+    pending_secret        = '',
+    pending_lease_seconds = 0
+where callback = ?1
+  and topic = ?2
+  and pending_mode = ?3
+  and pending_secret = ?4
+  and pending_lease_seconds = ?5
+`
+
+func (q *Queries) ClearHubSubscriptionRequest(ctx context.Context, callback string, topic string, pendingMode string, pendingSecret string, pendingLeaseSeconds int64) error {
+	_, err := q.exec(ctx, q.clearHubSubscriptionRequestStmt, clearHubSubscriptionRequest,
+		callback,
+		topic,
+		pendingMode,
+		pendingSecret,
+		pendingLeaseSeconds,
+	)
+	return err
+}
+
+const completeBackfill = `-- name: CompleteBackfill :exec
+insert into backfill (name, completed_at)
+values (?1, ?2)
+on conflict (name) do nothing
+`
+
+func (q *Queries) CompleteBackfill(ctx context.Context, name string, completedAt time.Time) error {
+	_, err := q.exec(ctx, q.completeBackfillStmt, completeBackfill, name, completedAt)
+	return err
+}
+
+const completePublishHook = `-- name: CompletePublishHook :exec
+insert into note_publish_hook (note_id, hook, completed_at)
+values (?1, ?2, ?3)
+on conflict (note_id, hook) do nothing
+`
+
+func (q *Queries) CompletePublishHook(ctx context.Context, noteID string, hook string, completedAt time.Time) error {
+	_, err := q.exec(ctx, q.completePublishHookStmt, completePublishHook, noteID, hook, completedAt)
+	return err
+}
+
+const completedPublishHooks = `-- name: CompletedPublishHooks :many
+select hook
+from note_publish_hook
+where note_id = ?1
+`
+
+func (q *Queries) CompletedPublishHooks(ctx context.Context, noteID string) ([]string, error) {
+	rows, err := q.query(ctx, q.completedPublishHooksStmt, completedPublishHooks, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var hook string
+		if err := rows.Scan(&hook); err != nil {
+			return nil, err
+		}
+		items = append(items, hook)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countFollowers = `-- name: CountFollowers :one
+select count(1)
+from follower
+`
+
+func (q *Queries) CountFollowers(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countFollowersStmt, countFollowers)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createAPIToken = `-- name: CreateAPIToken :exec
+insert into api_token (api_token_id, token_hash, name, scopes, created_at, expires_at)
+values (?1, ?2, ?3, ?4, ?5, ?6)
+`
+
+func (q *Queries) CreateAPIToken(ctx context.Context, apiTokenID string, tokenHash string, name string, scopes string, createdAt time.Time, expiresAt sql.NullTime) error {
+	_, err := q.exec(ctx, q.createAPITokenStmt, createAPIToken,
+		apiTokenID,
+		tokenHash,
+		name,
+		scopes,
+		createdAt,
+		expiresAt,
+	)
+	return err
+}
+
+const createActivityDelivery = `-- name: CreateActivityDelivery :exec
+insert into activity_delivery (activity_delivery_id, inbox, activity, status, next_attempt_at, created_at)
+values (?1, ?2, ?3, 'pending', ?4, ?4)
+on conflict (activity_delivery_id) do nothing
+`
+
+func (q *Queries) CreateActivityDelivery(ctx context.Context, activityDeliveryID string, inbox string, activity []byte, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createActivityDeliveryStmt, createActivityDelivery,
+		activityDeliveryID,
+		inbox,
+		activity,
+		createdAt,
+	)
+	return err
+}
+
+const createDraft = `-- name: CreateDraft :exec
+insert into note (note_id, title, body, created_at, draft)
+values (?1, ?2, ?3, ?4, true)
+`
+
+func (q *Queries) CreateDraft(ctx context.Context, noteID string, title string, body string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createDraftStmt, createDraft,
+		noteID,
+		title,
+		body,
+		createdAt,
+	)
+	return err
+}
+
+const createFollower = `-- name: CreateFollower :exec
+insert into follower (actor_id, inbox, shared_inbox, created_at)
+values (?1, ?2, ?3, ?4)
+on conflict (actor_id) do update set inbox        = excluded.inbox,
+                                     shared_inbox = excluded.shared_inbox
+`
+
+func (q *Queries) CreateFollower(ctx context.Context, actorID string, inbox string, sharedInbox string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createFollowerStmt, createFollower,
+		actorID,
+		inbox,
+		sharedInbox,
+		createdAt,
+	)
+	return err
+}
+
+const createHubSubscription = `-- name: CreateHubSubscription :exec
+insert into hub_subscription (callback, topic, status, lease_seconds, pending_mode, pending_secret, pending_lease_seconds,
+                              created_at)
+values (?1, ?2, 'pending', 0, 'subscribe', ?3, ?4, ?5)
+on conflict (callback, topic) do update set pending_mode          = 'subscribe',
+                                            pending_secret        = excluded.pending_secret,
+                                            pending_lease_seconds = excluded.pending_lease_seconds
+`
+
+func (q *Queries) CreateHubSubscription(ctx context.Context, callback string, topic string, pendingSecret string, pendingLeaseSeconds int64, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createHubSubscriptionStmt, createHubSubscription,
+		callback,
+		topic,
+		pendingSecret,
+		pendingLeaseSeconds,
+		createdAt,
+	)
+	return err
+}
+
 const createImage = `-- name: CreateImage :exec
 insert into image (image_id,
                    filename,
@@ -31,109 +449,1471 @@ func (q *Queries) CreateImage(ctx context.Context, imageID string, filename stri
 	return err
 }
 
-const createNote = `-- name: CreateNote :exec
-insert into note (note_id, body, created_at)
-values (?1, ?2, ?3)
+const createIndieauthCode = `-- name: CreateIndieauthCode :exec
+insert into indieauth_code (code_hash, client_id, redirect_uri, code_challenge, scope, created_at)
+values (?1, ?2, ?3, ?4, ?5, ?6)
+`
+
+func (q *Queries) CreateIndieauthCode(ctx context.Context, codeHash string, clientID string, redirectURI string, codeChallenge string, scope string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createIndieauthCodeStmt, createIndieauthCode,
+		codeHash,
+		clientID,
+		redirectURI,
+		codeChallenge,
+		scope,
+		createdAt,
+	)
+	return err
+}
+
+const createNote = `-- name: CreateNote :exec
+insert into note (note_id, title, body, created_at)
+values (?1, ?2, ?3, ?4)
+`
+
+func (q *Queries) CreateNote(ctx context.Context, noteID string, title string, body string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createNoteStmt, createNote,
+		noteID,
+		title,
+		body,
+		createdAt,
+	)
+	return err
+}
+
+const createNoteTag = `-- name: CreateNoteTag :exec
+insert into note_tag (note_id, tag)
+values (?1, ?2)
+on conflict do nothing
+`
+
+func (q *Queries) CreateNoteTag(ctx context.Context, noteID string, tag string) error {
+	_, err := q.exec(ctx, q.createNoteTagStmt, createNoteTag, noteID, tag)
+	return err
+}
+
+const createSession = `-- name: CreateSession :exec
+insert into session (session_hash, user_agent, ip_address, created_at, last_seen_at)
+values (?1, ?2, ?3, ?4, ?4)
+`
+
+func (q *Queries) CreateSession(ctx context.Context, sessionHash string, userAgent string, ipAddress string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createSessionStmt, createSession,
+		sessionHash,
+		userAgent,
+		ipAddress,
+		createdAt,
+	)
+	return err
+}
+
+const createWebauthnCredential = `-- name: CreateWebauthnCredential :exec
+insert into webauthn_credential (webauthn_credential_id, nickname, credential_data, created_at)
+values (?1, ?2, ?3, ?4)
+`
+
+func (q *Queries) CreateWebauthnCredential(ctx context.Context, webauthnCredentialID string, nickname string, credentialData *JSONCredential, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createWebauthnCredentialStmt, createWebauthnCredential,
+		webauthnCredentialID,
+		nickname,
+		credentialData,
+		createdAt,
+	)
+	return err
+}
+
+const createWebauthnSession = `-- name: CreateWebauthnSession :exec
+insert into webauthn_session (webauthn_session_hash, session_data, created_at)
+values (?1, ?2, ?3)
+`
+
+func (q *Queries) CreateWebauthnSession(ctx context.Context, webauthnSessionHash string, sessionData *JSONSessionData, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createWebauthnSessionStmt, createWebauthnSession, webauthnSessionHash, sessionData, createdAt)
+	return err
+}
+
+const createWebmention = `-- name: CreateWebmention :exec
+insert into webmention (webmention_id, note_id, source, target, status, created_at)
+values (?1, ?2, ?3, ?4, 'pending', ?5)
+on conflict (source, note_id) do update set target      = excluded.target,
+                                            status      = 'pending',
+                                            approved_at = null
+`
+
+func (q *Queries) CreateWebmention(ctx context.Context, webmentionID string, noteID string, source string, target string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.createWebmentionStmt, createWebmention,
+		webmentionID,
+		noteID,
+		source,
+		target,
+		createdAt,
+	)
+	return err
+}
+
+const deleteAPIToken = `-- name: DeleteAPIToken :exec
+delete
+from api_token
+where api_token_id = ?1
+`
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, apiTokenID string) error {
+	_, err := q.exec(ctx, q.deleteAPITokenStmt, deleteAPIToken, apiTokenID)
+	return err
+}
+
+const deleteAPITokenByHash = `-- name: DeleteAPITokenByHash :exec
+delete
+from api_token
+where token_hash = ?1
+`
+
+func (q *Queries) DeleteAPITokenByHash(ctx context.Context, tokenHash string) error {
+	_, err := q.exec(ctx, q.deleteAPITokenByHashStmt, deleteAPITokenByHash, tokenHash)
+	return err
+}
+
+const deleteAllSessions = `-- name: DeleteAllSessions :exec
+delete
+from session
+`
+
+func (q *Queries) DeleteAllSessions(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteAllSessionsStmt, deleteAllSessions)
+	return err
+}
+
+const deleteFollower = `-- name: DeleteFollower :exec
+delete
+from follower
+where actor_id = ?1
+`
+
+func (q *Queries) DeleteFollower(ctx context.Context, actorID string) error {
+	_, err := q.exec(ctx, q.deleteFollowerStmt, deleteFollower, actorID)
+	return err
+}
+
+const deleteHubSubscription = `-- name: DeleteHubSubscription :exec
+delete
+from hub_subscription
+where callback = ?1
+  and topic = ?2
+`
+
+func (q *Queries) DeleteHubSubscription(ctx context.Context, callback string, topic string) error {
+	_, err := q.exec(ctx, q.deleteHubSubscriptionStmt, deleteHubSubscription, callback, topic)
+	return err
+}
+
+const deleteIndieauthCode = `-- name: DeleteIndieauthCode :one
+delete
+from indieauth_code
+where code_hash = ?1
+  and created_at > ?2
+returning *
+`
+
+func (q *Queries) DeleteIndieauthCode(ctx context.Context, codeHash string, expiry time.Time) (IndieauthCode, error) {
+	row := q.queryRow(ctx, q.deleteIndieauthCodeStmt, deleteIndieauthCode, codeHash, expiry)
+	var i IndieauthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.RedirectURI,
+		&i.CodeChallenge,
+		&i.Scope,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteNote = `-- name: DeleteNote :exec
+update note
+set deleted_at = ?1
+where note_id = ?2
+  and deleted_at is null
+`
+
+func (q *Queries) DeleteNote(ctx context.Context, deletedAt sql.NullTime, noteID string) error {
+	_, err := q.exec(ctx, q.deleteNoteStmt, deleteNote, deletedAt, noteID)
+	return err
+}
+
+const deleteNoteTags = `-- name: DeleteNoteTags :exec
+delete
+from note_tag
+where note_id = ?1
+`
+
+func (q *Queries) DeleteNoteTags(ctx context.Context, noteID string) error {
+	_, err := q.exec(ctx, q.deleteNoteTagsStmt, deleteNoteTags, noteID)
+	return err
+}
+
+const deleteSession = `-- name: DeleteSession :exec
+delete
+from session
+where session_hash = ?1
+`
+
+func (q *Queries) DeleteSession(ctx context.Context, sessionHash string) error {
+	_, err := q.exec(ctx, q.deleteSessionStmt, deleteSession, sessionHash)
+	return err
+}
+
+const deleteWebauthnCredential = `-- name: DeleteWebauthnCredential :one
+delete
+from webauthn_credential
+where webauthn_credential_id = ?1
+  and (select count(1) from webauthn_credential) > 1
+returning webauthn_credential_id
+`
+
+func (q *Queries) DeleteWebauthnCredential(ctx context.Context, webauthnCredentialID string) (string, error) {
+	row := q.queryRow(ctx, q.deleteWebauthnCredentialStmt, deleteWebauthnCredential, webauthnCredentialID)
+	var webauthn_credential_id string
+	err := row.Scan(&webauthn_credential_id)
+	return webauthn_credential_id, err
+}
+
+const deleteWebauthnSession = `-- name: DeleteWebauthnSession :one
+delete
+from webauthn_session
+where webauthn_session_hash = ?1
+  and created_at > ?2
+returning session_data
+`
+
+func (q *Queries) DeleteWebauthnSession(ctx context.Context, webauthnSessionHash string, expiry time.Time) (*JSONSessionData, error) {
+	row := q.queryRow(ctx, q.deleteWebauthnSessionStmt, deleteWebauthnSession, webauthnSessionHash, expiry)
+	var session_data *JSONSessionData
+	err := row.Scan(&session_data)
+	return session_data, err
+}
+
+const deleteWebmention = `-- name: DeleteWebmention :exec
+delete
+from webmention
+where webmention_id = ?1
+`
+
+func (q *Queries) DeleteWebmention(ctx context.Context, webmentionID string) error {
+	_, err := q.exec(ctx, q.deleteWebmentionStmt, deleteWebmention, webmentionID)
+	return err
+}
+
+const deletedNotes = `-- name: DeletedNotes :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is not null
+order by deleted_at desc
+limit ?1
+`
+
+func (q *Queries) DeletedNotes(ctx context.Context, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.deletedNotesStmt, deletedNotes, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const drafts = `-- name: Drafts :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is null
+  and draft
+order by created_at desc
+limit ?1
+`
+
+func (q *Queries) Drafts(ctx context.Context, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.draftsStmt, drafts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dueActivityDeliveries = `-- name: DueActivityDeliveries :many
+select activity_delivery_id, inbox, activity, status, attempts, last_error, next_attempt_at, delivered_at, created_at
+from activity_delivery
+where status = 'pending'
+  and next_attempt_at <= ?1
+order by next_attempt_at
+limit ?2
+`
+
+func (q *Queries) DueActivityDeliveries(ctx context.Context, now time.Time, limit int64) ([]ActivityDelivery, error) {
+	rows, err := q.query(ctx, q.dueActivityDeliveriesStmt, dueActivityDeliveries, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ActivityDelivery
+	for rows.Next() {
+		var i ActivityDelivery
+		if err := rows.Scan(
+			&i.ActivityDeliveryID,
+			&i.Inbox,
+			&i.Activity,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dueHubNotifications = `-- name: DueHubNotifications :many
+select target, topic, status, attempts, last_error, next_attempt_at, notified_at, created_at
+from hub_notification
+where status = 'pending'
+  and next_attempt_at <= ?1
+order by next_attempt_at
+limit ?2
+`
+
+func (q *Queries) DueHubNotifications(ctx context.Context, now time.Time, limit int64) ([]HubNotification, error) {
+	rows, err := q.query(ctx, q.dueHubNotificationsStmt, dueHubNotifications, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HubNotification
+	for rows.Next() {
+		var i HubNotification
+		if err := rows.Scan(
+			&i.Target,
+			&i.Topic,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.NotifiedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dueOutgoingWebmentions = `-- name: DueOutgoingWebmentions :many
+select note_id, target, status, attempts, last_error, next_attempt_at, sent_at, created_at
+from outgoing_webmention
+where status = 'pending'
+  and next_attempt_at <= ?1
+order by next_attempt_at
+limit ?2
+`
+
+func (q *Queries) DueOutgoingWebmentions(ctx context.Context, now time.Time, limit int64) ([]OutgoingWebmention, error) {
+	rows, err := q.query(ctx, q.dueOutgoingWebmentionsStmt, dueOutgoingWebmentions, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutgoingWebmention
+	for rows.Next() {
+		var i OutgoingWebmention
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Target,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.SentAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dueSyndications = `-- name: DueSyndications :many
+select note_id, target, status, attempts, last_error, next_attempt_at, url, syndicated_at, created_at
+from syndication
+where status = 'pending'
+  and next_attempt_at <= ?1
+order by next_attempt_at
+limit ?2
+`
+
+func (q *Queries) DueSyndications(ctx context.Context, now time.Time, limit int64) ([]Syndication, error) {
+	rows, err := q.query(ctx, q.dueSyndicationsStmt, dueSyndications, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Syndication
+	for rows.Next() {
+		var i Syndication
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Target,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.URL,
+			&i.SyndicatedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const editableNoteByID = `-- name: EditableNoteByID :one
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where note_id = ?1
+  and deleted_at is null
+`
+
+func (q *Queries) EditableNoteByID(ctx context.Context, noteID string) (Note, error) {
+	row := q.queryRow(ctx, q.editableNoteByIDStmt, editableNoteByID, noteID)
+	var i Note
+	err := row.Scan(
+		&i.NoteID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Draft,
+		&i.AnnouncedAt,
+		&i.Title,
+	)
+	return i, err
+}
+
+const followers = `-- name: Followers :many
+select actor_id, inbox, shared_inbox, created_at
+from follower
+order by created_at
+`
+
+func (q *Queries) Followers(ctx context.Context) ([]Follower, error) {
+	rows, err := q.query(ctx, q.followersStmt, followers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Follower
+	for rows.Next() {
+		var i Follower
+		if err := rows.Scan(
+			&i.ActorID,
+			&i.Inbox,
+			&i.SharedInbox,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasWebauthnCredential = `-- name: HasWebauthnCredential :one
+select count(1) > 0
+from webauthn_credential
+`
+
+func (q *Queries) HasWebauthnCredential(ctx context.Context) (bool, error) {
+	row := q.queryRow(ctx, q.hasWebauthnCredentialStmt, hasWebauthnCredential)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const hubSubscription = `-- name: HubSubscription :one
+select callback, topic, secret, status, lease_seconds, expires_at, created_at, pending_mode, pending_secret, pending_lease_seconds
+from hub_subscription
+where callback = ?1
+  and topic = ?2
+`
+
+func (q *Queries) HubSubscription(ctx context.Context, callback string, topic string) (HubSubscription, error) {
+	row := q.queryRow(ctx, q.hubSubscriptionStmt, hubSubscription, callback, topic)
+	var i HubSubscription
+	err := row.Scan(
+		&i.Callback,
+		&i.Topic,
+		&i.Secret,
+		&i.Status,
+		&i.LeaseSeconds,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.PendingMode,
+		&i.PendingSecret,
+		&i.PendingLeaseSeconds,
+	)
+	return i, err
+}
+
+const invalidateWebmention = `-- name: InvalidateWebmention :exec
+update webmention
+set status      = 'invalid',
+    verified_at = ?1
+where webmention_id = ?2
+`
+
+func (q *Queries) InvalidateWebmention(ctx context.Context, verifiedAt sql.NullTime, webmentionID string) error {
+	_, err := q.exec(ctx, q.invalidateWebmentionStmt, invalidateWebmention, verifiedAt, webmentionID)
+	return err
+}
+
+const noteByID = `-- name: NoteByID :one
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where note_id = ?1
+  and deleted_at is null
+  and not draft
+  and created_at <= ?2
+`
+
+func (q *Queries) NoteByID(ctx context.Context, noteID string, now time.Time) (Note, error) {
+	row := q.queryRow(ctx, q.noteByIDStmt, noteByID, noteID, now)
+	var i Note
+	err := row.Scan(
+		&i.NoteID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Draft,
+		&i.AnnouncedAt,
+		&i.Title,
+	)
+	return i, err
+}
+
+const noteExists = `-- name: NoteExists :one
+select count(1) > 0
+from note
+where note_id = ?1
+`
+
+func (q *Queries) NoteExists(ctx context.Context, noteID string) (bool, error) {
+	row := q.queryRow(ctx, q.noteExistsStmt, noteExists, noteID)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const noteIsDeleted = `-- name: NoteIsDeleted :one
+select count(1) > 0
+from note
+where note_id = ?1
+  and deleted_at is not null
+`
+
+func (q *Queries) NoteIsDeleted(ctx context.Context, noteID string) (bool, error) {
+	row := q.queryRow(ctx, q.noteIsDeletedStmt, noteIsDeleted, noteID)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const noteRevisions = `-- name: NoteRevisions :many
+select note_id,
+       body,
+       created_at,
+       title
+from note_revision
+where note_id = ?1
+order by created_at desc
+`
+
+func (q *Queries) NoteRevisions(ctx context.Context, noteID string) ([]NoteRevision, error) {
+	rows, err := q.query(ctx, q.noteRevisionsStmt, noteRevisions, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteRevision
+	for rows.Next() {
+		var i NoteRevision
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notesByDate = `-- name: NotesByDate :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is null
+  and not draft
+  and ?1 <= created_at
+  and created_at < ?2
+  and created_at <= ?3
+order by created_at desc
+limit ?4
+`
+
+func (q *Queries) NotesByDate(ctx context.Context, startDate time.Time, endDate time.Time, now time.Time, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.notesByDateStmt, notesByDate,
+		startDate,
+		endDate,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notesByDateOlderThan = `-- name: NotesByDateOlderThan :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title
+from note n
+where n.deleted_at is null
+  and not n.draft
+  and ?1 <= n.created_at
+  and n.created_at < ?2
+  and n.created_at < (select n2.created_at from note n2 where n2.note_id = ?3)
+  and n.created_at <= ?4
+order by n.created_at desc
+limit ?5
+`
+
+func (q *Queries) NotesByDateOlderThan(ctx context.Context, startDate time.Time, endDate time.Time, noteID string, now time.Time, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.notesByDateOlderThanStmt, notesByDateOlderThan,
+		startDate,
+		endDate,
+		noteID,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notesByTag = `-- name: NotesByTag :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title
+from note n
+         join note_tag t on t.note_id = n.note_id
+where t.tag = ?1
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at <= ?2
+order by n.created_at desc
+limit ?3
+`
+
+func (q *Queries) NotesByTag(ctx context.Context, tag string, now time.Time, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.notesByTagStmt, notesByTag, tag, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notesByTagOlderThan = `-- name: NotesByTagOlderThan :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title
+from note n
+         join note_tag t on t.note_id = n.note_id
+where t.tag = ?1
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at < (select n2.created_at from note n2 where n2.note_id = ?2)
+  and n.created_at <= ?3
+order by n.created_at desc
+limit ?4
+`
+
+func (q *Queries) NotesByTagOlderThan(ctx context.Context, tag string, noteID string, now time.Time, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.notesByTagOlderThanStmt, notesByTagOlderThan,
+		tag,
+		noteID,
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const outgoingWebmentions = `-- name: OutgoingWebmentions :many
+select note_id, target, status, attempts, last_error, next_attempt_at, sent_at, created_at
+from outgoing_webmention
+order by created_at desc
+limit ?1
+`
+
+func (q *Queries) OutgoingWebmentions(ctx context.Context, limit int64) ([]OutgoingWebmention, error) {
+	rows, err := q.query(ctx, q.outgoingWebmentionsStmt, outgoingWebmentions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutgoingWebmention
+	for rows.Next() {
+		var i OutgoingWebmention
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Target,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.SentAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pendingHubSubscriptions = `-- name: PendingHubSubscriptions :many
+select callback, topic, secret, status, lease_seconds, expires_at, created_at, pending_mode, pending_secret, pending_lease_seconds
+from hub_subscription
+where pending_mode <> ''
+order by created_at
+limit ?1
+`
+
+func (q *Queries) PendingHubSubscriptions(ctx context.Context, limit int64) ([]HubSubscription, error) {
+	rows, err := q.query(ctx, q.pendingHubSubscriptionsStmt, pendingHubSubscriptions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HubSubscription
+	for rows.Next() {
+		var i HubSubscription
+		if err := rows.Scan(
+			&i.Callback,
+			&i.Topic,
+			&i.Secret,
+			&i.Status,
+			&i.LeaseSeconds,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.PendingMode,
+			&i.PendingSecret,
+			&i.PendingLeaseSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pendingWebmentions = `-- name: PendingWebmentions :many
+select webmention_id, note_id, source, target, status, mention_type, author_name, author_url, author_photo, content, created_at, verified_at, approved_at
+from webmention
+where status = 'pending'
+order by created_at
+limit ?1
+`
+
+func (q *Queries) PendingWebmentions(ctx context.Context, limit int64) ([]Webmention, error) {
+	rows, err := q.query(ctx, q.pendingWebmentionsStmt, pendingWebmentions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webmention
+	for rows.Next() {
+		var i Webmention
+		if err := rows.Scan(
+			&i.WebmentionID,
+			&i.NoteID,
+			&i.Source,
+			&i.Target,
+			&i.Status,
+			&i.MentionType,
+			&i.AuthorName,
+			&i.AuthorURL,
+			&i.AuthorPhoto,
+			&i.Content,
+			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.ApprovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDraft = `-- name: PublishDraft :exec
+update note
+set draft      = false,
+    created_at = ?1,
+    updated_at = null
+where note_id = ?2
+  and draft
+`
+
+func (q *Queries) PublishDraft(ctx context.Context, createdAt time.Time, noteID string) error {
+	_, err := q.exec(ctx, q.publishDraftStmt, publishDraft, createdAt, noteID)
+	return err
+}
+
+const purgeActivityDeliveries = `-- name: PurgeActivityDeliveries :execresult
+delete
+from activity_delivery
+where status != 'pending'
+  and created_at < ?1
+`
+
+func (q *Queries) PurgeActivityDeliveries(ctx context.Context, expiry time.Time) (sql.Result, error) {
+	return q.exec(ctx, q.purgeActivityDeliveriesStmt, purgeActivityDeliveries, expiry)
+}
+
+const purgeHubSubscriptions = `-- name: PurgeHubSubscriptions :execresult
+delete
+from hub_subscription
+where status = 'active'
+  and expires_at <= ?1
+`
+
+func (q *Queries) PurgeHubSubscriptions(ctx context.Context, now sql.NullTime) (sql.Result, error) {
+	return q.exec(ctx, q.purgeHubSubscriptionsStmt, purgeHubSubscriptions, now)
+}
+
+const purgeIndieauthCodes = `-- name: PurgeIndieauthCodes :execresult
+delete
+from indieauth_code
+where created_at < ?1
+`
+
+func (q *Queries) PurgeIndieauthCodes(ctx context.Context, expiry time.Time) (sql.Result, error) {
+	return q.exec(ctx, q.purgeIndieauthCodesStmt, purgeIndieauthCodes, expiry)
+}
+
+const purgeSessions = `-- name: PurgeSessions :execresult
+delete
+from session
+where last_seen_at < ?1
+   or created_at < ?2
+`
+
+func (q *Queries) PurgeSessions(ctx context.Context, idleExpiry sql.NullTime, lifetimeExpiry time.Time) (sql.Result, error) {
+	return q.exec(ctx, q.purgeSessionsStmt, purgeSessions, idleExpiry, lifetimeExpiry)
+}
+
+const purgeWebauthnSessions = `-- name: PurgeWebauthnSessions :execresult
+delete
+from webauthn_session
+where created_at < ?1
+`
+
+func (q *Queries) PurgeWebauthnSessions(ctx context.Context, expiry time.Time) (sql.Result, error) {
+	return q.exec(ctx, q.purgeWebauthnSessionsStmt, purgeWebauthnSessions, expiry)
+}
+
+const queueHubNotification = `-- name: QueueHubNotification :exec
+insert into hub_notification (target, topic, status, next_attempt_at, created_at)
+values (?1, ?2, 'pending', ?3, ?3)
+on conflict (target, topic) do update set status          = 'pending',
+                                          attempts        = 0,
+                                          last_error      = '',
+                                          next_attempt_at = excluded.next_attempt_at,
+                                          notified_at     = null
+`
+
+func (q *Queries) QueueHubNotification(ctx context.Context, target string, topic string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.queueHubNotificationStmt, queueHubNotification, target, topic, createdAt)
+	return err
+}
+
+const queueOutgoingWebmention = `-- name: QueueOutgoingWebmention :exec
+insert into outgoing_webmention (note_id, target, status, next_attempt_at, created_at)
+values (?1, ?2, 'pending', ?3, ?3)
+on conflict (note_id, target) do nothing
+`
+
+func (q *Queries) QueueOutgoingWebmention(ctx context.Context, noteID string, target string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.queueOutgoingWebmentionStmt, queueOutgoingWebmention, noteID, target, createdAt)
+	return err
+}
+
+const queueSyndication = `-- name: QueueSyndication :exec
+insert into syndication (note_id, target, status, next_attempt_at, created_at)
+values (?1, ?2, 'pending', ?3, ?3)
+on conflict (note_id, target) do nothing
+`
+
+func (q *Queries) QueueSyndication(ctx context.Context, noteID string, target string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.queueSyndicationStmt, queueSyndication, noteID, target, createdAt)
+	return err
+}
+
+const recentImages = `-- name: RecentImages :many
+select image_id, filename, original_filename, format, created_at
+from image
+order by created_at desc
+limit ?1
+`
+
+func (q *Queries) RecentImages(ctx context.Context, limit int64) ([]Image, error) {
+	rows, err := q.query(ctx, q.recentImagesStmt, recentImages, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Image
+	for rows.Next() {
+		var i Image
+		if err := rows.Scan(
+			&i.ImageID,
+			&i.Filename,
+			&i.OriginalFilename,
+			&i.Format,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recentNotes = `-- name: RecentNotes :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is null
+  and not draft
+  and created_at <= ?1
+order by created_at desc
+limit ?2
+`
+
+func (q *Queries) RecentNotes(ctx context.Context, now time.Time, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.recentNotesStmt, recentNotes, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recentNotesOlderThan = `-- name: RecentNotesOlderThan :many
+select note_id,
+       body,
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
+from note
+where deleted_at is null
+  and not draft
+  and created_at < (select n.created_at from note n where n.note_id = ?1)
+  and created_at <= ?2
+order by created_at desc
+limit ?3
 `
 
-func (q *Queries) CreateNote(ctx context.Context, noteID string, body string, createdAt time.Time) error {
-	_, err := q.exec(ctx, q.createNoteStmt, createNote, noteID, body, createdAt)
-	return err
+func (q *Queries) RecentNotesOlderThan(ctx context.Context, noteID string, now time.Time, limit int64) ([]Note, error) {
+	rows, err := q.query(ctx, q.recentNotesOlderThanStmt, recentNotesOlderThan, noteID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const createSession = `-- name: CreateSession :exec
-insert into session (session_id, created_at)
-values (?1, ?2)
+const requestHubUnsubscription = `-- name: RequestHubUnsubscription :exec
+update hub_subscription
+set pending_mode          = 'unsubscribe',
+    pending_secret        = '',
+    pending_lease_seconds = 0
+where callback = ?1
+  and topic = ?2
 `
 
-func (q *Queries) CreateSession(ctx context.Context, sessionID string, createdAt time.Time) error {
-	_, err := q.exec(ctx, q.createSessionStmt, createSession, sessionID, createdAt)
+func (q *Queries) RequestHubUnsubscription(ctx context.Context, callback string, topic string) error {
+	_, err := q.exec(ctx, q.requestHubUnsubscriptionStmt, requestHubUnsubscription, callback, topic)
 	return err
 }
 
-const createWebauthnCredential = `-- name: CreateWebauthnCredential :exec
-insert into webauthn_credential (credential_data, created_at)
-values (?1, ?2)
+const requeueOutgoingWebmention = `-- name: RequeueOutgoingWebmention :exec
+insert into outgoing_webmention (note_id, target, status, next_attempt_at, created_at)
+values (?1, ?2, 'pending', ?3, ?3)
+on conflict (note_id, target) do update set status          = 'pending',
+                                            attempts        = 0,
+                                            last_error      = '',
+                                            next_attempt_at = excluded.next_attempt_at,
+                                            sent_at         = null
 `
 
-func (q *Queries) CreateWebauthnCredential(ctx context.Context, credentialData *JSONCredential, createdAt time.Time) error {
-	_, err := q.exec(ctx, q.createWebauthnCredentialStmt, createWebauthnCredential, credentialData, createdAt)
+func (q *Queries) RequeueOutgoingWebmention(ctx context.Context, noteID string, target string, createdAt time.Time) error {
+	_, err := q.exec(ctx, q.requeueOutgoingWebmentionStmt, requeueOutgoingWebmention, noteID, target, createdAt)
 	return err
 }
 
-const createWebauthnSession = `-- name: CreateWebauthnSession :exec
-insert into webauthn_session (webauthn_session_id, session_data, created_at)
-values (?1, ?2, ?3)
+const rescheduleNote = `-- name: RescheduleNote :exec
+update note
+set created_at = ?1
+where note_id = ?2
+  and not draft
+  and announced_at is null
 `
 
-func (q *Queries) CreateWebauthnSession(ctx context.Context, webauthnSessionID string, sessionData *JSONSessionData, createdAt time.Time) error {
-	_, err := q.exec(ctx, q.createWebauthnSessionStmt, createWebauthnSession, webauthnSessionID, sessionData, createdAt)
+func (q *Queries) RescheduleNote(ctx context.Context, createdAt time.Time, noteID string) error {
+	_, err := q.exec(ctx, q.rescheduleNoteStmt, rescheduleNote, createdAt, noteID)
 	return err
 }
 
-const deleteWebauthnSession = `-- name: DeleteWebauthnSession :one
-delete
-from webauthn_session
-where webauthn_session_id = ?1
-  and created_at > ?2
-returning session_data
-`
-
-func (q *Queries) DeleteWebauthnSession(ctx context.Context, webauthnSessionID string, expiry time.Time) (*JSONSessionData, error) {
-	row := q.queryRow(ctx, q.deleteWebauthnSessionStmt, deleteWebauthnSession, webauthnSessionID, expiry)
-	var session_data *JSONSessionData
-	err := row.Scan(&session_data)
-	return session_data, err
-}
-
-const hasWebauthnCredential = `-- name: HasWebauthnCredential :one
-select count(1) > 0
-from webauthn_credential
+const restoreNote = `-- name: RestoreNote :exec
+update note
+set deleted_at = null
+where note_id = ?1
 `
 
-func (q *Queries) HasWebauthnCredential(ctx context.Context) (bool, error) {
-	row := q.queryRow(ctx, q.hasWebauthnCredentialStmt, hasWebauthnCredential)
-	var column_1 bool
-	err := row.Scan(&column_1)
-	return column_1, err
+func (q *Queries) RestoreNote(ctx context.Context, noteID string) error {
+	_, err := q.exec(ctx, q.restoreNoteStmt, restoreNote, noteID)
+	return err
 }
 
-const noteByID = `-- name: NoteByID :one
+const scheduledNotes = `-- name: ScheduledNotes :many
 select note_id,
        body,
-       created_at
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
 from note
-where note_id = ?1
+where deleted_at is null
+  and not draft
+  and created_at > ?1
+order by created_at
 `
 
-func (q *Queries) NoteByID(ctx context.Context, noteID string) (Note, error) {
-	row := q.queryRow(ctx, q.noteByIDStmt, noteByID, noteID)
-	var i Note
-	err := row.Scan(&i.NoteID, &i.Body, &i.CreatedAt)
-	return i, err
+func (q *Queries) ScheduledNotes(ctx context.Context, now time.Time) ([]Note, error) {
+	rows, err := q.query(ctx, q.scheduledNotesStmt, scheduledNotes, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const notesByDate = `-- name: NotesByDate :many
-select note_id,
-       body,
-       created_at
-from note
-where ?1 <= created_at
-  and created_at < ?2
-order by created_at desc
+const searchNotes = `-- name: SearchNotes :many
+select n.note_id,
+       n.body,
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title,
+       cast(snippet(note_search, -1, char(2), char(3), '…', 24) as text) as snippet
+from note_search
+         join note n on n.note_id = note_search.note_id
+where note_search match ?1
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at <= ?2
+order by n.created_at desc
 limit ?3
 `
 
-func (q *Queries) NotesByDate(ctx context.Context, startDate time.Time, endDate time.Time, limit int64) ([]Note, error) {
-	rows, err := q.query(ctx, q.notesByDateStmt, notesByDate, startDate, endDate, limit)
+type SearchNotesRow struct {
+	NoteID      string
+	Body        string
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+	DeletedAt   sql.NullTime
+	Draft       bool
+	AnnouncedAt sql.NullTime
+	Title       string
+	Snippet     string
+}
+
+func (q *Queries) SearchNotes(ctx context.Context, query string, now time.Time, limit int64) ([]SearchNotesRow, error) {
+	rows, err := q.query(ctx, q.searchNotesStmt, searchNotes, query, now, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Note
+	var items []SearchNotesRow
 	for rows.Next() {
-		var i Note
-		if err := rows.Scan(&i.NoteID, &i.Body, &i.CreatedAt); err != nil {
+		var i SearchNotesRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+			&i.Snippet,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -147,22 +1927,43 @@ func (q *Queries) NotesByDate(ctx context.Context, startDate time.Time, endDate
 	return items, nil
 }
 
-const notesByDateOlderThan = `-- name: NotesByDateOlderThan :many
+const searchNotesOlderThan = `-- name: SearchNotesOlderThan :many
 select n.note_id,
        n.body,
-       n.created_at
-from note n
-where ?1 <= n.created_at
-  and n.created_at < ?2
+       n.created_at,
+       n.updated_at,
+       n.deleted_at,
+       n.draft,
+       n.announced_at,
+       n.title,
+       cast(snippet(note_search, -1, char(2), char(3), '…', 24) as text) as snippet
+from note_search
+         join note n on n.note_id = note_search.note_id
+where note_search match ?1
+  and n.deleted_at is null
+  and not n.draft
+  and n.created_at <= ?2
   and n.created_at < (select n2.created_at from note n2 where n2.note_id = ?3)
 order by n.created_at desc
 limit ?4
 `
 
-func (q *Queries) NotesByDateOlderThan(ctx context.Context, startDate time.Time, endDate time.Time, noteID string, limit int64) ([]Note, error) {
-	rows, err := q.query(ctx, q.notesByDateOlderThanStmt, notesByDateOlderThan,
-		startDate,
-		endDate,
+type SearchNotesOlderThanRow struct {
+	NoteID      string
+	Body        string
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+	DeletedAt   sql.NullTime
+	Draft       bool
+	AnnouncedAt sql.NullTime
+	Title       string
+	Snippet     string
+}
+
+func (q *Queries) SearchNotesOlderThan(ctx context.Context, query string, now time.Time, noteID string, limit int64) ([]SearchNotesOlderThanRow, error) {
+	rows, err := q.query(ctx, q.searchNotesOlderThanStmt, searchNotesOlderThan,
+		query,
+		now,
 		noteID,
 		limit,
 	)
@@ -170,10 +1971,20 @@ func (q *Queries) NotesByDateOlderThan(ctx context.Context, startDate time.Time,
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Note
+	var items []SearchNotesOlderThanRow
 	for rows.Next() {
-		var i Note
-		if err := rows.Scan(&i.NoteID, &i.Body, &i.CreatedAt); err != nil {
+		var i SearchNotesOlderThanRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+			&i.Snippet,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -187,48 +1998,48 @@ func (q *Queries) NotesByDateOlderThan(ctx context.Context, startDate time.Time,
 	return items, nil
 }
 
-const purgeSessions = `-- name: PurgeSessions :execresult
-delete
+const sessionExists = `-- name: SessionExists :one
+select count(1) > 0
 from session
-where created_at < ?1
-`
-
-func (q *Queries) PurgeSessions(ctx context.Context, expiry time.Time) (sql.Result, error) {
-	return q.exec(ctx, q.purgeSessionsStmt, purgeSessions, expiry)
-}
-
-const purgeWebauthnSessions = `-- name: PurgeWebauthnSessions :execresult
-delete
-from webauthn_session
-where created_at < ?1
+where session_hash = ?1
+  and last_seen_at > ?2
+  and created_at > ?3
 `
 
-func (q *Queries) PurgeWebauthnSessions(ctx context.Context, expiry time.Time) (sql.Result, error) {
-	return q.exec(ctx, q.purgeWebauthnSessionsStmt, purgeWebauthnSessions, expiry)
+func (q *Queries) SessionExists(ctx context.Context, sessionHash string, idleExpiry sql.NullTime, lifetimeExpiry time.Time) (bool, error) {
+	row := q.queryRow(ctx, q.sessionExistsStmt, sessionExists, sessionHash, idleExpiry, lifetimeExpiry)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
 }
 
-const recentImages = `-- name: RecentImages :many
-select image_id, filename, original_filename, format, created_at
-from image
-order by created_at desc
-limit ?1
+const sessions = `-- name: Sessions :many
+select session_hash,
+       created_at,
+       user_agent,
+       ip_address,
+       last_seen_at
+from session
+where last_seen_at > ?1
+  and created_at > ?2
+order by last_seen_at desc
 `
 
-func (q *Queries) RecentImages(ctx context.Context, limit int64) ([]Image, error) {
-	rows, err := q.query(ctx, q.recentImagesStmt, recentImages, limit)
+func (q *Queries) Sessions(ctx context.Context, idleExpiry sql.NullTime, lifetimeExpiry time.Time) ([]Session, error) {
+	rows, err := q.query(ctx, q.sessionsStmt, sessions, idleExpiry, lifetimeExpiry)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Image
+	var items []Session
 	for rows.Next() {
-		var i Image
+		var i Session
 		if err := rows.Scan(
-			&i.ImageID,
-			&i.Filename,
-			&i.OriginalFilename,
-			&i.Format,
+			&i.SessionHash,
 			&i.CreatedAt,
+			&i.UserAgent,
+			&i.IPAddress,
+			&i.LastSeenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -243,25 +2054,34 @@ func (q *Queries) RecentImages(ctx context.Context, limit int64) ([]Image, error
 	return items, nil
 }
 
-const recentNotes = `-- name: RecentNotes :many
-select note_id,
-       body,
-       created_at
-from note
-order by created_at desc
-limit ?1
+const syndicationsByNote = `-- name: SyndicationsByNote :many
+select note_id, target, status, attempts, last_error, next_attempt_at, url, syndicated_at, created_at
+from syndication
+where note_id = ?1
+  and status = 'syndicated'
+order by target
 `
 
-func (q *Queries) RecentNotes(ctx context.Context, limit int64) ([]Note, error) {
-	rows, err := q.query(ctx, q.recentNotesStmt, recentNotes, limit)
+func (q *Queries) SyndicationsByNote(ctx context.Context, noteID string) ([]Syndication, error) {
+	rows, err := q.query(ctx, q.syndicationsByNoteStmt, syndicationsByNote, noteID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Note
+	var items []Syndication
 	for rows.Next() {
-		var i Note
-		if err := rows.Scan(&i.NoteID, &i.Body, &i.CreatedAt); err != nil {
+		var i Syndication
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Target,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.URL,
+			&i.SyndicatedAt,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -275,18 +2095,36 @@ func (q *Queries) RecentNotes(ctx context.Context, limit int64) ([]Note, error)
 	return items, nil
 }
 
-const recentNotesOlderThan = `-- name: RecentNotesOlderThan :many
+const touchSession = `-- name: TouchSession :exec
+update session
+set last_seen_at = ?1
+where session_hash = ?2
+`
+
+func (q *Queries) TouchSession(ctx context.Context, lastSeenAt sql.NullTime, sessionHash string) error {
+	_, err := q.exec(ctx, q.touchSessionStmt, touchSession, lastSeenAt, sessionHash)
+	return err
+}
+
+const unannouncedNotes = `-- name: UnannouncedNotes :many
 select note_id,
        body,
-       created_at
+       created_at,
+       updated_at,
+       deleted_at,
+       draft,
+       announced_at,
+       title
 from note
-where created_at < (select n.created_at from note n where n.note_id = ?1)
-order by created_at desc
-limit ?2
+where announced_at is null
+  and deleted_at is null
+  and not draft
+  and created_at <= ?1
+order by created_at
 `
 
-func (q *Queries) RecentNotesOlderThan(ctx context.Context, noteID string, limit int64) ([]Note, error) {
-	rows, err := q.query(ctx, q.recentNotesOlderThanStmt, recentNotesOlderThan, noteID, limit)
+func (q *Queries) UnannouncedNotes(ctx context.Context, now time.Time) ([]Note, error) {
+	rows, err := q.query(ctx, q.unannouncedNotesStmt, unannouncedNotes, now)
 	if err != nil {
 		return nil, err
 	}
@@ -294,7 +2132,16 @@ func (q *Queries) RecentNotesOlderThan(ctx context.Context, noteID string, limit
 	var items []Note
 	for rows.Next() {
 		var i Note
-		if err := rows.Scan(&i.NoteID, &i.Body, &i.CreatedAt); err != nil {
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Draft,
+			&i.AnnouncedAt,
+			&i.Title,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -308,38 +2155,254 @@ func (q *Queries) RecentNotesOlderThan(ctx context.Context, noteID string, limit
 	return items, nil
 }
 
-const sessionExists = `-- name: SessionExists :one
+const updateAPITokenUsage = `-- name: UpdateAPITokenUsage :exec
+update api_token
+set last_used_at = ?1
+where api_token_id = ?2
+`
+
+func (q *Queries) UpdateAPITokenUsage(ctx context.Context, lastUsedAt sql.NullTime, apiTokenID string) error {
+	_, err := q.exec(ctx, q.updateAPITokenUsageStmt, updateAPITokenUsage, lastUsedAt, apiTokenID)
+	return err
+}
+
+const updateActivityDelivery = `-- name: UpdateActivityDelivery :exec
+update activity_delivery
+set status          = ?1,
+    attempts        = ?2,
+    last_error      = ?3,
+    next_attempt_at = ?4,
+    delivered_at    = ?5
+where activity_delivery_id = ?6
+`
+
+func (q *Queries) UpdateActivityDelivery(ctx context.Context, status string, attempts int64, lastError string, nextAttemptAt time.Time, deliveredAt sql.NullTime, activityDeliveryID string) error {
+	_, err := q.exec(ctx, q.updateActivityDeliveryStmt, updateActivityDelivery,
+		status,
+		attempts,
+		lastError,
+		nextAttemptAt,
+		deliveredAt,
+		activityDeliveryID,
+	)
+	return err
+}
+
+const updateHubNotification = `-- name: UpdateHubNotification :exec
+update hub_notification
+set status          = ?1,
+    attempts        = ?2,
+    last_error      = ?3,
+    next_attempt_at = ?4,
+    notified_at     = ?5
+where target = ?6
+  and topic = ?7
+`
+
+func (q *Queries) UpdateHubNotification(ctx context.Context, status string, attempts int64, lastError string, nextAttemptAt time.Time, notifiedAt sql.NullTime, target string, topic string) error {
+	_, err := q.exec(ctx, q.updateHubNotificationStmt, updateHubNotification,
+		status,
+		attempts,
+		lastError,
+		nextAttemptAt,
+		notifiedAt,
+		target,
+		topic,
+	)
+	return err
+}
+
+const updateNote = `-- name: UpdateNote :exec
+update note
+set title      = ?1,
+    body       = ?2,
+    updated_at = ?3
+where note_id = ?4
+`
+
+func (q *Queries) UpdateNote(ctx context.Context, title string, body string, updatedAt sql.NullTime, noteID string) error {
+	_, err := q.exec(ctx, q.updateNoteStmt, updateNote,
+		title,
+		body,
+		updatedAt,
+		noteID,
+	)
+	return err
+}
+
+const updateOutgoingWebmention = `-- name: UpdateOutgoingWebmention :exec
+update outgoing_webmention
+set status          = ?1,
+    attempts        = ?2,
+    last_error      = ?3,
+    next_attempt_at = ?4,
+    sent_at         = ?5
+where note_id = ?6
+  and target = ?7
+`
+
+func (q *Queries) UpdateOutgoingWebmention(ctx context.Context, status string, attempts int64, lastError string, nextAttemptAt time.Time, sentAt sql.NullTime, noteID string, target string) error {
+	_, err := q.exec(ctx, q.updateOutgoingWebmentionStmt, updateOutgoingWebmention,
+		status,
+		attempts,
+		lastError,
+		nextAttemptAt,
+		sentAt,
+		noteID,
+		target,
+	)
+	return err
+}
+
+const updateSyndication = `-- name: UpdateSyndication :exec
+update syndication
+set status          = ?1,
+    attempts        = ?2,
+    last_error      = ?3,
+    next_attempt_at = ?4,
+    url             = ?5,
+    syndicated_at   = ?6
+where note_id = ?7
+  and target = ?8
+`
+
+func (q *Queries) UpdateSyndication(ctx context.Context, status string, attempts int64, lastError string, nextAttemptAt time.Time, url string, syndicatedAt sql.NullTime, noteID string, target string) error {
+	_, err := q.exec(ctx, q.updateSyndicationStmt, updateSyndication,
+		status,
+		attempts,
+		lastError,
+		nextAttemptAt,
+		url,
+		syndicatedAt,
+		noteID,
+		target,
+	)
+	return err
+}
+
+const updateWebauthnCredentialUsage = `-- name: UpdateWebauthnCredentialUsage :exec
+update webauthn_credential
+set credential_data = ?1,
+    last_used_at    = ?2
+where webauthn_credential_id = ?3
+`
+
+func (q *Queries) UpdateWebauthnCredentialUsage(ctx context.Context, credentialData *JSONCredential, lastUsedAt sql.NullTime, webauthnCredentialID string) error {
+	_, err := q.exec(ctx, q.updateWebauthnCredentialUsageStmt, updateWebauthnCredentialUsage, credentialData, lastUsedAt, webauthnCredentialID)
+	return err
+}
+
+const verifyWebmention = `-- name: VerifyWebmention :exec
+update webmention
+set status       = 'verified',
+    mention_type = ?1,
+    author_name  = ?2,
+    author_url   = ?3,
+    author_photo = ?4,
+    content      = ?5,
+    verified_at  = ?6
+where webmention_id = ?7
+`
+
+func (q *Queries) VerifyWebmention(ctx context.Context, mentionType string, authorName string, authorURL string, authorPhoto string, content string, verifiedAt sql.NullTime, webmentionID string) error {
+	_, err := q.exec(ctx, q.verifyWebmentionStmt, verifyWebmention,
+		mentionType,
+		authorName,
+		authorURL,
+		authorPhoto,
+		content,
+		verifiedAt,
+		webmentionID,
+	)
+	return err
+}
+
+const webauthnCredentialExists = `-- name: WebauthnCredentialExists :one
 select count(1) > 0
-from session
-where session_id = ?1
-  and created_at > ?2
+from webauthn_credential
+where webauthn_credential_id = ?1
 `
 
-func (q *Queries) SessionExists(ctx context.Context, sessionID string, expiry time.Time) (bool, error) {
-	row := q.queryRow(ctx, q.sessionExistsStmt, sessionExists, sessionID, expiry)
+func (q *Queries) WebauthnCredentialExists(ctx context.Context, webauthnCredentialID string) (bool, error) {
+	row := q.queryRow(ctx, q.webauthnCredentialExistsStmt, webauthnCredentialExists, webauthnCredentialID)
 	var column_1 bool
 	err := row.Scan(&column_1)
 	return column_1, err
 }
 
 const webauthnCredentials = `-- name: WebauthnCredentials :many
-select credential_data
+select credential_data,
+       created_at,
+       webauthn_credential_id,
+       nickname,
+       last_used_at
 from webauthn_credential
+order by created_at
 `
 
-func (q *Queries) WebauthnCredentials(ctx context.Context) ([]*JSONCredential, error) {
+func (q *Queries) WebauthnCredentials(ctx context.Context) ([]WebauthnCredential, error) {
 	rows, err := q.query(ctx, q.webauthnCredentialsStmt, webauthnCredentials)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []*JSONCredential
+	var items []WebauthnCredential
+	for rows.Next() {
+		var i WebauthnCredential
+		if err := rows.Scan(
+			&i.CredentialData,
+			&i.CreatedAt,
+			&i.WebauthnCredentialID,
+			&i.Nickname,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const webmentions = `-- name: Webmentions :many
+select webmention_id, note_id, source, target, status, mention_type, author_name, author_url, author_photo, content, created_at, verified_at, approved_at
+from webmention
+order by created_at desc
+limit ?1
+`
+
+func (q *Queries) Webmentions(ctx context.Context, limit int64) ([]Webmention, error) {
+	rows, err := q.query(ctx, q.webmentionsStmt, webmentions, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webmention
 	for rows.Next() {
-		var credential_data *JSONCredential
-		if err := rows.Scan(&credential_data); err != nil {
+		var i Webmention
+		if err := rows.Scan(
+			&i.WebmentionID,
+			&i.NoteID,
+			&i.Source,
+			&i.Target,
+			&i.Status,
+			&i.MentionType,
+			&i.AuthorName,
+			&i.AuthorURL,
+			&i.AuthorPhoto,
+			&i.Content,
+			&i.CreatedAt,
+			&i.VerifiedAt,
+			&i.ApprovedAt,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, credential_data)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -354,6 +2417,9 @@ const weeksWithNotes = `-- name: WeeksWithNotes :many
 select cast(date(datetime(created_at, 'weekday 0', '-7 days')) as text) as start_date,
        cast(date(datetime(created_at, 'weekday 0', '-1 day')) as text)  as end_date
 from note
+where deleted_at is null
+  and not draft
+  and created_at <= ?1
 group by 1
 order by 1 desc
 `
@@ -363,8 +2429,8 @@ type WeeksWithNotesRow struct {
 	EndDate   string
 }
 
-func (q *Queries) WeeksWithNotes(ctx context.Context) ([]WeeksWithNotesRow, error) {
-	rows, err := q.query(ctx, q.weeksWithNotesStmt, weeksWithNotes)
+func (q *Queries) WeeksWithNotes(ctx context.Context, now time.Time) ([]WeeksWithNotesRow, error) {
+	rows, err := q.query(ctx, q.weeksWithNotesStmt, weeksWithNotes, now)
 	if err != nil {
 		return nil, err
 	}
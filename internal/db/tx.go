@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Tx runs f with a copy of q which uses a new transaction. If f returns nil, the transaction is committed; otherwise,
+// it's rolled back.
+func (q *Queries) Tx(ctx context.Context, f func(q *Queries) error) error {
+	conn, ok := q.db.(*sql.DB)
+	if !ok {
+		return errors.New("queries are already in a transaction")
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := f(q.WithTx(tx)); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
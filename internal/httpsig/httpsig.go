@@ -0,0 +1,150 @@
+package httpsig
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxSkew is the maximum difference between a signed request's Date header and the current time.
+const MaxSkew = 12 * time.Hour
+
+var (
+	// ErrMissingSignature is returned when a request has no Signature header.
+	ErrMissingSignature = errors.New("missing signature")
+
+	// ErrInvalidSignature is returned when a request's signature is malformed, incomplete, stale, or doesn't verify.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// A KeyLookup returns the public key with the given ID.
+type KeyLookup func(ctx context.Context, keyID string) (*rsa.PublicKey, error)
+
+// Sign signs the request with an rsa-sha256 signature, per draft-cavage-http-signatures-12, over the request target, Host
+// and Date headers, and, if the request has a body, a SHA-256 Digest header. The Date and Digest headers are set on the
+// request.
+func Sign(req *http.Request, keyID string, key *rsa.PrivateKey, body []byte) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", digest(body))
+		headers = append(headers, "digest")
+	}
+
+	hash := sha256.Sum256([]byte(signingString(req, headers)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// Verify checks the request's signature and returns the ID of the key which signed it. The signature must cover the
+// request target, Host and Date headers, and, if the request has a body, a Digest header which matches the body.
+func Verify(req *http.Request, body []byte, lookup KeyLookup) (string, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return "", ErrMissingSignature
+	}
+
+	params := parseParams(header)
+	keyID, algorithm := params["keyId"], params["algorithm"]
+	if keyID == "" || (algorithm != "" && algorithm != "rsa-sha256" && algorithm != "hs2019") {
+		return "", fmt.Errorf("%w: unsupported key or algorithm", ErrInvalidSignature)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	required := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		required = append(required, "digest")
+	}
+
+	for _, h := range required {
+		if !slices.Contains(headers, h) {
+			return "", fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, h)
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil || time.Since(date).Abs() > MaxSkew {
+		return "", fmt.Errorf("%w: stale or missing date", ErrInvalidSignature)
+	}
+
+	if body != nil && subtle.ConstantTimeCompare([]byte(req.Header.Get("Digest")), []byte(digest(body))) != 1 {
+		return "", fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	key, err := lookup(req.Context(), keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up key %q: %w", keyID, err)
+	}
+
+	hash := sha256.Sum256([]byte(signingString(req, headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	return keyID, nil
+}
+
+// signingString returns the string to be signed for the given request and headers.
+func signingString(req *http.Request, headers []string) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		var value string
+		switch h {
+		case "(request-target)":
+			// Use the original request URI on the server side, since the URL may have been rewritten.
+			uri := req.RequestURI
+			if uri == "" {
+				uri = req.URL.RequestURI()
+			}
+			value = strings.ToLower(req.Method) + " " + uri
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		default:
+			value = strings.Join(req.Header.Values(h), ", ")
+		}
+		lines[i] = h + ": " + value
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseParams parses the comma-separated key="value" parameters of a Signature header.
+func parseParams(header string) map[string]string {
+	params := make(map[string]string)
+	for param := range strings.SplitSeq(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	return params
+}
+
+func digest(body []byte) string {
+	hash := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(hash[:])
+}
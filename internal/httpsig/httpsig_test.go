@@ -0,0 +1,74 @@
+package httpsig_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/httpsig"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lookup := func(_ context.Context, keyID string) (*rsa.PublicKey, error) {
+		if keyID != "https://example.com/actor#main-key" {
+			return nil, errors.New("unknown key")
+		}
+		return &key.PublicKey, nil
+	}
+
+	newRequest := func(body []byte) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "https://example.com/inbox", bytes.NewReader(body))
+		if err := httpsig.Sign(req, "https://example.com/actor#main-key", key, body); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+
+	keyID, err := httpsig.Verify(newRequest(body), body, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := keyID, "https://example.com/actor#main-key"; got != want {
+		t.Errorf("keyID = %q, want = %q", got, want)
+	}
+
+	for name, tc := range map[string]struct {
+		modify  func(req *http.Request)
+		body    []byte
+		wantErr error
+	}{
+		"tampered body": {func(*http.Request) {}, []byte(`{"type":"Undo"}`), httpsig.ErrInvalidSignature},
+		"missing signature": {func(req *http.Request) {
+			req.Header.Del("Signature")
+		}, body, httpsig.ErrMissingSignature},
+		"stale date": {func(req *http.Request) {
+			req.Header.Set("Date", time.Now().Add(-24*time.Hour).UTC().Format(http.TimeFormat))
+		}, body, httpsig.ErrInvalidSignature},
+		"different target": {func(req *http.Request) {
+			req.RequestURI = "/outbox"
+		}, body, httpsig.ErrInvalidSignature},
+	} {
+		req := newRequest(body)
+		tc.modify(req)
+
+		if _, err := httpsig.Verify(req, tc.body, lookup); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: Verify() err = %v, want = %v", name, err, tc.wantErr)
+		}
+	}
+}
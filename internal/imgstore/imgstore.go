@@ -12,7 +12,9 @@ import (
 	"io"
 	"io/fs"
 	"math"
+	"mime"
 	"os"
+	"path"
 
 	"github.com/HugoSmits86/nativewebp"
 	"github.com/google/uuid"
@@ -20,6 +22,9 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// ErrInvalidImage is returned when an added image can't be decoded or is in an unsupported format.
+var ErrInvalidImage = errors.New("invalid image")
+
 type Store struct {
 	root   *os.Root
 	images *os.Root
@@ -98,12 +103,26 @@ func (s *Store) ThumbImages() fs.FS {
 	return s.thumb.FS()
 }
 
+// FeedImageInfo returns the MIME type and size in bytes of the given feed image.
+func (s *Store) FeedImageInfo(filename string) (mimeType string, length int64, err error) {
+	fi, err := s.feed.Stat(filename)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat feed image %q: %w", filename, err)
+	}
+
+	mimeType = mime.TypeByExtension(path.Ext(filename))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return mimeType, fi.Size(), nil
+}
+
 func (s *Store) Add(ctx context.Context, id uuid.UUID, r io.Reader) (filename string, format string, err error) {
 	// Decode the image config, preserving the read part of the image in a buffer.
 	buf := new(bytes.Buffer)
 	_, format, err = image.DecodeConfig(io.TeeReader(r, buf))
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decode image configuration: %w", err)
+		return "", "", fmt.Errorf("failed to decode image configuration: %w: %w", ErrInvalidImage, err)
 	}
 
 	// Reassemble the image reader using the buffer.
@@ -129,7 +148,7 @@ func (s *Store) Add(ctx context.Context, id uuid.UUID, r io.Reader) (filename st
 	// Fully decode the image.
 	origImg, _, err := image.Decode(r)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to decode image: %w", err)
+		return "", "", fmt.Errorf("failed to decode image: %w: %w", ErrInvalidImage, err)
 	}
 
 	// Generate thumbnails.
@@ -140,7 +159,7 @@ func (s *Store) processAnim(ctx context.Context, r io.Reader, filename string) e
 	// Decode all frames.
 	img, err := gif.DecodeAll(r)
 	if err != nil {
-		return fmt.Errorf("failed to decode animated GIF: %w", err)
+		return fmt.Errorf("failed to decode animated GIF: %w: %w", ErrInvalidImage, err)
 	}
 
 	// If there's only one frame, treat it as a static image.
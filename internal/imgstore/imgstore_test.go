@@ -2,6 +2,7 @@ package imgstore_test
 
 import (
 	"image"
+	"io/fs"
 	"os"
 	"testing"
 
@@ -75,6 +76,24 @@ func TestStore_Add_Static(t *testing.T) {
 	if got, want := thumbImg.Bounds(), image.Rect(0, 0, 100, 100); !cmp.Equal(got, want) {
 		t.Errorf("Bounds = %d, want %d", got, want)
 	}
+
+	mimeType, length, err := store.FeedImageInfo(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := mimeType, "image/webp"; got != want {
+		t.Errorf("mimeType = %q, want %q", got, want)
+	}
+
+	fi, err := fs.Stat(store.FeedImages(), filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := length, fi.Size(); got != want {
+		t.Errorf("length = %d, want %d", got, want)
+	}
 }
 
 func TestStore_Add_Animated(t *testing.T) {
@@ -0,0 +1,129 @@
+package markdown
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// NormalizeTag returns the canonical form of a hashtag, without the leading #.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// kindHashtag is the AST node kind for hashtags.
+var kindHashtag = ast.NewNodeKind("Hashtag") //nolint:gochecknoglobals // node kinds must be registered once
+
+// hashtagNode is an inline AST node for a #hashtag.
+type hashtagNode struct {
+	ast.BaseInline
+	Tag []byte
+}
+
+func (n *hashtagNode) Kind() ast.NodeKind {
+	return kindHashtag
+}
+
+func (n *hashtagNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Tag": string(n.Tag)}, nil)
+}
+
+// hashtagParser parses #hashtags which are at the start of a line or preceded by whitespace. Hashtags consist of
+// letters, digits, and underscores, and must contain at least one letter.
+type hashtagParser struct{}
+
+func (hashtagParser) Trigger() []byte {
+	return []byte{'#'}
+}
+
+func (hashtagParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	if prev := block.PrecendingCharacter(); !unicode.IsSpace(prev) {
+		return nil
+	}
+
+	line, _ := block.PeekLine()
+	if len(line) < 2 || line[0] != '#' {
+		return nil
+	}
+
+	var (
+		n         = 1
+		hasLetter bool
+	)
+	for n < len(line) {
+		r, size := utf8.DecodeRune(line[n:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+		n += size
+	}
+
+	if !hasLetter {
+		return nil
+	}
+
+	block.Advance(n)
+	return &hashtagNode{Tag: line[1:n]}
+}
+
+// hashtagRenderer renders #hashtags as links to their tag pages.
+type hashtagRenderer struct {
+	baseURL *url.URL
+}
+
+func (r *hashtagRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindHashtag, r.render)
+}
+
+func (r *hashtagRenderer) render(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*hashtagNode) //nolint:errcheck,forcetypeassert // the renderer is only registered for hashtags
+
+	// Don't render links inside of links.
+	if inLink(n) || r.baseURL == nil {
+		_ = w.WriteByte('#')
+		_, _ = w.Write(util.EscapeHTML(n.Tag))
+		return ast.WalkContinue, nil
+	}
+
+	href := r.baseURL.JoinPath("tags", NormalizeTag(string(n.Tag))).String()
+	_, _ = w.WriteString(`<a href="`)
+	_, _ = w.Write(util.EscapeHTML([]byte(href)))
+	_, _ = w.WriteString(`" class="hashtag">#`)
+	_, _ = w.Write(util.EscapeHTML(n.Tag))
+	_, _ = w.WriteString(`</a>`)
+	return ast.WalkContinue, nil
+}
+
+// hashtags is a goldmark extension which parses #hashtags and renders them as links to tag pages relative to the base
+// URL.
+type hashtags struct {
+	baseURL *url.URL
+}
+
+func (e *hashtags) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(hashtagParser{}, 999)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&hashtagRenderer{e.baseURL}, 999)))
+}
+
+// inLink returns true if the node is a descendant of a link.
+func inLink(n ast.Node) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if _, ok := p.(*ast.Link); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"html/template"
 	"net/url"
+	"slices"
 	"strings"
+	"unicode"
 
 	_ "github.com/alecthomas/chroma/v2" // include chroma as a direct dependency
 	"github.com/valyala/bytebufferpool"
@@ -33,10 +35,14 @@ func Images(s string) ([]*url.URL, error) {
 }
 
 func Text(s string) (string, error) {
-	b := bytebufferpool.Get()
-	defer bytebufferpool.Put(b)
+	node := textMarkdown().Parser().Parse(text.NewReader([]byte(s)))
+	return plainText(node, []byte(s))
+}
 
-	md := goldmark.New(goldmark.WithExtensions(
+// textMarkdown returns a Markdown processor which uses Unicode characters for typographic substitutions, rather than
+// HTML entities.
+func textMarkdown() goldmark.Markdown {
+	return goldmark.New(goldmark.WithExtensions(
 		extension.GFM,
 		extension.NewTypographer(
 			extension.WithTypographicSubstitutions(
@@ -52,12 +58,18 @@ func Text(s string) (string, error) {
 					extension.RightAngleQuote:  []byte(`»`),
 					extension.Apostrophe:       []byte(`’`),
 				}))))
-	node := md.Parser().Parse(text.NewReader([]byte(s)))
+}
+
+// plainText returns the text content of the given node.
+func plainText(node ast.Node, source []byte) (string, error) {
+	b := bytebufferpool.Get()
+	defer bytebufferpool.Put(b)
+
 	if err := ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		switch n := n.(type) {
 		case *ast.Text:
 			if entering {
-				_, _ = b.Write(n.Segment.Value([]byte(s)))
+				_, _ = b.Write(n.Segment.Value(source))
 			}
 		case *ast.String:
 			if entering {
@@ -76,14 +88,122 @@ func Text(s string) (string, error) {
 	return strings.TrimSpace(b.String()), nil
 }
 
-func HTML(s string) (template.HTML, error) {
+func Tags(s string) ([]string, error) {
+	var tags []string
+	node := goldmark.New(goldmark.WithExtensions(extension.GFM, &hashtags{})).Parser().Parse(text.NewReader([]byte(s)))
+	if err := ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*hashtagNode); ok && entering && !inLink(n) {
+			if tag := NormalizeTag(string(n.Tag)); !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		return ast.WalkContinue, nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to walk markdown AST for tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Links returns the unique absolute HTTP and HTTPS URLs linked to from the given Markdown text, including bare URLs.
+func Links(s string) ([]string, error) {
+	var links []string
+	source := []byte(s)
+	node := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(source))
+	if err := ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		var dest string
+		switch n := n.(type) {
+		case *ast.Link:
+			dest = string(n.Destination)
+		case *ast.AutoLink:
+			if n.AutoLinkType != ast.AutoLinkURL {
+				return ast.WalkContinue, nil
+			}
+			dest = string(n.URL(source))
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		u, err := url.Parse(dest)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ast.WalkContinue, nil
+		}
+
+		if link := u.String(); !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+		return ast.WalkContinue, nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to walk markdown AST for links: %w", err)
+	}
+	return links, nil
+}
+
+// Title returns a title for the given Markdown text: the text of its first heading, if any, or an excerpt of its text
+// truncated at a word boundary.
+func Title(s string) (string, error) {
+	node := textMarkdown().Parser().Parse(text.NewReader([]byte(s)))
+
+	var heading *ast.Heading
+	if err := ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*ast.Heading); ok && entering {
+			heading = n
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	}); err != nil {
+		return "", fmt.Errorf("failed to walk markdown AST for title: %w", err)
+	}
+
+	if heading != nil {
+		title, err := plainText(heading, []byte(s))
+		if err != nil {
+			return "", err
+		}
+
+		if title != "" {
+			return title, nil
+		}
+	}
+
+	excerpt, err := plainText(node, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return truncate(excerpt, maxTitleLen), nil
+}
+
+// maxTitleLen is the maximum length, in runes, of a title excerpt.
+const maxTitleLen = 80
+
+// truncate shortens the string to at most n runes, breaking at the last word boundary and appending an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	cut := string(runes[:n])
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+func HTML(s string, baseURL *url.URL) (template.HTML, error) {
 	b := bytebufferpool.Get()
 	defer bytebufferpool.Put(b)
 
 	md := goldmark.New(goldmark.WithExtensions(
 		extension.GFM,
 		highlighting.NewHighlighting(highlighting.WithStyle("monokai")),
-		extension.NewTypographer()),
+		extension.NewTypographer(),
+		&hashtags{baseURL}),
 	)
 	if err := md.Convert([]byte(s), b); err != nil {
 		return "", fmt.Errorf("failed to convert markdown to HTML: %w", err)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/url"
+	"slices"
 	"testing"
 
 	"github.com/codahale/yellhole-go/internal/markdown"
@@ -12,7 +13,7 @@ import (
 func TestMarkdownHTML(t *testing.T) {
 	t.Parallel()
 
-	html, err := markdown.HTML("It's ~~not~~ _electric_!")
+	html, err := markdown.HTML("It's ~~not~~ _electric_!", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -58,3 +59,67 @@ func TestMarkdownImages(t *testing.T) {
 		t.Errorf("images[1].String() = %q, want = %q", got, want)
 	}
 }
+
+func TestMarkdownHTMLHashtags(t *testing.T) {
+	t.Parallel()
+
+	baseURL, _ := url.Parse("http://example.com/")
+
+	html, err := markdown.HTML("It's #Electric! [#not](http://example.com) a#tag or #1", baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := template.HTML(`<p>It&rsquo;s <a href="http://example.com/tags/electric" class="hashtag">#Electric</a>! ` +
+		`<a href="http://example.com">#not</a> a#tag or #1</p>` + "\n")
+	if got := html; got != want {
+		t.Errorf("HTML(s) = %q, want = %q", got, want)
+	}
+}
+
+func TestMarkdownTags(t *testing.T) {
+	t.Parallel()
+
+	tags, err := markdown.Tags("# Heading\n\n#Boogie woogie #woogie #boogie.\n\n- #list_item\n\n`#code`")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := tags, []string{"boogie", "woogie", "list_item"}; !slices.Equal(got, want) {
+		t.Errorf("Tags(s) = %q, want = %q", got, want)
+	}
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	t.Parallel()
+
+	links, err := markdown.Links("See [this](https://example.com/a) and https://example.org/b.\n\n" +
+		"Also [this again](https://example.com/a), [a relative link](/c), <mailto:me@example.com>, and ![an image](https://example.com/d.png).")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := links, []string{"https://example.com/a", "https://example.org/b"}; !slices.Equal(got, want) {
+		t.Errorf("Links(s) = %q, want = %q", got, want)
+	}
+}
+
+func TestMarkdownTitle(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[string]string{
+		"Some intro.\n\n## It's a *heading*\n\nMore text.": "It’s a heading",
+		"It's _electric_!\n\nBoogie woogie woogie.":        "It’s electric! Boogie woogie woogie.",
+		"This is a much longer note which goes on and on, well past the point where anyone would want to read it as a title.": "This is a much longer note which goes on and on, well past the point where…",
+		"": "",
+	} {
+		title, err := markdown.Title(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := title; got != want {
+			t.Errorf("Title(%q) = %q, want = %q", s, got, want)
+		}
+	}
+}
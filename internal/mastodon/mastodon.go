@@ -0,0 +1,158 @@
+// Package mastodon implements the parts of the Mastodon API needed to cross-post notes to Mastodon-compatible servers.
+package mastodon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"time"
+)
+
+// MaxAttachments is the number of media attachments Mastodon allows on a status.
+const MaxAttachments = 4
+
+// A Client makes authenticated requests to a Mastodon server's API.
+type Client struct {
+	// Server is the base URL of the Mastodon server.
+	Server *url.URL
+
+	// Token is an access token with the write:statuses and write:media scopes.
+	Token string
+
+	// HTTP is the HTTP client used to make requests.
+	HTTP *http.Client
+
+	// PollInterval is how often the status of media which is still being processed is checked.
+	PollInterval time.Duration
+}
+
+// A Status is a new status to be posted.
+type Status struct {
+	Text           string
+	MediaIDs       []string
+	Language       string
+	IdempotencyKey string
+}
+
+// UploadMedia uploads a media attachment and returns its ID. If the server processes the media asynchronously, it waits
+// until processing is complete.
+func (c *Client) UploadMedia(ctx context.Context, filename, contentType string, r io.Reader) (string, error) {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", multipart.FileContentDisposition("file", filename))
+	header.Set("Content-Type", contentType)
+
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to create media form: %w", err)
+	}
+
+	if _, err := io.Copy(part, r); err != nil {
+		return "", fmt.Errorf("failed to read media %q: %w", filename, err)
+	}
+
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to create media form: %w", err)
+	}
+
+	var attachment struct {
+		ID string `json:"id"`
+	}
+	status, err := c.do(ctx, http.MethodPost, "api/v2/media", form.FormDataContentType(), "", body, &attachment)
+	if err != nil {
+		return "", err
+	}
+
+	// Wait for asynchronously processed media to be ready, since statuses can't be posted with it until then.
+	for status == http.StatusAccepted || status == http.StatusPartialContent {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(c.PollInterval):
+		}
+
+		status, err = c.do(ctx, http.MethodGet, "api/v1/media/"+url.PathEscape(attachment.ID), "", "", nil, &attachment)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return attachment.ID, nil
+}
+
+// PostStatus posts a public status and returns its URL.
+func (c *Client) PostStatus(ctx context.Context, status *Status) (string, error) {
+	body, err := json.Marshal(struct {
+		Status     string   `json:"status"`
+		MediaIDs   []string `json:"media_ids,omitempty"`
+		Language   string   `json:"language,omitempty"`
+		Visibility string   `json:"visibility"`
+	}{
+		Status:     status.Text,
+		MediaIDs:   status.MediaIDs,
+		Language:   status.Language,
+		Visibility: "public",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode status: %w", err)
+	}
+
+	var posted struct {
+		URL string `json:"url"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "api/v1/statuses", "application/json", status.IdempotencyKey, bytes.NewReader(body), &posted); err != nil {
+		return "", err
+	}
+
+	if posted.URL == "" {
+		return "", errors.New("posted status has no URL")
+	}
+	return posted.URL, nil
+}
+
+// do makes an authenticated API request and decodes the JSON response into v, returning the response's status code.
+func (c *Client) do(ctx context.Context, method, path, contentType, idempotencyKey string, body io.Reader, v any) (status int, err error) {
+	endpoint := c.Server.JoinPath(path).String()
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request for %q: %w", endpoint, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to request %q: %w", endpoint, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&apiErr)
+		return 0, fmt.Errorf("unexpected response from %q: %s %s", endpoint, resp.Status, apiErr.Error)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return 0, fmt.Errorf("failed to decode response from %q: %w", endpoint, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,105 @@
+package mastodon_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/codahale/yellhole-go/internal/mastodon"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	var polls int
+	var posted struct {
+		Status     string   `json:"status"`
+		MediaIDs   []string `json:"media_ids"`
+		Visibility string   `json:"visibility"`
+	}
+	var idempotencyKey string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/media", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		if string(b) != "image" || header.Filename != "cat.png" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"id":"123"}`))
+	})
+	mux.HandleFunc("GET /api/v1/media/123", func(w http.ResponseWriter, _ *http.Request) {
+		polls++
+		if polls < 2 {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		_, _ = w.Write([]byte(`{"id":"123"}`))
+	})
+	mux.HandleFunc("POST /api/v1/statuses", func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey = r.Header.Get("Idempotency-Key")
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"456","url":"https://mastodon.example/@notes/456"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	serverURL, _ := url.Parse(server.URL)
+	client := &mastodon.Client{Server: serverURL, Token: "token", HTTP: server.Client()}
+
+	mediaID, err := client.UploadMedia(t.Context(), "cat.png", "image/png", strings.NewReader("image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := polls, 2; got != want {
+		t.Errorf("polls = %d, want = %d", got, want)
+	}
+
+	statusURL, err := client.PostStatus(t.Context(), &mastodon.Status{Text: "Hello.", MediaIDs: []string{mediaID}, IdempotencyKey: "note"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := statusURL, "https://mastodon.example/@notes/456"; got != want {
+		t.Errorf("statusURL = %q, want = %q", got, want)
+	}
+
+	if got, want := posted.MediaIDs, []string{"123"}; !slices.Equal(got, want) {
+		t.Errorf("media_ids = %v, want = %v", got, want)
+	}
+
+	if got, want := posted.Visibility, "public"; got != want {
+		t.Errorf("visibility = %q, want = %q", got, want)
+	}
+
+	if got, want := idempotencyKey, "note"; got != want {
+		t.Errorf("Idempotency-Key = %q, want = %q", got, want)
+	}
+
+	// Errors include the server's error message.
+	client.Token = "bad"
+	if _, err := client.UploadMedia(t.Context(), "cat.png", "image/png", strings.NewReader("image")); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want = 401 error", err)
+	}
+}
@@ -0,0 +1,371 @@
+// Package microformats implements a parser for microformats2 markup in HTML documents.
+//
+// It follows the microformats2 parsing specification (https://microformats.org/wiki/microformats2-parsing) closely
+// enough for IndieWeb use, but doesn't support backcompat parsing of classic microformats or the value class pattern.
+// Implied photo and url properties are only suppressed by explicit photo and url properties, so that common authorship
+// markup like <a class="h-card" href="/"><img class="u-photo" src="/me.jpg"> Jane</a> has both.
+package microformats
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// An Item is a parsed microformat.
+type Item struct {
+	// Type is the item's root class names, e.g. "h-entry".
+	Type []string
+
+	// Properties are the item's properties, keyed by name without the prefix (e.g. "url" for u-url). Values are either
+	// strings, *Item values for nested microformats, or Embedded values for e-* properties.
+	Properties map[string][]any
+
+	// Value is the value of a nested microformat which is also a property of its parent.
+	Value string
+
+	// Children are nested microformats which aren't properties of the item.
+	Children []*Item
+}
+
+// Embedded is the value of an e-* property.
+type Embedded struct {
+	HTML  string
+	Value string
+}
+
+// String returns the first value of the given property as a string. Nested microformats are represented by their value,
+// and embedded markup by its text.
+func (item *Item) String(name string) string {
+	values := item.Properties[name]
+	if len(values) == 0 {
+		return ""
+	}
+
+	switch v := values[0].(type) {
+	case string:
+		return v
+	case *Item:
+		return v.Value
+	case Embedded:
+		return v.Value
+	default:
+		return ""
+	}
+}
+
+// Item returns the first value of the given property if it's a nested microformat.
+func (item *Item) Item(name string) *Item {
+	for _, v := range item.Properties[name] {
+		if v, ok := v.(*Item); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// HasType returns true if the item has the given root class name.
+func (item *Item) HasType(typ string) bool {
+	return slices.Contains(item.Type, typ)
+}
+
+// Parse returns the top-level microformats in the document. Relative URLs are resolved against the base URL, or the
+// document's base element, if any.
+func Parse(doc *html.Node, base *url.URL) []*Item {
+	for n := range doc.Descendants() {
+		if n.DataAtom == atom.Base {
+			if href, ok := attr(n, "href"); ok {
+				if u, err := base.Parse(href); err == nil {
+					base = u
+				}
+			}
+			break
+		}
+	}
+
+	p := &parser{base: base}
+	return p.items(doc)
+}
+
+// Find returns the first microformat of the given type in the items or their descendants, in document order.
+func Find(items []*Item, typ string) *Item {
+	for _, item := range items {
+		if item.HasType(typ) {
+			return item
+		}
+
+		var nested []*Item
+		for _, values := range item.Properties {
+			for _, v := range values {
+				if v, ok := v.(*Item); ok {
+					nested = append(nested, v)
+				}
+			}
+		}
+
+		if found := Find(append(nested, item.Children...), typ); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+type parser struct {
+	base *url.URL
+}
+
+// items returns the microformats among the descendants of n, without descending into them.
+func (p *parser) items(n *html.Node) []*Item {
+	var items []*Item
+	for c := range n.ChildNodes() {
+		if types := prefixed(c, "h-"); len(types) > 0 {
+			items = append(items, p.item(c, types))
+		} else {
+			items = append(items, p.items(c)...)
+		}
+	}
+	return items
+}
+
+// item parses the microformat rooted at n.
+func (p *parser) item(n *html.Node, types []string) *Item {
+	item := &Item{Type: types, Properties: make(map[string][]any)}
+	p.properties(n, item)
+	p.implied(n, item)
+	return item
+}
+
+// properties adds the properties and children found among the descendants of n to the item.
+func (p *parser) properties(n *html.Node, item *Item) {
+	for c := range n.ChildNodes() {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		types := prefixed(c, "h-")
+		var nested *Item
+		if len(types) > 0 {
+			nested = p.item(c, types)
+		}
+
+		props := 0
+		for _, prefix := range []string{"p-", "u-", "dt-", "e-"} {
+			for _, name := range prefixed(c, prefix) {
+				props++
+				name = strings.TrimPrefix(name, prefix)
+				value := p.value(c, prefix)
+
+				if nested != nil {
+					// A nested microformat's value is its name or URL, if it has one, or the property's value.
+					v := *nested
+					switch {
+					case prefix == "p-" && v.String("name") != "":
+						v.Value = v.String("name")
+					case prefix == "u-" && v.String("url") != "":
+						v.Value = v.String("url")
+					case prefix == "e-":
+						v.Value = value.(Embedded).Value
+					default:
+						v.Value, _ = value.(string)
+					}
+					item.Properties[name] = append(item.Properties[name], &v)
+				} else {
+					item.Properties[name] = append(item.Properties[name], value)
+				}
+			}
+		}
+
+		switch {
+		case nested != nil && props == 0:
+			item.Children = append(item.Children, nested)
+		case nested == nil:
+			p.properties(c, item)
+		}
+	}
+}
+
+// value returns the value of a property with the given prefix on n.
+func (p *parser) value(n *html.Node, prefix string) any {
+	switch prefix {
+	case "u-":
+		if v, ok := urlAttr(n); ok {
+			return p.resolve(v)
+		}
+		if v, ok := valueAttr(n); ok {
+			return p.resolve(v)
+		}
+		return p.resolve(text(n))
+	case "dt-":
+		switch n.DataAtom {
+		case atom.Time, atom.Ins, atom.Del:
+			if v, ok := attr(n, "datetime"); ok {
+				return v
+			}
+		}
+		if v, ok := valueAttr(n); ok {
+			return v
+		}
+		return text(n)
+	case "e-":
+		var b strings.Builder
+		for c := range n.ChildNodes() {
+			_ = html.Render(&b, c)
+		}
+		return Embedded{HTML: strings.TrimSpace(b.String()), Value: text(n)}
+	default:
+		if v, ok := valueAttr(n); ok {
+			return v
+		}
+		if n.DataAtom == atom.Img || n.DataAtom == atom.Area {
+			if v, ok := attr(n, "alt"); ok {
+				return v
+			}
+		}
+		return text(n)
+	}
+}
+
+// implied adds the implied name, photo, and url properties to an item which doesn't have them.
+func (p *parser) implied(n *html.Node, item *Item) {
+	explicit := func(prefixes ...string) bool {
+		for d := range n.Descendants() {
+			if d == n {
+				continue
+			}
+			for _, prefix := range prefixes {
+				if len(prefixed(d, prefix)) > 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if _, ok := item.Properties["name"]; !ok && !explicit("p-", "e-") && len(item.Children) == 0 {
+		name := text(n)
+		if v, ok := attr(n, "alt"); ok && (n.DataAtom == atom.Img || n.DataAtom == atom.Area) {
+			name = v
+		} else if v, ok := attr(n, "title"); ok && n.DataAtom == atom.Abbr {
+			name = v
+		}
+		item.Properties["name"] = []any{name}
+	}
+
+	if _, ok := item.Properties["photo"]; !ok && len(item.Children) == 0 {
+		if img := implied(n, atom.Img, "src"); img != "" {
+			item.Properties["photo"] = []any{p.resolve(img)}
+		}
+	}
+
+	if _, ok := item.Properties["url"]; !ok && len(item.Children) == 0 {
+		if href := implied(n, atom.A, "href"); href != "" {
+			item.Properties["url"] = []any{p.resolve(href)}
+		}
+	}
+}
+
+// implied returns the attribute of n, or its only child, or its only grandchild, if it's an element of the given type
+// which isn't itself a microformat.
+func implied(n *html.Node, a atom.Atom, key string) string {
+	for range 3 {
+		if n.DataAtom == a {
+			v, _ := attr(n, key)
+			return v
+		}
+
+		var only *html.Node
+		for c := range n.ChildNodes() {
+			if c.Type == html.ElementNode {
+				if only != nil {
+					return ""
+				}
+				only = c
+			}
+		}
+
+		if only == nil || len(prefixed(only, "h-")) > 0 {
+			return ""
+		}
+		n = only
+	}
+	return ""
+}
+
+func (p *parser) resolve(ref string) string {
+	u, err := p.base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// prefixed returns the element's class names with the given prefix.
+func prefixed(n *html.Node, prefix string) []string {
+	if n.Type != html.ElementNode {
+		return nil
+	}
+
+	class, _ := attr(n, "class")
+	var names []string
+	for _, c := range strings.Fields(class) {
+		if len(c) > len(prefix) && strings.HasPrefix(c, prefix) && !slices.Contains(names, c) {
+			names = append(names, c)
+		}
+	}
+	return names
+}
+
+// urlAttr returns the URL attribute of the elements which have one.
+func urlAttr(n *html.Node) (string, bool) {
+	switch n.DataAtom {
+	case atom.A, atom.Area, atom.Link:
+		return attr(n, "href")
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Iframe:
+		return attr(n, "src")
+	case atom.Object:
+		return attr(n, "data")
+	default:
+		return "", false
+	}
+}
+
+// valueAttr returns the value of data and input elements, or the title of abbr elements.
+func valueAttr(n *html.Node) (string, bool) {
+	switch n.DataAtom {
+	case atom.Data, atom.Input:
+		return attr(n, "value")
+	case atom.Abbr:
+		return attr(n, "title")
+	default:
+		return "", false
+	}
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// text returns the text of an element and its descendants, with whitespace collapsed. Images are replaced by their alt
+// text.
+func text(n *html.Node) string {
+	var b strings.Builder
+	for d := range n.Descendants() {
+		switch {
+		case d.Type == html.TextNode && d.Parent.DataAtom != atom.Script && d.Parent.DataAtom != atom.Style:
+			b.WriteString(d.Data)
+		case d.DataAtom == atom.Img:
+			if alt, ok := attr(d, "alt"); ok {
+				b.WriteString(" " + alt + " ")
+			}
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,100 @@
+package microformats_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/codahale/yellhole-go/internal/microformats"
+	"golang.org/x/net/html"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<a class="h-card" href="/">Jane Doe</a>
+<main class="h-feed">
+	<article class="h-entry">
+		<h2 class="p-name">A Title</h2>
+		<div class="e-content"><p>Some <em>words</em>.</p></div>
+		<a class="u-url" href="/note/1"><time class="dt-published" datetime="2025-01-02T03:04:05Z">Jan 2</time></a>
+		<a class="u-in-reply-to h-cite" href="https://other.example/post"><span class="p-name">A post</span></a>
+		<span class="p-author h-card"><img class="u-photo" src="/me.png" alt=""><a class="p-name u-url" href="/">Jane Doe</a></span>
+	</article>
+</main>
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, _ := url.Parse("https://example.com/")
+	items := microformats.Parse(doc, base)
+
+	if got, want := len(items), 2; got != want {
+		t.Fatalf("len(items) = %d, want = %d", got, want)
+	}
+
+	card := items[0]
+	for name, want := range map[string]string{"name": "Jane Doe", "url": "https://example.com/"} {
+		if got := card.String(name); got != want {
+			t.Errorf("h-card %s = %q, want = %q", name, got, want)
+		}
+	}
+
+	entry := microformats.Find(items, "h-entry")
+	if entry == nil {
+		t.Fatal("no h-entry found")
+	}
+
+	for name, want := range map[string]string{
+		"name":        "A Title",
+		"content":     "Some words.",
+		"url":         "https://example.com/note/1",
+		"published":   "2025-01-02T03:04:05Z",
+		"in-reply-to": "https://other.example/post",
+		"author":      "Jane Doe",
+	} {
+		if got := entry.String(name); got != want {
+			t.Errorf("h-entry %s = %q, want = %q", name, got, want)
+		}
+	}
+
+	if got, want := entry.Properties["content"][0].(microformats.Embedded).HTML, "<p>Some <em>words</em>.</p>"; got != want {
+		t.Errorf("content HTML = %q, want = %q", got, want)
+	}
+
+	if got, want := entry.Item("in-reply-to").String("name"), "A post"; got != want {
+		t.Errorf("in-reply-to name = %q, want = %q", got, want)
+	}
+
+	author := entry.Item("author")
+	if author == nil || !author.HasType("h-card") {
+		t.Fatalf("author = %v, want = h-card", author)
+	}
+
+	if got, want := author.String("photo"), "https://example.com/me.png"; got != want {
+		t.Errorf("author photo = %q, want = %q", got, want)
+	}
+}
+
+func TestParseImpliedURLAndPhoto(t *testing.T) {
+	t.Parallel()
+
+	doc, err := html.Parse(strings.NewReader(`<a class="h-card" href="/"><img class="u-photo" src="/me.png" alt=""> <span class="p-name">Jane</span></a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, _ := url.Parse("https://example.com/")
+	card := microformats.Find(microformats.Parse(doc, base), "h-card")
+	if card == nil {
+		t.Fatal("no h-card found")
+	}
+
+	for name, want := range map[string]string{"name": "Jane", "url": "https://example.com/", "photo": "https://example.com/me.png"} {
+		if got := card.String(name); got != want {
+			t.Errorf("h-card %s = %q, want = %q", name, got, want)
+		}
+	}
+}
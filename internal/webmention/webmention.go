@@ -0,0 +1,319 @@
+package webmention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/codahale/yellhole-go/internal/microformats"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The types of Webmention, as determined by the microformats2 properties of the source's h-entry.
+const (
+	TypeMention = "mention"
+	TypeReply   = "reply"
+	TypeLike    = "like"
+	TypeRepost  = "repost"
+)
+
+var (
+	// ErrGone is returned when the source has been deleted.
+	ErrGone = errors.New("source is gone")
+
+	// ErrNoLink is returned when the source does not link to the target.
+	ErrNoLink = errors.New("source does not link to target")
+
+	// ErrNoEndpoint is returned when the target does not advertise a Webmention endpoint.
+	ErrNoEndpoint = errors.New("target has no webmention endpoint")
+
+	// ErrRejected is returned when a Webmention endpoint rejects a mention as invalid.
+	ErrRejected = errors.New("webmention rejected")
+)
+
+// maxSourceSize is the maximum number of bytes of a source document which will be parsed.
+const maxSourceSize = 1 << 20
+
+// maxContentLength is the maximum number of characters of content kept from a source's h-entry.
+const maxContentLength = 500
+
+// A Mention is the verified content of a Webmention.
+type Mention struct {
+	Type        string
+	AuthorName  string
+	AuthorURL   string
+	AuthorPhoto string
+	Content     string
+}
+
+// Verify fetches the source document and confirms that it links to the target. If the source has an h-entry, the type
+// of mention, its author, and its content are extracted from it.
+func Verify(ctx context.Context, client *http.Client, source, target string) (m *Mention, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %q: %w", source, err)
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %q: %w", source, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode == http.StatusGone {
+		return nil, ErrGone
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response fetching %q: %s", source, resp.Status)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxSourceSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", source, err)
+	}
+
+	return parse(doc, resp.Request.URL, target)
+}
+
+// Discover fetches the target and returns the URL of its Webmention endpoint, as advertised by either an HTTP Link
+// header or a link or a element in the document.
+func Discover(ctx context.Context, client *http.Client, target string) (endpoint string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %q: %w", target, err)
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %q: %w", target, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected response fetching %q: %s", target, resp.Status)
+	}
+
+	base := resp.Request.URL
+	for _, header := range resp.Header.Values("Link") {
+		if ref, ok := parseLinkHeader(header, "webmention"); ok {
+			return resolveEndpoint(base, ref)
+		}
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" {
+		return "", ErrNoEndpoint
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxSourceSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %q: %w", target, err)
+	}
+
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.Link && n.DataAtom != atom.A) {
+			continue
+		}
+
+		if !slices.Contains(strings.Fields(attr(n, "rel")), "webmention") {
+			continue
+		}
+
+		if ref, ok := attrOK(n, "href"); ok {
+			return resolveEndpoint(base, ref)
+		}
+	}
+
+	return "", ErrNoEndpoint
+}
+
+// Send notifies the Webmention endpoint that the source links to the target. If the endpoint responds with a client
+// error, the returned error wraps ErrRejected.
+func Send(ctx context.Context, client *http.Client, endpoint, source, target string) (err error) {
+	form := url.Values{"source": {source}, "target": {target}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %q: %w", endpoint, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webmention to %q: %w", endpoint, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w by %q: %s", ErrRejected, endpoint, resp.Status)
+	default:
+		return fmt.Errorf("unexpected response sending webmention to %q: %s", endpoint, resp.Status)
+	}
+}
+
+// parseLinkHeader returns the URL reference of the first link in an HTTP Link header with the given relation type.
+func parseLinkHeader(header, rel string) (string, bool) {
+	for link := range strings.SplitSeq(header, ",") {
+		ref, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.HasPrefix(ref, "<") || !strings.HasSuffix(ref, ">") {
+			continue
+		}
+
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+				continue
+			}
+
+			if slices.Contains(strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)), rel) {
+				return ref[1 : len(ref)-1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// resolveEndpoint resolves an endpoint reference against the target's URL. An empty reference refers to the target.
+func resolveEndpoint(base *url.URL, ref string) (string, error) {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", ErrNoEndpoint
+	}
+	return u.String(), nil
+}
+
+// parse extracts a mention of the target from an HTML document.
+func parse(doc *html.Node, base *url.URL, target string) (*Mention, error) {
+	linked := false
+	for n := range doc.Descendants() {
+		if link := linkURL(n, base); link != "" && link == target {
+			linked = true
+			break
+		}
+	}
+
+	if !linked {
+		return nil, ErrNoLink
+	}
+
+	m := &Mention{Type: TypeMention}
+
+	entry := microformats.Find(microformats.Parse(doc, base), "h-entry")
+	if entry == nil {
+		return m, nil
+	}
+
+	types := []struct{ name, typ string }{
+		{"in-reply-to", TypeReply},
+		{"repost-of", TypeRepost},
+		{"like-of", TypeLike},
+	}
+	for _, t := range types {
+		for _, v := range entry.Properties[t.name] {
+			if propertyURL(v) == target {
+				m.Type = t.typ
+			}
+		}
+	}
+
+	if author := entry.Item("author"); author != nil && author.HasType("h-card") {
+		m.AuthorName = author.String("name")
+		m.AuthorURL = httpURL(author.String("url"))
+		m.AuthorPhoto = httpURL(author.String("photo"))
+	} else {
+		m.AuthorName = entry.String("author")
+	}
+
+	m.Content = truncate(entry.String("content"), maxContentLength)
+
+	return m, nil
+}
+
+// linkURL returns the absolute URL an element links to or embeds, if any.
+func linkURL(n *html.Node, base *url.URL) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.A, atom.Area, atom.Link:
+		return resolve(base, attr(n, "href"))
+	case atom.Img, atom.Audio, atom.Video, atom.Source:
+		return resolve(base, attr(n, "src"))
+	default:
+		return ""
+	}
+}
+
+// propertyURL returns the URL value of a u-* property. Nested microformats, like an h-cite, use their url property.
+func propertyURL(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case *microformats.Item:
+		return v.Value
+	default:
+		return ""
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// resolve returns the given reference as an absolute http or https URL, or an empty string if it isn't one.
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+// httpURL returns s if it's an absolute http or https URL, or an empty string if it isn't one.
+func httpURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return s
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
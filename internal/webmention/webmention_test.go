@@ -0,0 +1,169 @@
+package webmention_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codahale/yellhole-go/internal/webmention"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /reply", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<!DOCTYPE html>
+<html><body>
+<article class="h-entry">
+	<a class="p-author h-card" href="/">
+		<img class="u-photo" src="/me.jpg" alt=""> <span class="p-name">Jane  Doe</span>
+	</a>
+	<a class="u-in-reply-to" href="https://example.com/note/123">In reply to</a>
+	<div class="e-content"><p>I <em>strongly</em> agree.</p></div>
+</article>
+</body></html>`))
+	})
+	mux.HandleFunc("GET /like", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<div class="h-entry">
+	<div class="u-like-of h-cite"><a class="u-url" href="https://example.com/note/123">A note</a></div>
+	<a class="p-author h-card" href="https://jane.example/">Jane</a>
+</div>`))
+	})
+	mux.HandleFunc("GET /mention", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<p>Check out <a href="https://example.com/note/123">this</a>.</p>`))
+	})
+	mux.HandleFunc("GET /nolink", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<p>Check out <a href="https://example.com/note/456">this</a>.</p>`))
+	})
+	mux.HandleFunc("GET /gone", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	for name, tc := range map[string]struct {
+		path    string
+		want    *webmention.Mention
+		wantErr error
+	}{
+		"reply": {"/reply", &webmention.Mention{
+			Type:        webmention.TypeReply,
+			AuthorName:  "Jane Doe",
+			AuthorURL:   server.URL + "/",
+			AuthorPhoto: server.URL + "/me.jpg",
+			Content:     "I strongly agree.",
+		}, nil},
+		"like": {"/like", &webmention.Mention{
+			Type:       webmention.TypeLike,
+			AuthorName: "Jane",
+			AuthorURL:  "https://jane.example/",
+		}, nil},
+		"mention": {"/mention", &webmention.Mention{Type: webmention.TypeMention}, nil},
+		"no link": {"/nolink", nil, webmention.ErrNoLink},
+		"gone":    {"/gone", nil, webmention.ErrGone},
+	} {
+		got, err := webmention.Verify(t.Context(), server.Client(), server.URL+tc.path, "https://example.com/note/123")
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: Verify() err = %v, want = %v", name, err, tc.wantErr)
+			continue
+		}
+
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%s: Verify() mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /header", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Link", `<https://example.org/hub>; rel="hub", </endpoint?a=b>; rel="webmention other"`)
+		_, _ = w.Write([]byte(`<link rel="webmention" href="/wrong">`))
+	})
+	mux.HandleFunc("GET /link", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><link rel="stylesheet" href="/a.css"><link rel="webmention" href="endpoint"></head></html>`))
+	})
+	mux.HandleFunc("GET /anchor", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<p><a rel="me webmention" href="https://example.net/wm">Webmention</a></p>`))
+	})
+	mux.HandleFunc("GET /self", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<link rel="webmention" href="">`))
+	})
+	mux.HandleFunc("GET /none", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<p>Nothing to see here.</p>`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	for name, tc := range map[string]struct {
+		path, want string
+		wantErr    error
+	}{
+		"header": {"/header", server.URL + "/endpoint?a=b", nil},
+		"link":   {"/link", server.URL + "/endpoint", nil},
+		"anchor": {"/anchor", "https://example.net/wm", nil},
+		"self":   {"/self", server.URL + "/self", nil},
+		"none":   {"/none", "", webmention.ErrNoEndpoint},
+	} {
+		got, err := webmention.Discover(t.Context(), server.Client(), server.URL+tc.path)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: Discover() err = %v, want = %v", name, err, tc.wantErr)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("%s: Discover() = %q, want = %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	var source, target string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /accept", func(w http.ResponseWriter, r *http.Request) {
+		source, target = r.PostFormValue("source"), r.PostFormValue("target")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("POST /reject", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	mux.HandleFunc("POST /error", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	if err := webmention.Send(t.Context(), server.Client(), server.URL+"/accept", "https://example.com/note/123", "https://example.org/post"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := source, "https://example.com/note/123"; got != want {
+		t.Errorf("source = %q, want = %q", got, want)
+	}
+
+	if got, want := target, "https://example.org/post"; got != want {
+		t.Errorf("target = %q, want = %q", got, want)
+	}
+
+	if err := webmention.Send(t.Context(), server.Client(), server.URL+"/reject", "a", "b"); !errors.Is(err, webmention.ErrRejected) {
+		t.Errorf("Send() err = %v, want = %v", err, webmention.ErrRejected)
+	}
+
+	if err := webmention.Send(t.Context(), server.Client(), server.URL+"/error", "a", "b"); err == nil || errors.Is(err, webmention.ErrRejected) {
+		t.Errorf("Send() err = %v, want = a retryable error", err)
+	}
+}
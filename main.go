@@ -27,8 +27,16 @@ func run(args []string, lookupEnv func(string) (string, bool)) error {
 	// Generate the build tag.
 	buildTag := build.Tag()
 
-	// Parse the configuration flags and environment variables.
-	addr, baseURL, dataDir, author, title, description, lang, err := loadConfig(args, lookupEnv)
+	// Check the configuration, if requested.
+	if len(args) >= 2 && args[0] == "config" && args[1] == "check" {
+		if err := checkConfig(os.Stdout, args[2:], lookupEnv); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		return nil
+	}
+
+	// Load the configuration from flags, environment variables, and the configuration file.
+	cfg, err := loadConfig(args, lookupEnv)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
@@ -38,8 +46,8 @@ func run(args []string, lookupEnv func(string) (string, bool)) error {
 	defer signalStop()
 
 	// Connect to the database.
-	logger.Info("starting", "dataDir", dataDir, "buildTag", buildTag)
-	conn, queries, err := db.NewWithMigrations(signalCtx, logger, filepath.Join(dataDir, "yellhole.db"))
+	logger.Info("starting", "dataDir", cfg.DataDir, "buildTag", buildTag)
+	conn, queries, err := db.NewWithMigrations(signalCtx, logger, filepath.Join(cfg.DataDir, "yellhole.db"))
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -53,7 +61,7 @@ func run(args []string, lookupEnv func(string) (string, bool)) error {
 	}()
 
 	// Create an image store.
-	images, err := imgstore.New(dataDir)
+	images, err := imgstore.New(cfg.DataDir)
 	if err != nil {
 		return fmt.Errorf("failed to create image store: %w", err)
 	}
@@ -63,8 +71,20 @@ func run(args []string, lookupEnv func(string) (string, bool)) error {
 		}
 	}()
 
+	// Load the key for hashing session tokens.
+	tokenKey, err := loadTokenKey(filepath.Join(cfg.DataDir, "token.key"))
+	if err != nil {
+		return fmt.Errorf("failed to load token key: %w", err)
+	}
+
+	// Load the key for signing ActivityPub requests.
+	actorKey, err := loadActorKey(filepath.Join(cfg.DataDir, "actor.key"))
+	if err != nil {
+		return fmt.Errorf("failed to load actor key: %w", err)
+	}
+
 	// Create a new app.
-	app, err := newApp(signalCtx, logger, queries, images, baseURL, author, title, description, lang, buildTag, true)
+	app, err := newApp(signalCtx, logger, cfg, queries, images, newPublicClient(30*time.Second), tokenKey, actorKey, buildTag, true)
 	if err != nil {
 		return fmt.Errorf("failed to create application: %w", err)
 	}
@@ -72,7 +92,7 @@ func run(args []string, lookupEnv func(string) (string, bool)) error {
 	// Configure an HTTP server with good defaults.
 	baseCtx, baseCtxStop := context.WithCancel(signalCtx)
 	server := &http.Server{
-		Addr:    addr,
+		Addr:    cfg.Addr,
 		Handler: http.TimeoutHandler(app, 60*time.Second, "request timeout"),
 
 		BaseContext: func(_ net.Listener) context.Context {
@@ -85,7 +105,7 @@ func run(args []string, lookupEnv func(string) (string, bool)) error {
 	}
 
 	// Listen for connections in a separate goroutine.
-	logger.Info("listening for connections", "baseURL", baseURL)
+	logger.Info("listening for connections", "baseURL", cfg.BaseURL)
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			slog.Error("error listening for requests", "err", err)
@@ -0,0 +1,557 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/codahale/yellhole-go/internal/markdown"
+	"github.com/google/uuid"
+)
+
+// micropubPrefix is the path prefix of the Micropub endpoints.
+const micropubPrefix = "/micropub"
+
+// handleMicropubQuery responds to Micropub configuration and source queries.
+func handleMicropubQuery(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		switch q := r.FormValue("q"); q {
+		case "config":
+			return jsonResponse(w, map[string]any{
+				"media-endpoint": baseURL.JoinPath("micropub", "media").String(),
+				"syndicate-to":   []any{},
+				"post-types":     []map[string]string{{"type": "note", "name": "Note"}},
+				"q":              []string{"config", "source", "syndicate-to"},
+			})
+		case "syndicate-to":
+			return jsonResponse(w, map[string]any{"syndicate-to": []any{}})
+		case "source":
+			note, err := micropubNote(r, queries, baseURL, r.FormValue("url"))
+			if err != nil {
+				return err
+			}
+
+			tags, err := markdown.Tags(note.Body)
+			if err != nil {
+				return fmt.Errorf("failed to parse tags for note %s: %w", note.NoteID, err)
+			}
+
+			properties := map[string][]any{
+				"content":   {note.Body},
+				"published": {note.CreatedAt.Format(time.RFC3339)},
+				"category":  anySlice(tags),
+			}
+			if note.Title != "" {
+				properties["name"] = []any{note.Title}
+			}
+			if note.Draft {
+				properties["post-status"] = []any{"draft"}
+			}
+
+			// Only return the requested properties, if any were given.
+			if names := r.Form["properties[]"]; len(names) > 0 {
+				for name := range properties {
+					if !slices.Contains(names, name) {
+						delete(properties, name)
+					}
+				}
+				return jsonResponse(w, map[string]any{"properties": properties})
+			}
+			return jsonResponse(w, map[string]any{"type": []string{"h-entry"}, "properties": properties})
+		default:
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("Unsupported query %q.", q)}
+		}
+	}
+}
+
+// handleMicropub creates, updates, deletes, and undeletes notes from Micropub requests, either form-encoded or JSON.
+func handleMicropub(queries *db.Queries, images *imgstore.Store, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		req, err := parseMicropubRequest(r)
+		if err != nil {
+			return err
+		}
+
+		if scope, ok := micropubActionScopes[req.Action]; ok {
+			if err := requireMicropubScope(r, scope); err != nil {
+				return err
+			}
+		}
+
+		// Photos can only be uploaded with new posts, and require the media scope.
+		if len(req.photos) > 0 {
+			if req.Action != "" {
+				return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Photos can only be uploaded with new posts."}
+			}
+
+			if err := requireMicropubScope(r, "media"); err != nil {
+				return err
+			}
+		}
+
+		switch req.Action {
+		case "":
+			return micropubCreate(w, r, queries, images, baseURL, req)
+		case "update":
+			return micropubUpdate(w, r, queries, baseURL, req)
+		case "delete":
+			note, err := micropubNote(r, queries, baseURL, req.URL)
+			if err != nil {
+				return err
+			}
+
+			if err := queries.DeleteNote(r.Context(), sql.NullTime{Time: time.Now(), Valid: true}, note.NoteID); err != nil {
+				return fmt.Errorf("failed to delete note %s via micropub: %w", note.NoteID, err)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		case "undelete":
+			id, err := micropubNoteID(baseURL, req.URL)
+			if err != nil {
+				return err
+			}
+
+			exists, err := queries.NoteExists(r.Context(), id)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve note %s for micropub: %w", id, err)
+			}
+
+			if !exists {
+				return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Note not found."}
+			}
+
+			if err := queries.RestoreNote(r.Context(), id); err != nil {
+				return fmt.Errorf("failed to restore note %s via micropub: %w", id, err)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		default:
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("Unsupported action %q.", req.Action)}
+		}
+	}
+}
+
+// handleMicropubMedia adds an image uploaded to the Micropub media endpoint and responds with its URL.
+func handleMicropubMedia(queries *db.Queries, images *imgstore.Store, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) (err error) {
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "A file is required."}
+		}
+		defer func() {
+			err = errors.Join(err, f.Close())
+		}()
+
+		imageURL, err := micropubAddImage(r, queries, images, baseURL, f, h.Filename)
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Location", imageURL)
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	}
+}
+
+// handleOAuthErrors converts the errors returned by Micropub and IndieAuth handlers into OAuth 2.0 error responses.
+func handleOAuthErrors(handler appHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := handler(w, r); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				slog.ErrorContext(r.Context(), "error handling oauth request", "err", err)
+				apiErr = &apiError{Status: http.StatusInternalServerError, Code: "internal_error", Message: "Internal server error."}
+			}
+			writeOAuthError(w, apiErr)
+		}
+	})
+}
+
+// writeOAuthError writes the given error in the format defined by OAuth 2.0 and used by Micropub.
+func writeOAuthError(w http.ResponseWriter, err *apiError) {
+	_ = apiResponse(w, err.Status, map[string]string{"error": err.Code, "error_description": err.Message})
+}
+
+// micropubActionScopes maps Micropub actions to the IndieAuth scopes they require.
+//
+//nolint:gochecknoglobals // lookup table
+var micropubActionScopes = map[string]string{
+	"":         "create",
+	"update":   "update",
+	"delete":   "delete",
+	"undelete": "delete",
+}
+
+// requireMicropubScope checks that a request authenticated with a token issued via IndieAuth was granted the given
+// scope. Requests authenticated with sessions or with tokens with the notes:write scope can do anything.
+func requireMicropubScope(r *http.Request, scope string) error {
+	scopes, ok := apiTokenScopesFrom(r)
+	if !ok || slices.Contains(scopes, scopeNotesWrite) {
+		return nil
+	}
+
+	if !slices.Contains(scopes, scope) {
+		return &apiError{Status: http.StatusForbidden, Code: "insufficient_scope", Message: fmt.Sprintf("The %q scope is required.", scope)}
+	}
+	return nil
+}
+
+// isMicropubRequest returns true if the request is for a Micropub endpoint.
+func isMicropubRequest(r *http.Request) bool {
+	return r.URL.Path == micropubPrefix || strings.HasPrefix(r.URL.Path, micropubPrefix+"/")
+}
+
+func micropubCreate(w http.ResponseWriter, r *http.Request, queries *db.Queries, images *imgstore.Store, baseURL *url.URL, req *micropubRequest) error {
+	if len(req.Type) > 0 && req.Type[0] != "h-entry" {
+		return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Only h-entry posts are supported."}
+	}
+
+	title, err := req.Properties.string("name")
+	if err != nil {
+		return err
+	}
+
+	body, err := req.Properties.string("content")
+	if err != nil {
+		return err
+	}
+
+	content, err := appendMicropubProperties(body, req.Properties)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(content) == "" && len(req.photos) == 0 {
+		return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "A note requires content or a photo."}
+	}
+
+	createdAt := time.Now()
+	if published, err := req.Properties.string("published"); err != nil {
+		return err
+	} else if published != "" {
+		createdAt, err = time.Parse(time.RFC3339, published)
+		if err != nil {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Invalid published date."}
+		}
+	}
+
+	status, err := req.Properties.string("post-status")
+	if err != nil {
+		return err
+	}
+
+	// Store uploaded photos only once the request is known to be valid.
+	if len(req.photos) > 0 {
+		for _, h := range req.photos {
+			imageURL, err := addMicropubPhoto(r, queries, images, baseURL, h)
+			if err != nil {
+				return err
+			}
+			req.Properties["photo"] = append(req.Properties["photo"], imageURL)
+		}
+
+		content, err = appendMicropubProperties(body, req.Properties)
+		if err != nil {
+			return err
+		}
+	}
+	body = content
+
+	id := uuid.NewString()
+	if status == "draft" {
+		if err := queries.CreateDraft(r.Context(), id, strings.TrimSpace(title), body, createdAt); err != nil {
+			return fmt.Errorf("failed to create new draft via micropub: %w", err)
+		}
+	} else {
+		if err := queries.CreateNote(r.Context(), id, strings.TrimSpace(title), body, createdAt); err != nil {
+			return fmt.Errorf("failed to create new note via micropub: %w", err)
+		}
+	}
+
+	if err := setNoteTags(r.Context(), queries, id, body); err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", baseURL.JoinPath("note", id).String())
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+func micropubUpdate(w http.ResponseWriter, r *http.Request, queries *db.Queries, baseURL *url.URL, req *micropubRequest) error {
+	note, err := micropubNote(r, queries, baseURL, req.URL)
+	if err != nil {
+		return err
+	}
+
+	title, body := note.Title, note.Body
+	for name := range req.Replace {
+		switch name {
+		case "name":
+			title, err = req.Replace.string(name)
+		case "content":
+			body, err = req.Replace.string(name)
+		default:
+			err = &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("Replacing %q isn't supported.", name)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	body, err = appendMicropubProperties(body, req.Add)
+	if err != nil {
+		return err
+	}
+
+	for name := range req.Add {
+		if name != "category" && name != "photo" {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("Adding %q isn't supported.", name)}
+		}
+	}
+
+	for _, name := range req.Delete {
+		if name != "name" {
+			return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("Deleting %q isn't supported.", name)}
+		}
+		title = ""
+	}
+
+	// Update the note. The previous body is preserved as a revision by the database.
+	if err := queries.UpdateNote(r.Context(), strings.TrimSpace(title), body, sql.NullTime{Time: time.Now(), Valid: true}, note.NoteID); err != nil {
+		return fmt.Errorf("failed to update note %s via micropub: %w", note.NoteID, err)
+	}
+
+	if err := setNoteTags(r.Context(), queries, note.NoteID, body); err != nil {
+		return err
+	}
+
+	if err := queueEditedNoteWebmentions(r.Context(), queries, &note, body); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// appendMicropubProperties appends photos and categories to a note's body as Markdown images and hashtags.
+func appendMicropubProperties(body string, properties micropubProperties) (string, error) {
+	for _, photo := range properties["photo"] {
+		switch v := photo.(type) {
+		case string:
+			body += "\n\n" + markdownImage("", v)
+		case map[string]any:
+			src, _ := v["value"].(string)
+			alt, _ := v["alt"].(string)
+			body += "\n\n" + markdownImage(alt, src)
+		default:
+			return "", &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Invalid photo."}
+		}
+	}
+
+	tags, err := markdown.Tags(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse tags for micropub note: %w", err)
+	}
+
+	var hashtags []string
+	for _, category := range properties["category"] {
+		s, ok := category.(string)
+		if !ok || strings.ContainsFunc(s, func(r rune) bool { return r == ' ' || r == '#' }) {
+			return "", &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Categories must be single words."}
+		}
+
+		if tag := markdown.NormalizeTag(s); !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+			hashtags = append(hashtags, "#"+s)
+		}
+	}
+
+	if len(hashtags) > 0 {
+		body += "\n\n" + strings.Join(hashtags, " ")
+	}
+	return body, nil
+}
+
+// markdownImage returns a Markdown image with the given alt text and URL, escaped so that neither can break out of it.
+func markdownImage(alt, src string) string {
+	return "![" + markdownAltEscaper.Replace(alt) + "](" + markdownURLEscaper.Replace(src) + ")"
+}
+
+var (
+	markdownAltEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "\r", " ", "\n", " ")
+	markdownURLEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E", `\`, "%5C", "\r", "%0D", "\n", "%0A")
+)
+
+// micropubAddImage adds the given image to the store and database and returns its URL.
+func micropubAddImage(r *http.Request, queries *db.Queries, images *imgstore.Store, baseURL *url.URL, f multipart.File, originalFilename string) (string, error) {
+	id := uuid.New()
+
+	filename, format, err := images.Add(r.Context(), id, f)
+	if err != nil {
+		return "", fmt.Errorf("failed to add micropub image to store: %w", err)
+	}
+
+	if err := queries.CreateImage(r.Context(), id.String(), filename, originalFilename, format, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to create image record in database via micropub: %w", err)
+	}
+
+	return baseURL.JoinPath("images", "feed", filename).String(), nil
+}
+
+// micropubNote returns the editable note with the given URL.
+func micropubNote(r *http.Request, queries *db.Queries, baseURL *url.URL, noteURL string) (db.Note, error) {
+	id, err := micropubNoteID(baseURL, noteURL)
+	if err != nil {
+		return db.Note{}, err
+	}
+
+	note, err := queries.EditableNoteByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return note, &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Note not found."}
+	} else if err != nil {
+		return note, fmt.Errorf("failed to retrieve note for micropub: %w", err)
+	}
+	return note, nil
+}
+
+// micropubNoteID returns the ID of the note with the given URL.
+func micropubNoteID(baseURL *url.URL, noteURL string) (string, error) {
+	id, ok := strings.CutPrefix(noteURL, baseURL.JoinPath("note").String()+"/")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: "Invalid note URL."}
+	}
+	return id, nil
+}
+
+// micropubRequest is a normalized Micropub request, parsed from either a form or JSON.
+type micropubRequest struct {
+	Action     string             `json:"action"`
+	URL        string             `json:"url"`
+	Type       []string           `json:"type"`
+	Properties micropubProperties `json:"properties"`
+	Replace    micropubProperties `json:"replace"`
+	Add        micropubProperties `json:"add"`
+	Delete     []string           `json:"-"`
+
+	// photos are the photos uploaded with a multipart request, which are stored when a post is created.
+	photos []*multipart.FileHeader
+}
+
+// micropubProperties maps the names of microformats2 properties to their values.
+type micropubProperties map[string][]any
+
+// string returns the first value of the given property as a string, or an empty string if it has no values. Content
+// may be given as an object with a value, but not HTML, which wouldn't survive rendering as Markdown.
+func (p micropubProperties) string(name string) (string, error) {
+	if len(p[name]) == 0 {
+		return "", nil
+	}
+
+	switch v := p[name][0].(type) {
+	case string:
+		return v, nil
+	case map[string]any:
+		if s, ok := v["value"].(string); ok {
+			return s, nil
+		}
+	}
+	return "", &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf("Unsupported value for %q.", name)}
+}
+
+// parseMicropubRequest parses a Micropub request from a JSON, form-encoded, or multipart body. Files uploaded as photos
+// in a multipart request are returned as-is, to be stored only if the request is allowed and valid.
+func parseMicropubRequest(r *http.Request) (*micropubRequest, error) {
+	invalid := func(msg string) error {
+		return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: msg}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var req struct {
+			micropubRequest
+			Delete json.RawMessage `json:"delete"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, invalid("Invalid JSON request: " + err.Error())
+		}
+
+		// Deletes are either a list of property names or a map of property names to values. Only the former is supported.
+		if len(req.Delete) > 0 {
+			if err := json.Unmarshal(req.Delete, &req.micropubRequest.Delete); err != nil {
+				return nil, invalid("Only deleting entire properties is supported.")
+			}
+		}
+		return &req.micropubRequest, nil
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return nil, invalid("Invalid form request.")
+	}
+
+	req := &micropubRequest{
+		Action:     r.PostFormValue("action"),
+		URL:        r.PostFormValue("url"),
+		Properties: make(micropubProperties),
+	}
+
+	if h := r.PostFormValue("h"); h != "" {
+		req.Type = []string{"h-" + h}
+	}
+
+	for key, values := range r.PostForm {
+		if key == "h" || key == "action" || key == "url" || key == "access_token" {
+			continue
+		}
+
+		name := strings.TrimSuffix(key, "[]")
+		for _, v := range values {
+			req.Properties[name] = append(req.Properties[name], v)
+		}
+	}
+
+	if r.MultipartForm != nil {
+		for key, files := range r.MultipartForm.File {
+			if name := strings.TrimSuffix(key, "[]"); name != "photo" {
+				return nil, invalid(fmt.Sprintf("Unsupported file property %q.", name))
+			}
+
+			req.photos = append(req.photos, files...)
+		}
+	}
+
+	return req, nil
+}
+
+func addMicropubPhoto(r *http.Request, queries *db.Queries, images *imgstore.Store, baseURL *url.URL, h *multipart.FileHeader) (_ string, err error) {
+	f, err := h.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open micropub photo: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	return micropubAddImage(r, queries, images, baseURL, f, h.Filename)
+}
+
+// anySlice converts a slice of strings to a slice of empty interfaces.
+func anySlice(s []string) []any {
+	a := make([]any, len(s))
+	for i, v := range s {
+		a[i] = v
+	}
+	return a
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/markdown"
+)
+
+func TestMicropubCreateForm(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	token := createTestAPIToken(t, app, scopeNotesWrite)
+
+	form := url.Values{
+		"h":            {"entry"},
+		"content":      {"Posting from my phone."},
+		"category[]":   {"indieweb", "phones"},
+		"access_token": {token},
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/micropub", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 1; got != want {
+		t.Fatalf("len(notes) = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/note/"+notes[0].NoteID; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %q, want = %q`, got, want)
+	}
+
+	if got, want := notes[0].Body, "Posting from my phone.\n\n#indieweb #phones"; got != want {
+		t.Errorf("notes[0].Body = %q, want = %q", got, want)
+	}
+
+	tagged, err := app.queries.NotesByTag(t.Context(), "phones", time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(tagged), 1; got != want {
+		t.Errorf("len(tagged) = %d, want = %d", got, want)
+	}
+}
+
+func TestMicropubJSON(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	token := createTestAPIToken(t, app, scopeNotesRead+" "+scopeNotesWrite)
+
+	do := func(method, target, body string) *http.Response {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp := do(http.MethodPost, "http://example.com/micropub", `{
+		"type": ["h-entry"],
+		"properties": {
+			"name": ["Hello"],
+			"content": ["It's a #test."],
+			"photo": [{"value": "https://example.com/cat.jpg", "alt": "a cat"}]
+		}
+	}`)
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+	noteURL := resp.Header.Get("Location")
+
+	resp = do(http.MethodPost, "http://example.com/micropub", `{
+		"action": "update",
+		"url": "`+noteURL+`",
+		"replace": {"content": ["It's an updated #test."]},
+		"add": {"category": ["micropub"]}
+	}`)
+	if got, want := resp.StatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	resp = do(http.MethodGet, "http://example.com/micropub?q=source&url="+url.QueryEscape(noteURL), "")
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var source struct {
+		Properties map[string][]string `json:"properties"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&source); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := source.Properties["name"], []string{"Hello"}; !slices.Equal(got, want) {
+		t.Errorf("name = %v, want = %v", got, want)
+	}
+
+	if got, want := source.Properties["content"], []string{"It's an updated #test.\n\n#micropub"}; !slices.Equal(got, want) {
+		t.Errorf("content = %v, want = %v", got, want)
+	}
+
+	if got, want := source.Properties["category"], []string{"test", "micropub"}; !slices.Equal(got, want) {
+		t.Errorf("category = %v, want = %v", got, want)
+	}
+
+	resp = do(http.MethodPost, "http://example.com/micropub", `{"action": "delete", "url": "`+noteURL+`"}`)
+	if got, want := resp.StatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	resp = do(http.MethodGet, "http://example.com/micropub?q=source&url="+url.QueryEscape(noteURL), "")
+	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	resp = do(http.MethodPost, "http://example.com/micropub", `{"action": "undelete", "url": "`+noteURL+`"}`)
+	if got, want := resp.StatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// Unknown notes can't be deleted or undeleted.
+	for _, action := range []string{"delete", "undelete"} {
+		resp = do(http.MethodPost, "http://example.com/micropub", `{"action": "`+action+`", "url": "http://example.com/note/unknown"}`)
+		if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", action, got, want)
+		}
+	}
+}
+
+func TestMicropubConfig(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	token := createTestAPIToken(t, app, scopeNotesRead)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/micropub?q=config", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var config struct {
+		MediaEndpoint string `json:"media-endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := config.MediaEndpoint, "http://example.com/micropub/media"; got != want {
+		t.Errorf("config.MediaEndpoint = %q, want = %q", got, want)
+	}
+}
+
+func TestMicropubUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/micropub?q=config", nil)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusUnauthorized; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := body.Error, "unauthorized"; got != want {
+		t.Errorf("body.Error = %q, want = %q", got, want)
+	}
+}
+
+func TestMicropubFormTokenLimits(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	token := createTestAPIToken(t, app, scopeNotesWrite)
+
+	// Only form-encoded bodies of a limited size are searched for an access token.
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	_ = mw.WriteField("h", "entry")
+	_ = mw.WriteField("content", "Not from a form.")
+	_ = mw.WriteField("access_token", token)
+	_ = mw.Close()
+
+	oversized := url.Values{
+		"h":            {"entry"},
+		"content":      {strings.Repeat("a", maxTokenFormSize)},
+		"access_token": {token},
+	}
+
+	for _, tc := range []struct {
+		name, contentType, body string
+	}{
+		{"multipart", mw.FormDataContentType(), b.String()},
+		{"oversized", "application/x-www-form-urlencoded", oversized.Encode()},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/micropub", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		if got, want := w.Result().StatusCode, http.StatusUnauthorized; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", tc.name, got, want)
+		}
+	}
+
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 0; got != want {
+		t.Errorf("len(notes) = %d, want = %d", got, want)
+	}
+}
+
+func TestMicropubMedia(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	token := createTestAPIToken(t, app, scopeImagesWrite)
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	file, _ := mw.CreateFormFile("file", "square.png")
+	if err := png.Encode(file, image.NewNRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/micropub/media", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	images, err := app.queries.RecentImages(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(images), 1; got != want {
+		t.Fatalf("len(images) = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/images/feed/"+images[0].Filename; got != want {
+		t.Errorf(`resp.Header.Get("Location") = %q, want = %q`, got, want)
+	}
+}
+
+func TestMicropubCreatePhoto(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	post := func(scopes, h string) *http.Response {
+		var b bytes.Buffer
+		mw := multipart.NewWriter(&b)
+		_ = mw.WriteField("h", h)
+		_ = mw.WriteField("content", "A square.")
+		file, _ := mw.CreateFormFile("photo", "square.png")
+		if err := png.Encode(file, image.NewNRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+			t.Fatal(err)
+		}
+		_ = mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/micropub", &b)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+createTestAPIToken(t, app, scopes))
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	// Requests which aren't allowed or aren't valid don't store their photos.
+	for _, tc := range []struct {
+		scopes, h string
+		want      int
+	}{
+		{"update", "entry", http.StatusForbidden},
+		{"create", "entry", http.StatusForbidden},
+		{"create media", "event", http.StatusBadRequest},
+	} {
+		if got, want := post(tc.scopes, tc.h).StatusCode, tc.want; got != want {
+			t.Errorf("%q/%q: resp.StatusCode = %d, want = %d", tc.scopes, tc.h, got, want)
+		}
+	}
+
+	images, err := app.queries.RecentImages(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(images), 0; got != want {
+		t.Fatalf("len(images) = %d, want = %d", got, want)
+	}
+
+	if got, want := post("create media", "entry").StatusCode, http.StatusCreated; got != want {
+		t.Fatalf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	images, err = app.queries.RecentImages(t.Context(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(images), 1; got != want {
+		t.Fatalf("len(images) = %d, want = %d", got, want)
+	}
+
+	notes, err := app.queries.RecentNotes(t.Context(), time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := notes[0].Body, "A square.\n\n![](http://example.com/images/feed/"+images[0].Filename+")"; got != want {
+		t.Errorf("notes[0].Body = %q, want = %q", got, want)
+	}
+}
+
+func TestMarkdownImage(t *testing.T) {
+	t.Parallel()
+
+	body := markdownImage(`a [cat](https://evil.example)\`, "https://example.com/a cat).jpg")
+
+	images, err := markdown.Images(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(images), 1; got != want {
+		t.Fatalf("len(images) = %d, want = %d", got, want)
+	}
+
+	if got, want := images[0].String(), "https://example.com/a%20cat%29.jpg"; got != want {
+		t.Errorf("images[0] = %q, want = %q", got, want)
+	}
+
+	links, err := markdown.Links(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(links), 0; got != want {
+		t.Errorf("len(links) = %d, want = %d", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/google/uuid"
+)
+
+// handlePasskeysPage renders the admin page for managing passkeys.
+func handlePasskeysPage(queries *db.Queries, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		credentials, err := queries.WebauthnCredentials(r.Context())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve webauthn credentials for passkeys page: %w", err)
+		}
+
+		passkeys := make([]passkey, len(credentials))
+		for i, cred := range credentials {
+			passkeys[i] = passkey{
+				ID:            cred.WebauthnCredentialID,
+				Nickname:      cred.Nickname,
+				Authenticator: authenticatorName(cred.CredentialData.Data.Authenticator.AAGUID),
+				CreatedAt:     cred.CreatedAt,
+				LastUsedAt:    cred.LastUsedAt,
+			}
+		}
+
+		return htmlResponse(w, t, "passkeys.gohtml", passkeys)
+	}
+}
+
+// handleRevokePasskey deletes a passkey, unless it's the last one.
+func handleRevokePasskey(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		// The credential is only deleted if it isn't the last one, atomically.
+		id := r.PathValue("id")
+		if _, err := queries.DeleteWebauthnCredential(r.Context(), id); errors.Is(err, sql.ErrNoRows) {
+			exists, err := queries.WebauthnCredentialExists(r.Context(), id)
+			if err != nil {
+				return fmt.Errorf("failed to check for webauthn credential: %w", err)
+			}
+
+			if !exists {
+				http.NotFound(w, r)
+				return nil
+			}
+
+			http.Error(w, "You can't revoke your last passkey.", http.StatusBadRequest)
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to delete webauthn credential: %w", err)
+		}
+
+		http.Redirect(w, r, baseURL.JoinPath("admin", "passkeys").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+type passkey struct {
+	ID            string
+	Nickname      string
+	Authenticator string
+	CreatedAt     time.Time
+	LastUsedAt    sql.NullTime
+}
+
+// authenticatorName returns the name of the authenticator with the given AAGUID, if known.
+func authenticatorName(aaguid []byte) string {
+	id, err := uuid.FromBytes(aaguid)
+	if err != nil || id == uuid.Nil {
+		return "Unknown authenticator"
+	}
+
+	if name, ok := authenticatorNames[id.String()]; ok {
+		return name
+	}
+	return "Unknown authenticator (" + id.String() + ")"
+}
+
+// authenticatorNames maps the AAGUIDs of common passkey providers to their names.
+//
+//nolint:gochecknoglobals // lookup table
+var authenticatorNames = map[string]string{
+	"08987058-cadc-4b81-b6e1-30de50dcbe96": "Windows Hello",
+	"0ea242b4-43c4-4a1b-8b17-dd6d0b6baec6": "KeePassXC",
+	"50726f74-6f6e-5061-7373-50726f746f6e": "Proton Pass",
+	"531126d6-e717-415c-9320-3d9aa6981239": "Dashlane",
+	"53414d53-554e-4700-0000-000000000000": "Samsung Pass",
+	"6028b017-b1d4-4c02-b4b3-afcdafc96bb2": "Windows Hello",
+	"771b48fd-d3d4-4f74-9232-fc157ab0507a": "Edge on Mac",
+	"9ddd1817-af5a-4672-a2b9-3e3dd95000a9": "Windows Hello",
+	"adce0002-35bc-c60a-648b-0b25f1f05503": "Chrome on Mac",
+	"bada5566-a7aa-401f-bd96-45619a55120d": "1Password",
+	"cb69481e-8ff7-4039-93ec-0a2729a154a8": "YubiKey 5 Series",
+	"d548826e-79b4-db40-a3d8-11116f7e8349": "Bitwarden",
+	"dd4ec289-e01d-41c9-bb89-70fa845d4bf2": "iCloud Keychain (Managed)",
+	"ea9b8d66-4d01-1d21-3ce4-b6b48cb575d4": "Google Password Manager",
+	"ee882879-721c-4913-9775-3dfcce97072a": "YubiKey 5 Series",
+	"fa2b99dc-9e39-4257-8f92-4a30d23c4118": "YubiKey 5 Series with NFC",
+	"fbfc3007-154e-4ecc-8c0b-6e020557d7bd": "iCloud Keychain",
+}
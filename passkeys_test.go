@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+func TestPasskeysPage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	aaguid := uuid.MustParse("fbfc3007-154e-4ecc-8c0b-6e020557d7bd")
+	if err := app.queries.CreateWebauthnCredential(t.Context(), uuid.NewString(), "My Phone", &db.JSONCredential{
+		Data: &webauthn.Credential{ID: []byte("test-id"), Authenticator: webauthn.Authenticator{AAGUID: aaguid[:]}},
+	}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/admin/passkeys", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	for _, want := range []string{"My Phone", "iCloud Keychain", "Never"} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+
+	if got, notWant := string(body), "Revoke"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
+func TestPasskeysRevoke(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{uuid.NewString(), uuid.NewString()}
+	for i, id := range ids {
+		if err := app.queries.CreateWebauthnCredential(t.Context(), id, "", &db.JSONCredential{
+			Data: &webauthn.Credential{ID: []byte{byte(i)}},
+		}, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revoke := func(id string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/passkeys/"+id+"/revoke", nil)
+		req.Header.Set("Sec-Fetch-Site", "none")
+		req.AddCookie(&http.Cookie{
+			Name:  "sessionID",
+			Value: sessionID,
+		})
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	if got, want := revoke(ids[0]).StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// The last passkey can't be revoked.
+	if got, want := revoke(ids[1]).StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// Unknown passkeys can't be revoked.
+	if got, want := revoke(uuid.NewString()).StatusCode, http.StatusNotFound; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	credentials, err := app.queries.WebauthnCredentials(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(credentials), 1; got != want {
+		t.Fatalf("len(credentials) = %d, want = %d", got, want)
+	}
+
+	if got, want := credentials[0].WebauthnCredentialID, ids[1]; got != want {
+		t.Errorf("credentials[0].WebauthnCredentialID = %q, want = %q", got, want)
+	}
+}
+
+func TestPasskeysRegisterAdditional(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.CreateWebauthnCredential(t.Context(), uuid.NewString(), "", &db.JSONCredential{
+		Data: &webauthn.Credential{ID: []byte("test-id")},
+	}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/register/start", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// The existing passkey is excluded from registration.
+	if got, want := string(body), `"excludeCredentials":[{"type":"public-key","id":"dGVzdC1pZA"}]`; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
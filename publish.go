@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+)
+
+// A publishHook is called for each note after it becomes publicly visible. Hooks which perform slow or unreliable work
+// (e.g. network requests) should enqueue that work rather than performing it inline. If a hook returns an error, it's
+// run again for the note later.
+type publishHook struct {
+	// name identifies the hook in the record of which hooks have completed for each note, so it must not change.
+	name string
+
+	run func(ctx context.Context, note *db.Note) error
+}
+
+// announceNotes runs the publish hooks for each note which has become publicly visible since the last announcement.
+// Hooks which have already completed for a note aren't run again. Notes for which a hook fails remain unannounced.
+func announceNotes(ctx context.Context, logger *slog.Logger, queries *db.Queries, hooks []publishHook) error {
+	notes, err := queries.UnannouncedNotes(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to retrieve unannounced notes: %w", err)
+	}
+
+	for _, note := range notes {
+		completed, err := queries.CompletedPublishHooks(ctx, note.NoteID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve completed publish hooks for note %s: %w", note.NoteID, err)
+		}
+
+		failed := false
+		for _, hook := range hooks {
+			if slices.Contains(completed, hook.name) {
+				continue
+			}
+
+			if err := hook.run(ctx, &note); err != nil {
+				logger.ErrorContext(ctx, "error running publish hook", "noteID", note.NoteID, "hook", hook.name, "err", err)
+				failed = true
+				continue
+			}
+
+			if err := queries.CompletePublishHook(ctx, note.NoteID, hook.name, time.Now()); err != nil {
+				return fmt.Errorf("failed to record publish hook %s for note %s: %w", hook.name, note.NoteID, err)
+			}
+		}
+
+		// Leave the note unannounced so that the hooks are retried.
+		if failed {
+			continue
+		}
+
+		if err := queries.AnnounceNote(ctx, sql.NullTime{Time: time.Now(), Valid: true}, note.NoteID); err != nil {
+			return fmt.Errorf("failed to mark note %s as announced: %w", note.NoteID, err)
+		}
+		logger.InfoContext(ctx, "announced note", "noteID", note.NoteID)
+	}
+
+	return nil
+}
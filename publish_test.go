@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/google/uuid"
+)
+
+func TestAnnounceNotes(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	publishedID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), publishedID, "", "Published.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduledID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), scheduledID, "", "Scheduled.", time.Now().Add(1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.CreateDraft(t.Context(), uuid.NewString(), "", "Draft.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var announced []string
+	hook := publishHook{name: "test", run: func(_ context.Context, note *db.Note) error {
+		announced = append(announced, note.NoteID)
+		return nil
+	}}
+
+	logger := slog.New(slog.DiscardHandler)
+	for range 2 {
+		if err := announceNotes(t.Context(), logger, app.queries, []publishHook{hook}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := len(announced), 1; got != want {
+		t.Fatalf("len(announced) = %d, want = %d", got, want)
+	}
+
+	if got, want := announced[0], publishedID; got != want {
+		t.Errorf("announced[0] = %q, want = %q", got, want)
+	}
+}
+
+func TestAnnounceNotesHookFailure(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Published.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stand in for a hook which fails the first time it's run, and one which always succeeds.
+	var failingCalls, okCalls int
+	failing := publishHook{name: "failing", run: func(_ context.Context, _ *db.Note) error {
+		failingCalls++
+		if failingCalls == 1 {
+			return errors.New("database is locked")
+		}
+		return nil
+	}}
+	ok := publishHook{name: "ok", run: func(_ context.Context, _ *db.Note) error {
+		okCalls++
+		return nil
+	}}
+
+	logger := slog.New(slog.DiscardHandler)
+	for range 3 {
+		if err := announceNotes(t.Context(), logger, app.queries, []publishHook{failing, ok}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The failed hook is retried, but the hook which succeeded isn't run again.
+	if got, want := failingCalls, 2; got != want {
+		t.Errorf("failingCalls = %d, want = %d", got, want)
+	}
+
+	if got, want := okCalls, 1; got != want {
+		t.Errorf("okCalls = %d, want = %d", got, want)
+	}
+}
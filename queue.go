@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// A retryPolicy determines how failed attempts to process a queued item are retried.
+type retryPolicy struct {
+	// maxAttempts is the number of times an item is attempted before giving up.
+	maxAttempts int64
+
+	// delay is the delay before the first retry of a failed item. Subsequent retries back off exponentially.
+	delay time.Duration
+}
+
+// An attempt is the recorded outcome of an attempt to process a queued item.
+type attempt struct {
+	status        string
+	attempts      int64
+	lastError     string
+	nextAttemptAt time.Time
+	completedAt   sql.NullTime
+}
+
+// attempt returns the outcome of an attempt to process a queued item which has previously been attempted the given
+// number of times. If err is nil, the item has the done status. If err is permanent or the item has been attempted too
+// many times, the item has failed. Otherwise, the item is pending and its next attempt is delayed.
+func (p retryPolicy) attempt(attempts int64, nextAttemptAt time.Time, done string, err error, permanent bool) attempt {
+	a := attempt{status: done, attempts: attempts + 1, nextAttemptAt: nextAttemptAt}
+	switch {
+	case err != nil && (permanent || a.attempts >= p.maxAttempts):
+		a.status, a.lastError = "failed", err.Error()
+	case err != nil:
+		a.status, a.lastError = "pending", err.Error()
+		a.nextAttemptAt = time.Now().Add(p.delay << (a.attempts - 1))
+	default:
+		a.completedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return a
+}
+
+// runPeriodically calls f on every tick of the ticker until the context is cancelled, logging any errors.
+func runPeriodically(ctx context.Context, logger *slog.Logger, ticker *time.Ticker, desc string, f func(context.Context) error) {
+	for {
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			if err := f(ctx); err != nil {
+				logger.ErrorContext(ctx, "error "+desc, "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	p := retryPolicy{maxAttempts: 3, delay: 1 * time.Minute}
+	next := time.Now()
+	errFailed := errors.New("failed")
+
+	a := p.attempt(0, next, "sent", nil, false)
+	if got, want := a.status, "sent"; got != want {
+		t.Errorf("status = %q, want = %q", got, want)
+	}
+
+	if !a.completedAt.Valid {
+		t.Error("completedAt is not set")
+	}
+
+	a = p.attempt(1, next, "sent", errFailed, false)
+	if got, want := a.status, "pending"; got != want {
+		t.Errorf("status = %q, want = %q", got, want)
+	}
+
+	if got, want := a.attempts, int64(2); got != want {
+		t.Errorf("attempts = %d, want = %d", got, want)
+	}
+
+	if got, want := a.nextAttemptAt, time.Now().Add(2*time.Minute); got.Before(want.Add(-1*time.Second)) || got.After(want) {
+		t.Errorf("nextAttemptAt = %s, want = %s", got, want)
+	}
+
+	for _, tc := range []struct {
+		attempts  int64
+		permanent bool
+	}{{2, false}, {0, true}} {
+		a = p.attempt(tc.attempts, next, "sent", errFailed, tc.permanent)
+		if got, want := a.status, "failed"; got != want {
+			t.Errorf("attempt(%d, %v).status = %q, want = %q", tc.attempts, tc.permanent, got, want)
+		}
+
+		if got, want := a.lastError, "failed"; got != want {
+			t.Errorf("attempt(%d, %v).lastError = %q, want = %q", tc.attempts, tc.permanent, got, want)
+		}
+	}
+}
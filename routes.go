@@ -10,23 +10,78 @@ import (
 	"github.com/codahale/yellhole-go/internal/imgstore"
 )
 
-func addRoutes(mux *http.ServeMux, author, title, description string, baseURL *url.URL, logger *slog.Logger, queries *db.Queries, t *template.Template, images *imgstore.Store, assets http.Handler, assetPaths []string) {
+func addRoutes(mux *http.ServeMux, cfg *Config, baseURL *url.URL, ws *webSub, logger *slog.Logger, queries *db.Queries, actor *actor, client *http.Client, tokens *tokenHasher, policy sessionPolicy, t *template.Template, images *imgstore.Store, assets http.Handler, assetPaths []string) {
 	mux.Handle("GET /{$}", handleErrors(handleHomePage(queries, t)))
 	mux.Handle("GET /notes/{start}", handleErrors(handleWeekPage(queries, t)))
-	mux.Handle("GET /note/{id}", handleErrors(handleNotePage(queries, t)))
-	mux.Handle("GET /atom.xml", handleErrors(handleAtomFeed(queries, author, title, description, baseURL)))
+	mux.Handle("GET /notes/{start}/atom.xml", handleErrors(handleAtomArchive(queries, images, cfg.Author, cfg.Title, cfg.Description, baseURL)))
+	mux.Handle("GET /note/{id}", handleErrors(handleNotePage(queries, actor, t)))
+	mux.Handle("GET /search", handleErrors(handleSearchPage(queries, t)))
+	mux.Handle("GET /tags/{tag}", handleErrors(handleTagPage(queries, t)))
+	mux.Handle("GET /tags/{tag}/atom.xml", handleErrors(handleTagAtomFeed(queries, images, cfg.Author, cfg.Title, cfg.Description, baseURL, ws.hub)))
+	mux.Handle("GET /atom.xml", handleErrors(handleAtomFeed(queries, images, cfg.Author, cfg.Title, cfg.Description, baseURL, ws.hub, cfg.CompleteFeed)))
+	mux.Handle("GET /rss.xml", handleErrors(handleRSSFeed(queries, images, cfg.Author, cfg.Title, cfg.Description, baseURL, ws.hub)))
+	mux.Handle("GET /feed.json", handleErrors(handleJSONFeed(queries, images, cfg.Author, cfg.Title, cfg.Description, baseURL, ws.hub)))
 
 	mux.Handle("GET /admin", handleErrors(handleAdminPage(queries, t)))
 	mux.Handle("POST /admin/new", handleErrors(handleNewNote(queries, t, baseURL)))
+	mux.Handle("GET /admin/note/{id}/edit", handleErrors(handleEditNotePage(queries, t)))
+	mux.Handle("POST /admin/note/{id}/edit", handleErrors(handleEditNote(queries, t, baseURL)))
+	mux.Handle("POST /admin/note/{id}/publish", handleErrors(handlePublishDraft(queries, baseURL)))
+	mux.Handle("POST /admin/note/{id}/delete", handleErrors(handleDeleteNote(queries, baseURL)))
+	mux.Handle("POST /admin/note/{id}/restore", handleErrors(handleRestoreNote(queries, baseURL)))
+	mux.Handle("POST /admin/logout", handleErrors(handleLogout(queries, tokens, baseURL)))
+	mux.Handle("GET /admin/sessions", handleErrors(handleSessionsPage(queries, tokens, policy, t)))
+	mux.Handle("POST /admin/sessions/revoke", handleErrors(handleRevokeAllSessions(queries, baseURL)))
+	mux.Handle("POST /admin/sessions/{id}/revoke", handleErrors(handleRevokeSession(queries, baseURL)))
+	mux.Handle("GET /admin/passkeys", handleErrors(handlePasskeysPage(queries, t)))
+	mux.Handle("POST /admin/passkeys/{id}/revoke", handleErrors(handleRevokePasskey(queries, baseURL)))
+	mux.Handle("GET /admin/tokens", handleErrors(handleAPITokensPage(queries, t)))
+	mux.Handle("POST /admin/tokens", handleErrors(handleCreateAPIToken(queries, tokens, t)))
+	mux.Handle("POST /admin/tokens/{id}/revoke", handleErrors(handleRevokeAPIToken(queries, baseURL)))
+	mux.Handle("GET /admin/webmentions", handleErrors(handleWebmentionsPage(queries, t)))
+	mux.Handle("POST /admin/webmentions/{id}/approve", handleErrors(handleApproveWebmention(queries, baseURL)))
+	mux.Handle("POST /admin/webmentions/{id}/delete", handleErrors(handleDeleteWebmention(queries, baseURL)))
 	mux.Handle("POST /admin/images/download", handleErrors(handleDownloadImage(logger, queries, images, baseURL)))
 	mux.Handle("POST /admin/images/upload", handleErrors(handleUploadImage(queries, images, baseURL)))
 
-	mux.Handle("GET /register", handleErrors(handleRegisterPage(queries, t, baseURL)))
-	mux.Handle("POST /register/start", handleErrors(handleRegisterStart(queries, author, title, baseURL)))
-	mux.Handle("POST /register/finish", handleErrors(handleRegisterFinish(logger, queries, author, title, baseURL)))
-	mux.Handle("GET /login", handleErrors(handleLoginPage(queries, t, baseURL)))
-	mux.Handle("POST /login/start", handleErrors(handleLoginStart(queries, author, title, baseURL)))
-	mux.Handle("POST /login/finish", handleErrors(handleLoginFinish(logger, queries, author, title, baseURL)))
+	mux.Handle("GET /api/v1/notes", handleAPIErrors(handleAPIListNotes(queries, baseURL)))
+	mux.Handle("POST /api/v1/notes", handleAPIErrors(handleAPICreateNote(queries, baseURL)))
+	mux.Handle("GET /api/v1/notes/{id}", handleAPIErrors(handleAPIGetNote(queries, baseURL)))
+	mux.Handle("PUT /api/v1/notes/{id}", handleAPIErrors(handleAPIUpdateNote(queries, baseURL)))
+	mux.Handle("DELETE /api/v1/notes/{id}", handleAPIErrors(handleAPIDeleteNote(queries)))
+	mux.Handle("GET /api/v1/images", handleAPIErrors(handleAPIListImages(queries, baseURL)))
+	mux.Handle("POST /api/v1/images", handleAPIErrors(handleAPIUploadImage(queries, images, baseURL)))
+
+	mux.Handle("GET /micropub", handleOAuthErrors(handleMicropubQuery(queries, baseURL)))
+	mux.Handle("POST /micropub", handleOAuthErrors(handleMicropub(queries, images, baseURL)))
+	mux.Handle("POST /micropub/media", handleOAuthErrors(handleMicropubMedia(queries, images, baseURL)))
+
+	mux.Handle("GET /.well-known/oauth-authorization-server", handleErrors(handleIndieAuthMetadata(baseURL)))
+	mux.Handle("GET /indieauth/auth", handleErrors(handleIndieAuthPage(queries, tokens, policy, t, baseURL)))
+	mux.Handle("POST /indieauth/auth", handleOAuthErrors(handleIndieAuthProfile(queries, tokens, cfg.Author, baseURL)))
+	mux.Handle("POST /indieauth/token", handleOAuthErrors(handleIndieAuthToken(queries, tokens, cfg.Author, baseURL)))
+	mux.Handle("POST /indieauth/introspect", handleOAuthErrors(handleIndieAuthIntrospect(queries, tokens, baseURL)))
+	mux.Handle("POST /indieauth/revoke", handleOAuthErrors(handleIndieAuthRevoke(queries, tokens)))
+	mux.Handle("POST /admin/indieauth/approve", handleErrors(handleIndieAuthApprove(queries, tokens, baseURL)))
+
+	mux.Handle("POST /webmention", handleErrors(handleWebmention(queries, baseURL)))
+
+	if ws.builtin {
+		mux.Handle("POST /websub", handleErrors(handleHub(queries, ws)))
+	}
+
+	mux.Handle("GET /.well-known/webfinger", handleErrors(handleWebFinger(actor)))
+	mux.Handle("GET /activitypub/actor", handleErrors(handleActor(actor)))
+	mux.Handle("GET /activitypub/outbox", handleErrors(handleOutbox(queries, actor)))
+	mux.Handle("GET /activitypub/followers", handleErrors(handleFollowers(queries, actor)))
+	mux.Handle("POST /activitypub/inbox", handleErrors(handleInbox(logger, queries, actor, client)))
+
+	mux.Handle("GET /register", handleErrors(handleRegisterPage(queries, tokens, policy, t, baseURL)))
+	mux.Handle("POST /register/start", handleErrors(handleRegisterStart(queries, tokens, policy, cfg.Author, cfg.Title, baseURL)))
+	mux.Handle("POST /register/finish", handleErrors(handleRegisterFinish(logger, queries, tokens, policy, cfg.Author, cfg.Title, baseURL)))
+	mux.Handle("GET /login", handleErrors(handleLoginPage(queries, tokens, policy, t, baseURL)))
+	mux.Handle("POST /login/start", handleErrors(handleLoginStart(queries, tokens, policy, cfg.Author, cfg.Title, baseURL)))
+	mux.Handle("POST /login/finish", handleErrors(handleLoginFinish(logger, queries, tokens, policy, cfg.Author, cfg.Title, baseURL)))
 
 	mux.Handle("GET /images/feed/", http.StripPrefix("/images/feed/", handleFeedImage(images)))
 	mux.Handle("GET /images/thumb/", http.StripPrefix("/images/thumb/", handleThumbImage(images)))
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+)
+
+// handleSearchPage renders the notes which match a full-text search query, along with highlighted snippets.
+func handleSearchPage(queries *db.Queries, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query := strings.TrimSpace(r.FormValue("q"))
+
+		n, err := strconv.ParseInt(r.FormValue("n"), 10, 8)
+		if err != nil {
+			n = 10
+		}
+
+		var results []db.SearchNotesRow
+		noteID := r.FormValue("id")
+		switch {
+		case query == "":
+			// An empty query matches nothing.
+		case noteID == "":
+			results, err = queries.SearchNotes(r.Context(), ftsQuery(query), time.Now(), n)
+			if err != nil {
+				return fmt.Errorf("failed to search notes for %q: %w", query, err)
+			}
+		default:
+			older, err := queries.SearchNotesOlderThan(r.Context(), ftsQuery(query), time.Now(), noteID, n)
+			if err != nil {
+				return fmt.Errorf("failed to search notes for %q older than note %q: %w", query, noteID, err)
+			}
+			for _, row := range older {
+				results = append(results, db.SearchNotesRow(row))
+			}
+		}
+
+		notes := make([]db.Note, len(results))
+		snippets := make(map[string]template.HTML, len(results))
+		for i, result := range results {
+			notes[i] = db.Note{
+				NoteID:      result.NoteID,
+				Body:        result.Body,
+				CreatedAt:   result.CreatedAt,
+				UpdatedAt:   result.UpdatedAt,
+				DeletedAt:   result.DeletedAt,
+				Draft:       result.Draft,
+				AnnouncedAt: result.AnnouncedAt,
+				Title:       result.Title,
+			}
+			snippets[result.NoteID] = highlightSnippet(result.Snippet)
+		}
+
+		weeks, err := queries.WeeksWithNotes(r.Context(), time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve weeks with notes for search page: %w", err)
+		}
+
+		return htmlResponse(w, t, "feed.gohtml", &feedPage{Notes: notes, Weeks: weeks, Query: query, Snippets: snippets})
+	}
+}
+
+// ftsQuery converts a user-provided search query into an FTS5 query which matches all of its terms. Each term is quoted
+// as a string, which prevents FTS5 query syntax errors.
+func ftsQuery(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// highlightSnippet escapes a search snippet and replaces the match delimiters with <mark> elements.
+func highlightSnippet(snippet string) template.HTML {
+	s := html.EscapeString(snippet)
+	s = strings.ReplaceAll(s, "\x02", "<mark>")
+	s = strings.ReplaceAll(s, "\x03", "</mark>")
+	return template.HTML(s) //nolint:gosec // snippet is escaped
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchPage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "The quick brown fox.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "The lazy dog.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/search?q=fox", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := string(body), "The quick brown <mark>fox</mark>."; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	if got, notWant := string(body), "lazy dog"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
+func TestSearchPageEdited(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "A tpyo.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.queries.UpdateNote(t.Context(), "", "A typo.", sql.NullTime{Time: time.Now(), Valid: true}, noteID); err != nil {
+		t.Fatal(err)
+	}
+
+	for q, want := range map[string]bool{"tpyo": false, "typo": true} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/search?q="+q, nil)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+
+		if got := strings.Contains(string(body), "<mark>"); got != want {
+			t.Errorf("search for %q matched = %v, want = %v", q, got, want)
+		}
+	}
+}
+
+func TestSearchPageSyntax(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, `http://example.com/search?q=%22foo+AND+(`, nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+}
+
+func TestSearchPagePagination(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	olderID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), olderID, "", "An older fox.", time.Now().Add(-1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	newerID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), newerID, "", "A newer fox.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/search?q=fox&n=1", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := string(body), "?q=fox&id="+newerID; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/search?q=fox&n=1&id="+newerID, nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+	body, _ = io.ReadAll(resp.Body)
+
+	if got, want := string(body), "An older"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	if got, notWant := string(body), "A newer"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
+func TestSearchPageTitle(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "Foxes", "They're quick and brown.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/search?q=foxes", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	body, _ := io.ReadAll(w.Result().Body)
+	if got, want := string(body), "<mark>Foxes</mark>"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
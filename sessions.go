@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+)
+
+// handleLogout deletes the current session and its cookie.
+func handleLogout(queries *db.Queries, tokens *tokenHasher, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		cookie, err := r.Cookie("sessionID")
+		if err != nil && !errors.Is(err, http.ErrNoCookie) {
+			return fmt.Errorf("failed to get session cookie: %w", err)
+		}
+
+		if cookie != nil {
+			if err := queries.DeleteSession(r.Context(), tokens.hash(cookie.Value)); err != nil {
+				return fmt.Errorf("failed to delete session: %w", err)
+			}
+		}
+
+		http.SetCookie(w, secureCookie(baseURL, "sessionID", "", -1))
+		http.Redirect(w, r, baseURL.String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+// handleSessionsPage renders the admin page listing active sessions.
+func handleSessionsPage(queries *db.Queries, tokens *tokenHasher, policy sessionPolicy, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		now := time.Now()
+		sessions, err := queries.Sessions(r.Context(), policy.idleExpiry(now), policy.lifetimeExpiry(now))
+		if err != nil {
+			return fmt.Errorf("failed to retrieve sessions: %w", err)
+		}
+
+		var current string
+		if cookie, err := r.Cookie("sessionID"); err == nil {
+			current = tokens.hash(cookie.Value)
+		}
+
+		return htmlResponse(w, t, "sessions.gohtml", &sessionsPage{Sessions: sessions, Current: current})
+	}
+}
+
+// handleRevokeSession deletes a single session.
+func handleRevokeSession(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := queries.DeleteSession(r.Context(), r.PathValue("id")); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+
+		http.Redirect(w, r, baseURL.JoinPath("admin", "sessions").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+// handleRevokeAllSessions deletes all sessions, including the current one.
+func handleRevokeAllSessions(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := queries.DeleteAllSessions(r.Context()); err != nil {
+			return fmt.Errorf("failed to delete all sessions: %w", err)
+		}
+
+		http.SetCookie(w, secureCookie(baseURL, "sessionID", "", -1))
+		http.Redirect(w, r, baseURL.JoinPath("login").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+// sessionPolicy determines how long sessions last. Sessions expire after being idle for IdleTimeout, and
+// unconditionally after Lifetime.
+type sessionPolicy struct {
+	IdleTimeout time.Duration
+	Lifetime    time.Duration
+}
+
+// idleExpiry returns the time before which sessions last seen are expired.
+func (p sessionPolicy) idleExpiry(now time.Time) sql.NullTime {
+	return sql.NullTime{Time: now.Add(-p.IdleTimeout), Valid: true}
+}
+
+// lifetimeExpiry returns the time before which sessions created are expired.
+func (p sessionPolicy) lifetimeExpiry(now time.Time) time.Time {
+	return now.Add(-p.Lifetime)
+}
+
+// cookieMaxAge returns the max age of session cookies, in seconds. The cookie is refreshed with each authenticated
+// request, and the absolute lifetime is enforced by the database.
+func (p sessionPolicy) cookieMaxAge() int {
+	return int(p.IdleTimeout.Seconds())
+}
+
+type sessionsPage struct {
+	Sessions []db.Session
+	Current  string
+}
+
+// remoteIP returns the IP address of the request's client.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
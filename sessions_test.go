@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLogout(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/logout", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+
+	if got, want := resp.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	// The session cookie is refreshed by authentication and then deleted by logging out.
+	cookies := resp.Cookies()
+	if got, want := cookies[len(cookies)-1].MaxAge, -1; got != want {
+		t.Errorf("cookie.MaxAge = %d, want = %d", got, want)
+	}
+
+	exists, err := app.queries.SessionExists(t.Context(), app.tokens.hash(sessionID), testSessionPolicy.idleExpiry(time.Now()), testSessionPolicy.lifetimeExpiry(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exists {
+		t.Error("exists = true, want = false")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/admin", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp = w.Result()
+
+	if got, want := resp.Header.Get("Location"), "http://example.com/login"; got != want {
+		t.Errorf("resp.Header.Get(\"Location\") = %q, want = %q", got, want)
+	}
+}
+
+func TestSessionsPage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "Netscape Navigator", "192.0.2.1", time.Now().Add(-1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	otherID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(otherID), "Mosaic", "192.0.2.2", time.Now().Add(-1*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/admin/sessions", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	for _, want := range []string{"Netscape Navigator", "192.0.2.1", "Current", "/admin/sessions/" + app.tokens.hash(otherID) + "/revoke"} {
+		if got := string(body); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+
+	// Session tokens are secret, so only their hashes are displayed.
+	for _, notWant := range []string{sessionID, otherID} {
+		if got := string(body); strings.Contains(got, notWant) {
+			t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+		}
+	}
+
+	sessions, err := app.queries.Sessions(t.Context(), testSessionPolicy.idleExpiry(time.Now()), testSessionPolicy.lifetimeExpiry(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sessions[0].LastSeenAt.Time, sessions[0].CreatedAt; !got.After(want) {
+		t.Errorf("sessions[0].LastSeenAt = %v, want > %v", got, want)
+	}
+}
+
+func TestSessionsRevokeAll(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionIDs := []string{uuid.NewString(), uuid.NewString()}
+	for _, sessionID := range sessionIDs {
+		if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/sessions/revoke", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionIDs[0],
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	sessions, err := app.queries.Sessions(t.Context(), testSessionPolicy.idleExpiry(time.Now()), testSessionPolicy.lifetimeExpiry(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(sessions), 0; got != want {
+		t.Errorf("len(sessions) = %d, want = %d", got, want)
+	}
+}
+
+func TestSessionsExpiry(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	for name, tc := range map[string]struct {
+		createdAt, lastSeenAt time.Time
+		want                  bool
+	}{
+		"active": {time.Now().AddDate(0, 0, -10), time.Now().Add(-1 * time.Hour), true},
+		"idle":   {time.Now().AddDate(0, 0, -10), time.Now().AddDate(0, 0, -8), false},
+		"old":    {time.Now().AddDate(0, 0, -31), time.Now().Add(-1 * time.Hour), false},
+	} {
+		sessionID := uuid.NewString()
+		if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", tc.createdAt); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := app.queries.TouchSession(t.Context(), sql.NullTime{Time: tc.lastSeenAt, Valid: true}, app.tokens.hash(sessionID)); err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/admin", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  "sessionID",
+			Value: sessionID,
+		})
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		resp := w.Result()
+
+		if got, want := resp.StatusCode == http.StatusOK, tc.want; got != want {
+			t.Errorf("%s: authenticated = %v, want = %v", name, got, want)
+		}
+
+		if tc.want {
+			if got, want := resp.Cookies()[0].MaxAge, int(testSessionPolicy.IdleTimeout.Seconds()); got != want {
+				t.Errorf("%s: cookie.MaxAge = %d, want = %d", name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/codahale/yellhole-go/internal/markdown"
+	"github.com/codahale/yellhole-go/internal/mastodon"
+)
+
+// syndicationBatchSize is the maximum number of queued syndications attempted at a time.
+const syndicationBatchSize = 10
+
+// syndicationRetries is the retry policy for syndicating notes.
+var syndicationRetries = retryPolicy{maxAttempts: 5, delay: 1 * time.Minute}
+
+// A syndicationTarget is a site to which published notes are cross-posted.
+type syndicationTarget interface {
+	// Name returns the name of the site, which is stored with each syndicated copy and displayed on the note page.
+	Name() string
+
+	// Syndicate publishes a copy of the note to the site and returns the copy's URL.
+	Syndicate(ctx context.Context, note *db.Note) (string, error)
+}
+
+// syndicationHook returns a publish hook which queues newly-visible notes for syndication to each target.
+func syndicationHook(queries *db.Queries, targets []syndicationTarget) publishHook {
+	return publishHook{name: "syndication", run: func(ctx context.Context, note *db.Note) error {
+		for _, target := range targets {
+			if err := queries.QueueSyndication(ctx, note.NoteID, target.Name(), time.Now()); err != nil {
+				return fmt.Errorf("failed to queue syndication of note %s to %s: %w", note.NoteID, target.Name(), err)
+			}
+		}
+		return nil
+	}}
+}
+
+// syndicateNotes syndicates a batch of queued notes which are due. Failed syndications are retried with exponential
+// backoff until they've been attempted too many times.
+func syndicateNotes(ctx context.Context, logger *slog.Logger, queries *db.Queries, targets []syndicationTarget) error {
+	due, err := queries.DueSyndications(ctx, time.Now(), syndicationBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve due syndications: %w", err)
+	}
+
+	for _, s := range due {
+		var syndicatedURL string
+		note, err := queries.NoteByID(ctx, s.NoteID, time.Now())
+		if err == nil {
+			err = errors.New("unknown syndication target")
+			for _, target := range targets {
+				if target.Name() == s.Target {
+					syndicatedURL, err = target.Syndicate(ctx, &note)
+					break
+				}
+			}
+		}
+
+		a := syndicationRetries.attempt(s.Attempts, s.NextAttemptAt, "syndicated", err, errors.Is(err, sql.ErrNoRows))
+
+		if err := queries.UpdateSyndication(ctx, a.status, a.attempts, a.lastError, a.nextAttemptAt, syndicatedURL, a.completedAt, s.NoteID, s.Target); err != nil {
+			return fmt.Errorf("failed to update syndication of note %s to %s: %w", s.NoteID, s.Target, err)
+		}
+		logger.InfoContext(ctx, "syndicated note", "noteID", s.NoteID, "target", s.Target, "status", a.status, "url", syndicatedURL, "err", a.lastError)
+	}
+
+	return nil
+}
+
+const (
+	// maxMastodonStatusLength is the default maximum length of a Mastodon status, in characters.
+	maxMastodonStatusLength = 500
+
+	// mastodonURLLength is the number of characters Mastodon counts for any URL in a status.
+	mastodonURLLength = 23
+)
+
+// mastodonTarget syndicates notes as statuses on a Mastodon-compatible server. Each status contains the note's text,
+// truncated if necessary, a link to the note, and the note's locally-stored images.
+type mastodonTarget struct {
+	client  *mastodon.Client
+	images  *imgstore.Store
+	baseURL *url.URL
+	lang    string
+}
+
+func (m *mastodonTarget) Name() string {
+	return "Mastodon"
+}
+
+func (m *mastodonTarget) Syndicate(ctx context.Context, note *db.Note) (string, error) {
+	text, err := markdown.Text(note.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to render note %s as text: %w", note.NoteID, err)
+	}
+
+	if note.Title != "" {
+		text = note.Title + "\n\n" + text
+	}
+
+	images, err := markdown.Images(note.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse images for note %s: %w", note.NoteID, err)
+	}
+
+	var mediaIDs []string
+	for _, image := range images {
+		filename, ok := m.feedImage(image)
+		if !ok || len(mediaIDs) == mastodon.MaxAttachments {
+			continue
+		}
+
+		id, err := m.uploadImage(ctx, filename)
+		if err != nil {
+			return "", err
+		}
+		mediaIDs = append(mediaIDs, id)
+	}
+
+	noteURL := m.baseURL.JoinPath("note", note.NoteID).String()
+	text = truncateText(text, maxMastodonStatusLength-mastodonURLLength-2) + "\n\n" + noteURL
+
+	return m.client.PostStatus(ctx, &mastodon.Status{Text: text, MediaIDs: mediaIDs, Language: m.lang, IdempotencyKey: note.NoteID})
+}
+
+// feedImage returns the filename of the feed image with the given URL, if it's stored locally.
+func (m *mastodonTarget) feedImage(image *url.URL) (string, bool) {
+	u := m.baseURL.ResolveReference(image)
+	dir := m.baseURL.JoinPath("images", "feed").Path + "/"
+	if u.Host != m.baseURL.Host || !strings.HasPrefix(u.Path, dir) {
+		return "", false
+	}
+	return path.Base(u.Path), true
+}
+
+func (m *mastodonTarget) uploadImage(ctx context.Context, filename string) (id string, err error) {
+	contentType, _, err := m.images.FeedImageInfo(filename)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := m.images.FeedImages().Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to open feed image %q: %w", filename, err)
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	id, err = m.client.UploadMedia(ctx, filename, contentType, f)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload feed image %q: %w", filename, err)
+	}
+	return id, nil
+}
+
+// truncateText truncates s to at most n characters, ending it with an ellipsis if it was truncated.
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/codahale/yellhole-go/internal/mastodon"
+	"github.com/google/uuid"
+)
+
+func TestSyndication(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	images, err := imgstore.New(app.tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := images.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := os.WriteFile(filepath.Join(app.tempDir, "images", "feed", "cat.png"), []byte("not really a cat"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stand in for a Mastodon server which fails the first attempt to post a status.
+	var uploads []string
+	var statuses []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/media", func(w http.ResponseWriter, r *http.Request) {
+		_, header, _ := r.FormFile("file")
+		uploads = append(uploads, header.Filename)
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	})
+	mux.HandleFunc("POST /api/v1/statuses", func(w http.ResponseWriter, r *http.Request) {
+		var status struct {
+			Status string `json:"status"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&status)
+		statuses = append(statuses, status.Status)
+
+		if len(statuses) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"2","url":"https://mastodon.example/@notes/2"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	serverURL, _ := url.Parse(server.URL)
+	targets := []syndicationTarget{&mastodonTarget{
+		client:  &mastodon.Client{Server: serverURL, Token: "token", HTTP: server.Client()},
+		images:  images,
+		baseURL: &url.URL{Scheme: "http", Host: "example.com", Path: "/"},
+		lang:    "en",
+	}}
+
+	noteID := uuid.NewString()
+	body := "It's a *cat*.\n\n![a cat](/images/feed/cat.png)\n\n![a dog](https://dogs.example/dog.png)"
+	if err := app.queries.CreateNote(t.Context(), noteID, "Cats", body, time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if err := announceNotes(t.Context(), logger, app.queries, []publishHook{syndicationHook(app.queries, targets)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syndicateNotes(t.Context(), logger, app.queries, targets); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed attempt is retried later.
+	due, err := app.queries.DueSyndications(t.Context(), time.Now().Add(1*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(due), 1; got != want {
+		t.Fatalf("len(due) = %d, want = %d", got, want)
+	}
+
+	if got, want := due[0].LastError, "503"; !strings.Contains(got, want) {
+		t.Errorf("LastError = %q, want = /.*%s.*/", got, want)
+	}
+
+	if err := app.queries.UpdateSyndication(t.Context(), "pending", due[0].Attempts, "", time.Now(), "", due[0].SyndicatedAt, noteID, due[0].Target); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := syndicateNotes(t.Context(), logger, app.queries, targets); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only locally-stored images are uploaded.
+	if got, want := uploads, []string{"cat.png", "cat.png"}; !slices.Equal(got, want) {
+		t.Errorf("uploads = %v, want = %v", got, want)
+	}
+
+	if got, want := statuses[1], "Cats\n\nIt’s a cat. a cat a dog\n\nhttp://example.com/note/"+noteID; got != want {
+		t.Errorf("status = %q, want = %q", got, want)
+	}
+
+	// The syndicated copy is linked from the note page.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/note/"+noteID, nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	page, _ := io.ReadAll(w.Result().Body)
+	if got, want := string(page), `<a class="u-syndication" rel="syndication" href="https://mastodon.example/@notes/2">Mastodon</a>`; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	t.Parallel()
+
+	if got, want := truncateText("short", 10), "short"; got != want {
+		t.Errorf("truncateText() = %q, want = %q", got, want)
+	}
+
+	if got, want := truncateText("a bit too long", 8), "a bit t…"; got != want {
+		t.Errorf("truncateText() = %q, want = %q", got, want)
+	}
+}
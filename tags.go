@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/codahale/yellhole-go/internal/markdown"
+	"github.com/gorilla/feeds"
+)
+
+// handleTagPage renders the notes with a given hashtag.
+func handleTagPage(queries *db.Queries, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tag := markdown.NormalizeTag(r.PathValue("tag"))
+
+		n, err := strconv.ParseInt(r.FormValue("n"), 10, 8)
+		if err != nil {
+			n = 10
+		}
+
+		var notes []db.Note
+		noteID := r.FormValue("id")
+		if noteID == "" {
+			notes, err = queries.NotesByTag(r.Context(), tag, time.Now(), n)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve notes with tag %q: %w", tag, err)
+			}
+		} else {
+			notes, err = queries.NotesByTagOlderThan(r.Context(), tag, noteID, time.Now(), n)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve notes with tag %q older than note %q: %w", tag, noteID, err)
+			}
+		}
+
+		weeks, err := queries.WeeksWithNotes(r.Context(), time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve weeks with notes for tag page: %w", err)
+		}
+
+		return htmlResponse(w, t, "feed.gohtml", &feedPage{Notes: notes, Weeks: weeks, Tag: tag})
+	}
+}
+
+// handleTagAtomFeed renders an Atom feed of the most recent notes with a given hashtag.
+func handleTagAtomFeed(queries *db.Queries, images *imgstore.Store, author, title, description string, baseURL *url.URL, hub string) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		tag := markdown.NormalizeTag(r.PathValue("tag"))
+
+		notes, err := queries.NotesByTag(r.Context(), tag, time.Now(), feedPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve notes with tag %q for atom feed: %w", tag, err)
+		}
+
+		feed := feeds.Feed{
+			Title:       title + " #" + tag,
+			Link:        &feeds.Link{Href: baseURL.JoinPath("tags", tag).String()},
+			Description: description,
+			Author:      &feeds.Author{Name: author},
+		}
+
+		if err := addFeedItems(&feed, notes, images, baseURL); err != nil {
+			return err
+		}
+
+		self := feeds.AtomLink{Href: baseURL.JoinPath("tags", tag, "atom.xml").String(), Rel: "self"}
+		return writeFeed(w, newAtomHistoryFeed(&feed, hubLinks(hub, self)...), "application/atom+xml")
+	}
+}
+
+// setNoteTags replaces the given note's tags with the hashtags in its body.
+func setNoteTags(ctx context.Context, queries *db.Queries, noteID, body string) error {
+	tags, err := markdown.Tags(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse tags for note %s: %w", noteID, err)
+	}
+
+	if err := queries.DeleteNoteTags(ctx, noteID); err != nil {
+		return fmt.Errorf("failed to delete tags for note %s: %w", noteID, err)
+	}
+
+	for _, tag := range tags {
+		if err := queries.CreateNoteTag(ctx, noteID, tag); err != nil {
+			return fmt.Errorf("failed to create tag %q for note %s: %w", tag, noteID, err)
+		}
+	}
+	return nil
+}
+
+// backfillNoteTags parses the hashtags of all existing notes, once. This populates tags for notes which were created
+// before hashtags were supported.
+func backfillNoteTags(ctx context.Context, queries *db.Queries) error {
+	completed, err := queries.BackfillCompleted(ctx, "note_tag")
+	if err != nil {
+		return fmt.Errorf("failed to check for note tag backfill: %w", err)
+	}
+
+	if completed {
+		return nil
+	}
+
+	notes, err := queries.AllNoteBodies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve note bodies: %w", err)
+	}
+
+	for _, note := range notes {
+		if err := setNoteTags(ctx, queries, note.NoteID, note.Body); err != nil {
+			return err
+		}
+	}
+
+	if err := queries.CompleteBackfill(ctx, "note_tag", time.Now()); err != nil {
+		return fmt.Errorf("failed to record note tag backfill: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/google/uuid"
+)
+
+func TestTagPage(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	for _, body := range []string{"Going #Camping.", "Just #cooking."} {
+		noteID := uuid.NewString()
+		if err := app.queries.CreateNote(t.Context(), noteID, "", body, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := setNoteTags(t.Context(), app.queries, noteID, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/tags/camping", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := string(body), `<a href="http://example.com/tags/camping" class="hashtag">#Camping</a>`; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	if got, notWant := string(body), "cooking"; strings.Contains(got, notWant) {
+		t.Errorf("body = %q, notWant = /.*%s.*/", got, notWant)
+	}
+}
+
+func TestTagAtomFeed(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Going #camping.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setNoteTags(t.Context(), app.queries, noteID, "Going #camping."); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/tags/Camping/atom.xml", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if got, want := resp.Header.Get("Content-Type"), "application/atom+xml"; got != want {
+		t.Errorf(`resp.Header.Get("Content-Type") = %q, want = %q`, got, want)
+	}
+
+	if got, want := string(body), "<id>"+noteID+"</id>"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
+
+func TestAdminNoteEditTags(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "Going #camping.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setNoteTags(t.Context(), app.queries, noteID, "Going #camping."); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	body, _ := mw.CreateFormField("body")
+	_, _ = body.Write([]byte("Going #hiking."))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/note/"+noteID+"/edit", &b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	for tag, want := range map[string]int{"camping": 0, "hiking": 1} {
+		notes, err := app.queries.NotesByTag(t.Context(), tag, time.Now(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := len(notes); got != want {
+			t.Errorf("len(NotesByTag(%q)) = %d, want = %d", tag, got, want)
+		}
+	}
+}
+
+func TestBackfillNoteTags(t *testing.T) {
+	t.Parallel()
+
+	// Use a database without a test app, which would have already backfilled its tags.
+	conn, queries, err := db.NewWithMigrations(t.Context(), slog.New(slog.DiscardHandler), filepath.Join(t.TempDir(), "yellhole.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := queries.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := conn.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	noteID := uuid.NewString()
+	if err := queries.CreateNote(t.Context(), noteID, "", "A #cat.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backfillNoteTags(t.Context(), queries); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := queries.NotesByTag(t.Context(), "cat", time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 1; got != want {
+		t.Fatalf("len(notes) = %d, want = %d", got, want)
+	}
+
+	// Notes aren't parsed again once the backfill is complete.
+	if err := queries.DeleteNoteTags(t.Context(), noteID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backfillNoteTags(t.Context(), queries); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err = queries.NotesByTag(t.Context(), "cat", time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(notes), 0; got != want {
+		t.Errorf("len(notes) = %d, want = %d", got, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"time"
 
+	"github.com/codahale/yellhole-go/internal/db"
 	"github.com/codahale/yellhole-go/internal/markdown"
 )
 
@@ -18,7 +19,7 @@ var (
 )
 
 // loadTemplates loads and parses all the embedded templates for the app.
-func loadTemplates(author, title, description, lang, buildTag string, baseURL *url.URL, assetHashes map[string]string) (*template.Template, error) {
+func loadTemplates(cfg *Config, buildTag string, baseURL *url.URL, assetHashes map[string]string) (*template.Template, error) {
 	return template.New("yellhole").Funcs(template.FuncMap{
 		"assetHash": func(elem ...string) (string, error) {
 			p := path.Join(elem...)
@@ -29,26 +30,31 @@ func loadTemplates(author, title, description, lang, buildTag string, baseURL *u
 			return hash, nil
 		},
 		"author": func() string {
-			return author
+			return cfg.Author
 		},
 		"buildTag": func() string {
 			return buildTag
 		},
 		"description": func() string {
-			return description
+			return cfg.Description
 		},
 		"host": func() string {
 			return baseURL.Host
 		},
 		"lang": func() string {
-			return lang
+			return cfg.Lang
+		},
+		"markdownHTML": func(s string) (template.HTML, error) {
+			return markdown.HTML(s, baseURL)
 		},
-		"markdownHTML":   markdown.HTML,
 		"markdownText":   markdown.Text,
 		"markdownImages": markdown.Images,
-		"now":            time.Now,
+		"noteTitle": func(note db.Note) (string, error) {
+			return noteTitle(&note)
+		},
+		"now": time.Now,
 		"title": func() string {
-			return title
+			return cfg.Title
 		},
 		"url": func(elem ...string) template.URL {
 			return template.URL(baseURL.JoinPath(elem...).String()) //nolint:gosec // input is trusted
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// tokenHasher produces keyed hashes of secret tokens (e.g. session IDs) for storage in the database. Only hashes are
+// stored, so read access to the database or its backups isn't sufficient to use the tokens. Because lookups are done by
+// keyed hash, the timing of a lookup reveals nothing about any stored token.
+type tokenHasher struct {
+	key []byte
+}
+
+// hash returns the hex-encoded HMAC-SHA-256 of the token.
+func (h *tokenHasher) hash(token string) string {
+	mac := hmac.New(sha256.New, h.key)
+	_, _ = mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loadTokenKey reads the token hashing key from the given file, creating it with a random key if it doesn't exist. The
+// key is stored outside the database so that a copy of the database alone can't be used to verify tokens.
+func loadTokenKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("invalid token key length in %q: %d", path, len(key))
+		}
+		return key, nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read token key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	_, _ = rand.Read(key)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token key file: %w", err)
+	}
+
+	if _, err := f.Write(key); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to write token key: %w", err), f.Close())
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close token key file: %w", err)
+	}
+	return key, nil
+}
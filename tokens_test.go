@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTokenKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "token.key")
+
+	a, err := loadTokenKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := loadTokenKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(a), 32; got != want {
+		t.Errorf("len(key) = %d, want = %d", got, want)
+	}
+
+	if !bytes.Equal(a, b) {
+		t.Errorf("loadTokenKey = %x, want = %x", b, a)
+	}
+}
+
+func TestTokenHasher(t *testing.T) {
+	t.Parallel()
+
+	a := &tokenHasher{key: bytes.Repeat([]byte{1}, 32)}
+	b := &tokenHasher{key: bytes.Repeat([]byte{2}, 32)}
+
+	if got, notWant := a.hash("token"), "token"; got == notWant {
+		t.Errorf("hash(%q) = %q, notWant = %q", "token", got, notWant)
+	}
+
+	if got, want := a.hash("token"), a.hash("token"); got != want {
+		t.Errorf("hash(%q) = %q, want = %q", "token", got, want)
+	}
+
+	if got, notWant := a.hash("token"), b.hash("token"); got == notWant {
+		t.Errorf("hash(%q) = %q with different keys", "token", got)
+	}
+}
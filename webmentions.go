@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/markdown"
+	"github.com/codahale/yellhole-go/internal/webmention"
+	"github.com/google/uuid"
+)
+
+// webmentionBatchSize is the maximum number of pending Webmentions verified or sent at a time.
+const webmentionBatchSize = 10
+
+// webmentionRetries is the retry policy for sending Webmentions.
+var webmentionRetries = retryPolicy{maxAttempts: 5, delay: 1 * time.Minute}
+
+// handleWebmention accepts a Webmention for a note and queues it for asynchronous verification.
+func handleWebmention(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		source, target := r.PostFormValue("source"), r.PostFormValue("target")
+
+		sourceURL, err := url.Parse(source)
+		if err != nil || (sourceURL.Scheme != "http" && sourceURL.Scheme != "https") || sourceURL.Host == "" {
+			http.Error(w, "Invalid source URL.", http.StatusBadRequest)
+			return nil
+		}
+
+		if source == target {
+			http.Error(w, "Source and target must be different.", http.StatusBadRequest)
+			return nil
+		}
+
+		noteID, err := micropubNoteID(baseURL, target)
+		if err != nil {
+			http.Error(w, "Target is not a note.", http.StatusBadRequest)
+			return nil
+		}
+
+		if _, err := queries.NoteByID(r.Context(), noteID, time.Now()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Target is not a note.", http.StatusBadRequest)
+				return nil
+			}
+			return fmt.Errorf("failed to retrieve note for webmention: %w", err)
+		}
+
+		if err := queries.CreateWebmention(r.Context(), uuid.NewString(), noteID, source, target, time.Now()); err != nil {
+			return fmt.Errorf("failed to create webmention: %w", err)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+// handleWebmentionsPage renders the admin page for moderating received Webmentions and checking on sent ones.
+func handleWebmentionsPage(queries *db.Queries, t *template.Template) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		received, err := queries.Webmentions(r.Context(), 100)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve webmentions: %w", err)
+		}
+
+		sent, err := queries.OutgoingWebmentions(r.Context(), 100)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve outgoing webmentions: %w", err)
+		}
+
+		return htmlResponse(w, t, "webmentions.gohtml", &webmentionsPage{Received: received, Sent: sent})
+	}
+}
+
+type webmentionsPage struct {
+	Received []db.Webmention
+	Sent     []db.OutgoingWebmention
+}
+
+// handleApproveWebmention approves a Webmention for display once it has been verified.
+func handleApproveWebmention(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		approved, err := queries.ApproveWebmention(r.Context(), sql.NullTime{Time: time.Now(), Valid: true}, r.PathValue("id"))
+		if err != nil {
+			return fmt.Errorf("failed to approve webmention: %w", err)
+		}
+
+		if approved == 0 {
+			http.Error(w, "Only verified Webmentions can be approved.", http.StatusConflict)
+			return nil
+		}
+
+		http.Redirect(w, r, baseURL.JoinPath("admin", "webmentions").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+// handleDeleteWebmention deletes a Webmention.
+func handleDeleteWebmention(queries *db.Queries, baseURL *url.URL) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := queries.DeleteWebmention(r.Context(), r.PathValue("id")); err != nil {
+			return fmt.Errorf("failed to delete webmention: %w", err)
+		}
+
+		http.Redirect(w, r, baseURL.JoinPath("admin", "webmentions").String(), http.StatusSeeOther)
+		return nil
+	}
+}
+
+// verifyWebmentions verifies a batch of pending Webmentions. Mentions whose sources are gone are deleted, and mentions
+// whose sources no longer link to their targets are marked as invalid.
+func verifyWebmentions(ctx context.Context, logger *slog.Logger, queries *db.Queries, client *http.Client) error {
+	pending, err := queries.PendingWebmentions(ctx, webmentionBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve pending webmentions: %w", err)
+	}
+
+	for _, wm := range pending {
+		m, err := webmention.Verify(ctx, client, wm.Source, wm.Target)
+		switch {
+		case errors.Is(err, webmention.ErrGone):
+			if err := queries.DeleteWebmention(ctx, wm.WebmentionID); err != nil {
+				return fmt.Errorf("failed to delete webmention %s: %w", wm.WebmentionID, err)
+			}
+			logger.InfoContext(ctx, "deleted webmention", "webmentionID", wm.WebmentionID, "source", wm.Source)
+		case err != nil:
+			if err := queries.InvalidateWebmention(ctx, sql.NullTime{Time: time.Now(), Valid: true}, wm.WebmentionID); err != nil {
+				return fmt.Errorf("failed to invalidate webmention %s: %w", wm.WebmentionID, err)
+			}
+			logger.InfoContext(ctx, "invalid webmention", "webmentionID", wm.WebmentionID, "source", wm.Source, "err", err)
+		default:
+			if err := queries.VerifyWebmention(ctx, m.Type, m.AuthorName, m.AuthorURL, m.AuthorPhoto, m.Content, sql.NullTime{Time: time.Now(), Valid: true}, wm.WebmentionID); err != nil {
+				return fmt.Errorf("failed to verify webmention %s: %w", wm.WebmentionID, err)
+			}
+			logger.InfoContext(ctx, "verified webmention", "webmentionID", wm.WebmentionID, "source", wm.Source)
+		}
+	}
+
+	return nil
+}
+
+// webmentionHook returns a publish hook which queues Webmentions for the links in newly-visible notes.
+func webmentionHook(queries *db.Queries) publishHook {
+	return publishHook{name: "webmention", run: func(ctx context.Context, note *db.Note) error {
+		return queueWebmentions(ctx, queries.QueueOutgoingWebmention, note.NoteID, note.Body)
+	}}
+}
+
+// queueEditedNoteWebmentions queues Webmentions for the links in both the old and new bodies of an edited note, so that
+// sites which are no longer linked to are notified too. Notes which haven't been announced yet are skipped, since their
+// Webmentions will be queued by webmentionHook. Webmentions which have already been sent are sent again.
+func queueEditedNoteWebmentions(ctx context.Context, queries *db.Queries, note *db.Note, body string) error {
+	if !note.AnnouncedAt.Valid {
+		return nil
+	}
+	return queueWebmentions(ctx, queries.RequeueOutgoingWebmention, note.NoteID, note.Body, body)
+}
+
+// queueWebmentions queues a Webmention for each link in the given note bodies with the given query.
+func queueWebmentions(ctx context.Context, queue func(ctx context.Context, noteID, target string, createdAt time.Time) error, noteID string, bodies ...string) error {
+	for _, body := range bodies {
+		links, err := markdown.Links(body)
+		if err != nil {
+			return fmt.Errorf("failed to parse links for note %s: %w", noteID, err)
+		}
+
+		for _, link := range links {
+			if err := queue(ctx, noteID, link, time.Now()); err != nil {
+				return fmt.Errorf("failed to queue webmention to %q for note %s: %w", link, noteID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sendWebmentions sends a batch of queued Webmentions which are due. Failed mentions are retried with exponential
+// backoff, unless the endpoint rejected them or they've been attempted too many times.
+func sendWebmentions(ctx context.Context, logger *slog.Logger, queries *db.Queries, client *http.Client, baseURL *url.URL) error {
+	due, err := queries.DueOutgoingWebmentions(ctx, time.Now(), webmentionBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve due webmentions: %w", err)
+	}
+
+	for _, wm := range due {
+		source := baseURL.JoinPath("note", wm.NoteID).String()
+		endpoint, err := webmention.Discover(ctx, client, wm.Target)
+		if err == nil {
+			err = webmention.Send(ctx, client, endpoint, source, wm.Target)
+		}
+
+		a := webmentionRetries.attempt(wm.Attempts, wm.NextAttemptAt, "sent", err, errors.Is(err, webmention.ErrRejected) || errors.Is(err, webmention.ErrNoEndpoint))
+		if errors.Is(err, webmention.ErrNoEndpoint) {
+			a.status, a.lastError = "unsupported", ""
+		}
+
+		if err := queries.UpdateOutgoingWebmention(ctx, a.status, a.attempts, a.lastError, a.nextAttemptAt, a.completedAt, wm.NoteID, wm.Target); err != nil {
+			return fmt.Errorf("failed to update webmention to %q for note %s: %w", wm.Target, wm.NoteID, err)
+		}
+		logger.InfoContext(ctx, "sent webmention", "noteID", wm.NoteID, "target", wm.Target, "status", a.status, "err", a.lastError)
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebmentions(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "An example.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	target := "http://example.com/note/" + noteID
+
+	content := "Great note!"
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<div class="h-entry">
+	<a class="p-author h-card" href="https://jane.example/">Jane</a>
+	<a class="u-in-reply-to" href="` + target + `">Re</a>
+	<p class="e-content">` + content + `</p>
+</div>`))
+	}))
+	t.Cleanup(source.Close)
+
+	send := func(source, target string) int {
+		form := url.Values{"source": {source}, "target": {target}}
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/webmention", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+
+	notePage := func() string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+
+		body, _ := io.ReadAll(w.Result().Body)
+		return string(body)
+	}
+
+	for name, tc := range map[string]struct {
+		source, target string
+		want           int
+	}{
+		"invalid source": {"ftp://example.org/", target, http.StatusBadRequest},
+		"same URL":       {target, target, http.StatusBadRequest},
+		"not a note":     {source.URL, "http://example.com/search", http.StatusBadRequest},
+		"missing note":   {source.URL, "http://example.com/note/" + uuid.NewString(), http.StatusBadRequest},
+		"valid":          {source.URL, target, http.StatusAccepted},
+	} {
+		if got, want := send(tc.source, tc.target), tc.want; got != want {
+			t.Errorf("%s: resp.StatusCode = %d, want = %d", name, got, want)
+		}
+	}
+
+	// Resending a webmention doesn't duplicate it.
+	if got, want := send(source.URL, target), http.StatusAccepted; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if err := verifyWebmentions(t.Context(), slog.New(slog.DiscardHandler), app.queries, source.Client()); err != nil {
+		t.Fatal(err)
+	}
+
+	webmentions, err := app.queries.Webmentions(t.Context(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(webmentions), 1; got != want {
+		t.Fatalf("len(webmentions) = %d, want = %d", got, want)
+	}
+
+	if got, want := webmentions[0].Status, "verified"; got != want {
+		t.Errorf("webmentions[0].Status = %q, want = %q", got, want)
+	}
+
+	if got, want := webmentions[0].MentionType, "reply"; got != want {
+		t.Errorf("webmentions[0].MentionType = %q, want = %q", got, want)
+	}
+
+	// Verified webmentions aren't displayed until approved.
+	if got, want := notePage(), "Great note!"; strings.Contains(got, want) {
+		t.Errorf("body = %q, want != /.*%s.*/", got, want)
+	}
+
+	if _, err := app.queries.ApproveWebmention(t.Context(), sql.NullTime{Time: time.Now(), Valid: true}, webmentions[0].WebmentionID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Replies", "Great note!", "Jane"} {
+		if got := notePage(); !strings.Contains(got, want) {
+			t.Errorf("body = %q, want = /.*%s.*/", got, want)
+		}
+	}
+
+	// Resending an approved webmention requires it to be approved again.
+	content = "Buy my pills!"
+	if got, want := send(source.URL, target), http.StatusAccepted; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	if err := verifyWebmentions(t.Context(), slog.New(slog.DiscardHandler), app.queries, source.Client()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := notePage(), "Buy my pills!"; strings.Contains(got, want) {
+		t.Errorf("body = %q, want != /.*%s.*/", got, want)
+	}
+}
+
+func TestWebmentionsInvalid(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	noteID := uuid.NewString()
+	if err := app.queries.CreateNote(t.Context(), noteID, "", "An example.", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<p>Nothing to see here.</p>`))
+	}))
+	t.Cleanup(source.Close)
+
+	if err := app.queries.CreateWebmention(t.Context(), uuid.NewString(), noteID, source.URL, "http://example.com/note/"+noteID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyWebmentions(t.Context(), slog.New(slog.DiscardHandler), app.queries, source.Client()); err != nil {
+		t.Fatal(err)
+	}
+
+	webmentions, err := app.queries.Webmentions(t.Context(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := webmentions[0].Status, "invalid"; got != want {
+		t.Errorf("webmentions[0].Status = %q, want = %q", got, want)
+	}
+
+	// Invalid webmentions can't be approved.
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/webmentions/"+webmentions[0].WebmentionID+"/approve", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.AddCookie(&http.Cookie{
+		Name:  "sessionID",
+		Value: sessionID,
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got, want := w.Result().StatusCode, http.StatusConflict; got != want {
+		t.Errorf("resp.StatusCode = %d, want = %d", got, want)
+	}
+
+	approved, err := app.queries.ApprovedWebmentionsByNote(t.Context(), noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(approved), 0; got != want {
+		t.Errorf("len(approved) = %d, want = %d", got, want)
+	}
+}
+
+func TestSendWebmentions(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	var sources []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /post", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Link", `</endpoint>; rel="webmention"`)
+	})
+	mux.HandleFunc("GET /flaky", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<link rel="webmention" href="/unavailable">`))
+	})
+	mux.HandleFunc("GET /plain", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<p>No webmentions here.</p>`))
+	})
+	mux.HandleFunc("POST /endpoint", func(w http.ResponseWriter, r *http.Request) {
+		sources = append(sources, r.PostFormValue("source"))
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("POST /unavailable", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	noteID := uuid.NewString()
+	body := "Check out [this](" + server.URL + "/post), <" + server.URL + "/flaky>, and [this](" + server.URL + "/plain)."
+	if err := app.queries.CreateNote(t.Context(), noteID, "", body, time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if err := announceNotes(t.Context(), logger, app.queries, []publishHook{webmentionHook(app.queries)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sendWebmentions(t.Context(), logger, app.queries, server.Client(), &url.URL{Scheme: "http", Host: "example.com", Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sources, []string{"http://example.com/note/" + noteID}; !slices.Equal(got, want) {
+		t.Errorf("sources = %v, want = %v", got, want)
+	}
+
+	statuses := func() map[string]string {
+		sent, err := app.queries.OutgoingWebmentions(t.Context(), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		statuses := make(map[string]string, len(sent))
+		for _, wm := range sent {
+			statuses[strings.TrimPrefix(wm.Target, server.URL)] = wm.Status
+		}
+		return statuses
+	}
+
+	if got, want := statuses(), map[string]string{"/post": "sent", "/flaky": "pending", "/plain": "unsupported"}; !maps.Equal(got, want) {
+		t.Errorf("statuses = %v, want = %v", got, want)
+	}
+
+	// Editing an announced note queues mentions for both old and new links.
+	note, err := app.queries.EditableNoteByID(t.Context(), noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := queueEditedNoteWebmentions(t.Context(), app.queries, &note, "Never mind."); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := statuses(), map[string]string{"/post": "pending", "/flaky": "pending", "/plain": "pending"}; !maps.Equal(got, want) {
+		t.Errorf("statuses = %v, want = %v", got, want)
+	}
+
+	// Delivery status is displayed in the admin.
+	sessionID := uuid.NewString()
+	if err := app.queries.CreateSession(t.Context(), app.tokens.hash(sessionID), "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/admin/webmentions", nil)
+	req.AddCookie(&http.Cookie{Name: "sessionID", Value: sessionID})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	page, _ := io.ReadAll(w.Result().Body)
+	if got, want := string(page), server.URL+"/flaky"; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+}
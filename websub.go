@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/db"
+	"github.com/codahale/yellhole-go/internal/markdown"
+	"github.com/google/uuid"
+)
+
+const (
+	// hubBatchSize is the maximum number of hub notifications or subscription verifications attempted at a time.
+	hubBatchSize = 20
+
+	// defaultLeaseSeconds and maxLeaseSeconds bound the lease of subscriptions to the built-in hub.
+	defaultLeaseSeconds = 10 * 24 * 60 * 60
+	maxLeaseSeconds     = 30 * 24 * 60 * 60
+
+	// maxHubSecretLength is the maximum length of a subscriber's secret, per the WebSub specification.
+	maxHubSecretLength = 200
+)
+
+// hubRetries is the retry policy for hub notifications.
+var hubRetries = retryPolicy{maxAttempts: 5, delay: 1 * time.Minute}
+
+// webSub publishes feed updates via WebSub, either by pinging an external hub or by acting as a hub itself. If hub is
+// empty, WebSub is disabled.
+type webSub struct {
+	hub     string
+	builtin bool
+	baseURL *url.URL
+	client  *http.Client
+
+	// feeds serves the feeds which are distributed to subscribers of the built-in hub.
+	feeds http.Handler
+}
+
+// newWebSub returns a webSub which pings the given external hub, or uses the built-in hub. The built-in hub uses the
+// given client for requests to subscribers' callbacks, which are given by third parties. The external hub is given by
+// the operator, so it's pinged without restrictions.
+func newWebSub(baseURL *url.URL, hub string, builtin bool, client *http.Client) *webSub {
+	if builtin {
+		hub = baseURL.JoinPath("websub").String()
+	} else {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &webSub{hub: hub, builtin: builtin, baseURL: baseURL, client: client}
+}
+
+// topics returns the URLs of the feeds which change when the given note is published.
+func (ws *webSub) topics(note *db.Note) ([]string, error) {
+	tags, err := markdown.Tags(note.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tags for note %s: %w", note.NoteID, err)
+	}
+
+	topics := []string{
+		ws.baseURL.JoinPath("atom.xml").String(),
+		ws.baseURL.JoinPath("rss.xml").String(),
+		ws.baseURL.JoinPath("feed.json").String(),
+	}
+	for _, tag := range tags {
+		topics = append(topics, ws.baseURL.JoinPath("tags", tag, "atom.xml").String())
+	}
+	return topics, nil
+}
+
+// isTopic returns true if the given URL is a feed which can be subscribed to via the built-in hub. The URL must be
+// built the same way as those returned by topics.
+func (ws *webSub) isTopic(topic string) bool {
+	for _, feed := range []string{"atom.xml", "rss.xml", "feed.json"} {
+		if topic == ws.baseURL.JoinPath(feed).String() {
+			return true
+		}
+	}
+
+	tag, ok := strings.CutPrefix(topic, ws.baseURL.JoinPath("tags").String()+"/")
+	tag, ok2 := strings.CutSuffix(tag, "/atom.xml")
+	if !ok || !ok2 {
+		return false
+	}
+
+	tag, err := url.PathUnescape(tag)
+	return err == nil && tag != "" && !strings.Contains(tag, "/") && topic == ws.baseURL.JoinPath("tags", tag, "atom.xml").String()
+}
+
+// webSubHook returns a publish hook which queues notifications of the feeds which a newly-visible note changes. With an
+// external hub, the hub is notified. With the built-in hub, each subscriber is notified.
+func webSubHook(queries *db.Queries, ws *webSub) publishHook {
+	return publishHook{name: "websub", run: func(ctx context.Context, note *db.Note) error {
+		if ws.hub == "" {
+			return nil
+		}
+
+		topics, err := ws.topics(note)
+		if err != nil {
+			return err
+		}
+
+		for _, topic := range topics {
+			targets := []string{ws.hub}
+			if ws.builtin {
+				subs, err := queries.ActiveHubSubscriptions(ctx, topic, sql.NullTime{Time: time.Now(), Valid: true})
+				if err != nil {
+					return fmt.Errorf("failed to retrieve subscriptions to %q: %w", topic, err)
+				}
+
+				targets = nil
+				for _, sub := range subs {
+					targets = append(targets, sub.Callback)
+				}
+			}
+
+			for _, target := range targets {
+				if err := queries.QueueHubNotification(ctx, target, topic, time.Now()); err != nil {
+					return fmt.Errorf("failed to queue hub notification of %q to %q: %w", topic, target, err)
+				}
+			}
+		}
+		return nil
+	}}
+}
+
+// handleHub accepts subscription and unsubscription requests to the built-in hub and queues them for asynchronous
+// verification of intent.
+func handleHub(queries *db.Queries, ws *webSub) appHandler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		mode, callback, topic := r.PostFormValue("hub.mode"), r.PostFormValue("hub.callback"), r.PostFormValue("hub.topic")
+
+		callbackURL, err := url.Parse(callback)
+		if err != nil || (callbackURL.Scheme != "http" && callbackURL.Scheme != "https") || callbackURL.Host == "" {
+			http.Error(w, "Invalid hub.callback.", http.StatusBadRequest)
+			return nil
+		}
+
+		if !ws.isTopic(topic) {
+			http.Error(w, "Invalid hub.topic.", http.StatusBadRequest)
+			return nil
+		}
+
+		switch mode {
+		case "subscribe":
+			secret := r.PostFormValue("hub.secret")
+			if len(secret) > maxHubSecretLength {
+				http.Error(w, "Invalid hub.secret.", http.StatusBadRequest)
+				return nil
+			}
+
+			lease, err := strconv.ParseInt(r.PostFormValue("hub.lease_seconds"), 10, 64)
+			if err != nil || lease <= 0 {
+				lease = defaultLeaseSeconds
+			}
+			lease = min(lease, maxLeaseSeconds)
+
+			if err := queries.CreateHubSubscription(r.Context(), callback, topic, secret, lease, time.Now()); err != nil {
+				return fmt.Errorf("failed to create hub subscription: %w", err)
+			}
+		case "unsubscribe":
+			if err := queries.RequestHubUnsubscription(r.Context(), callback, topic); err != nil {
+				return fmt.Errorf("failed to request hub unsubscription: %w", err)
+			}
+		default:
+			http.Error(w, "Invalid hub.mode.", http.StatusBadRequest)
+			return nil
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	}
+}
+
+// verifyHubSubscriptions verifies the intent of a batch of pending subscription and unsubscription requests by
+// challenging their callbacks. Requests are kept apart from the subscription they apply to until they're verified:
+// verified subscriptions are activated with the requested secret and lease, and verified unsubscriptions are deleted.
+// Requests which fail verification leave an active subscription as it was, and delete any other.
+func verifyHubSubscriptions(ctx context.Context, logger *slog.Logger, queries *db.Queries, ws *webSub) error {
+	pending, err := queries.PendingHubSubscriptions(ctx, hubBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve pending hub subscriptions: %w", err)
+	}
+
+	for _, sub := range pending {
+		err := ws.verify(ctx, &sub)
+
+		switch {
+		case err == nil && sub.PendingMode == "subscribe":
+			expiresAt := sql.NullTime{Time: time.Now().Add(time.Duration(sub.PendingLeaseSeconds) * time.Second), Valid: true}
+			if err := queries.ActivateHubSubscription(ctx, expiresAt, sub.Callback, sub.Topic, sub.PendingSecret, sub.PendingLeaseSeconds); err != nil {
+				return fmt.Errorf("failed to activate hub subscription: %w", err)
+			}
+		case err == nil || sub.Status != "active":
+			if err := queries.DeleteHubSubscription(ctx, sub.Callback, sub.Topic); err != nil {
+				return fmt.Errorf("failed to delete hub subscription: %w", err)
+			}
+		default:
+			if err := queries.ClearHubSubscriptionRequest(ctx, sub.Callback, sub.Topic, sub.PendingMode, sub.PendingSecret, sub.PendingLeaseSeconds); err != nil {
+				return fmt.Errorf("failed to clear hub subscription request: %w", err)
+			}
+		}
+		logger.InfoContext(ctx, "verified hub subscription", "mode", sub.PendingMode, "callback", sub.Callback, "topic", sub.Topic, "err", err)
+	}
+
+	return nil
+}
+
+// verify confirms the subscriber's intent to make its pending request by requesting its callback with a challenge, which
+// it must echo.
+func (ws *webSub) verify(ctx context.Context, sub *db.HubSubscription) (err error) {
+	mode := sub.PendingMode
+	callback, err := url.Parse(sub.Callback)
+	if err != nil {
+		return fmt.Errorf("invalid callback %q: %w", sub.Callback, err)
+	}
+
+	challenge := uuid.NewString()
+	query := callback.Query()
+	query.Set("hub.mode", mode)
+	query.Set("hub.topic", sub.Topic)
+	query.Set("hub.challenge", challenge)
+	if mode == "subscribe" {
+		query.Set("hub.lease_seconds", strconv.FormatInt(sub.PendingLeaseSeconds, 10))
+	}
+	callback.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, callback.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %q: %w", sub.Callback, err)
+	}
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify %q: %w", sub.Callback, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return fmt.Errorf("failed to read response from %q: %w", sub.Callback, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || strings.TrimSpace(string(body)) != challenge {
+		return fmt.Errorf("%q did not confirm %s: %s", sub.Callback, mode, resp.Status)
+	}
+	return nil
+}
+
+// notifyHubs sends a batch of queued hub notifications which are due. Failed notifications are retried with exponential
+// backoff until they've been attempted too many times.
+func notifyHubs(ctx context.Context, logger *slog.Logger, queries *db.Queries, ws *webSub) error {
+	due, err := queries.DueHubNotifications(ctx, time.Now(), hubBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve due hub notifications: %w", err)
+	}
+
+	for _, n := range due {
+		if ws.builtin {
+			err = ws.distribute(ctx, queries, n.Target, n.Topic)
+		} else {
+			err = ws.ping(ctx, n.Target, n.Topic)
+		}
+
+		a := hubRetries.attempt(n.Attempts, n.NextAttemptAt, "notified", err, false)
+
+		if err := queries.UpdateHubNotification(ctx, a.status, a.attempts, a.lastError, a.nextAttemptAt, a.completedAt, n.Target, n.Topic); err != nil {
+			return fmt.Errorf("failed to update hub notification of %q to %q: %w", n.Topic, n.Target, err)
+		}
+		logger.InfoContext(ctx, "sent hub notification", "target", n.Target, "topic", n.Topic, "status", a.status, "err", a.lastError)
+	}
+
+	return nil
+}
+
+// ping notifies an external hub that the topic has been updated.
+func (ws *webSub) ping(ctx context.Context, hub, topic string) (err error) {
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": {topic}, "hub.topic": {topic}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %q: %w", hub, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to ping hub %q: %w", hub, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response pinging hub %q: %s", hub, resp.Status)
+	}
+	return nil
+}
+
+// distribute delivers the current content of the topic to a subscriber of the built-in hub. If the subscription has
+// expired or been removed, nothing is delivered. If the subscriber responds with 410 Gone, the subscription is deleted.
+func (ws *webSub) distribute(ctx context.Context, queries *db.Queries, callback, topic string) (err error) {
+	sub, err := queries.HubSubscription(ctx, callback, topic)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (sub.Status != "active" || sub.ExpiresAt.Time.Before(time.Now()))) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to retrieve hub subscription: %w", err)
+	}
+
+	contentType, body, err := ws.render(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %q: %w", callback, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="hub"`, ws.hub))
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="self"`, topic))
+	if sub.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.Secret))
+		_, _ = mac.Write(body)
+		req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver %q to %q: %w", topic, callback, err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		if err := queries.DeleteHubSubscription(ctx, callback, topic); err != nil {
+			return fmt.Errorf("failed to delete hub subscription: %w", err)
+		}
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("unexpected response delivering %q to %q: %s", topic, callback, resp.Status)
+	default:
+		return nil
+	}
+}
+
+// render returns the content type and body of the topic, rendered in-process.
+func (ws *webSub) render(ctx context.Context, topic string) (string, []byte, error) {
+	u, err := url.Parse(topic)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid topic %q: %w", topic, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, topic, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create request for %q: %w", topic, err)
+	}
+	// The feeds are served relative to the base URL.
+	req.URL.Path = "/" + strings.TrimPrefix(u.Path, ws.baseURL.Path)
+
+	rec := &feedRecorder{header: make(http.Header), status: http.StatusOK}
+	ws.feeds.ServeHTTP(rec, req)
+	if rec.status != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to render %q: %d", topic, rec.status)
+	}
+	return rec.header.Get("Content-Type"), rec.body.Bytes(), nil
+}
+
+// feedRecorder is a response writer which records a feed rendered in-process.
+type feedRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *feedRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *feedRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *feedRecorder) WriteHeader(status int) {
+	r.status = status
+}
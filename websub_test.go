@@ -0,0 +1,290 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codahale/yellhole-go/internal/imgstore"
+	"github.com/google/uuid"
+)
+
+func TestWebSubPing(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	// Stand in for a hub which fails the first ping.
+	var pings []string
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.PostFormValue("hub.mode"), "publish"; got != want {
+			t.Errorf("hub.mode = %q, want = %q", got, want)
+		}
+
+		pings = append(pings, r.PostFormValue("hub.url"))
+		if len(pings) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(hub.Close)
+
+	ws := newWebSub(&url.URL{Scheme: "http", Host: "example.com", Path: "/"}, hub.URL, false, nil)
+	ws.client = hub.Client()
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "Hello, #cats.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if err := announceNotes(t.Context(), logger, app.queries, []publishHook{webSubHook(app.queries, ws)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifyHubs(t.Context(), logger, app.queries, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(pings)
+	if got, want := pings, []string{
+		"http://example.com/atom.xml",
+		"http://example.com/feed.json",
+		"http://example.com/rss.xml",
+		"http://example.com/tags/cats/atom.xml",
+	}; !slices.Equal(got, want) {
+		t.Errorf("pings = %v, want = %v", got, want)
+	}
+
+	// The failed ping is retried later.
+	due, err := app.queries.DueHubNotifications(t.Context(), time.Now().Add(1*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(due), 1; got != want {
+		t.Fatalf("len(due) = %d, want = %d", got, want)
+	}
+
+	if got, want := due[0].LastError, "503"; !strings.Contains(got, want) {
+		t.Errorf("LastError = %q, want = /.*%s.*/", got, want)
+	}
+}
+
+func TestWebSubIsTopic(t *testing.T) {
+	t.Parallel()
+
+	for _, base := range []string{"http://example.com/yell", "http://example.com/yell/"} {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ws := &webSub{baseURL: baseURL}
+		for topic, want := range map[string]bool{
+			"http://example.com/yell/atom.xml":                true,
+			"http://example.com/yell/rss.xml":                 true,
+			"http://example.com/yell/feed.json":               true,
+			"http://example.com/yell/tags/cats/atom.xml":      true,
+			"http://example.com/yell/tags/caf%C3%A9/atom.xml": true,
+			"http://example.com/yell/tags/a/b/atom.xml":       false,
+			"http://example.com/yell/tags//atom.xml":          false,
+			"http://example.com/yell/notes.xml":               false,
+			"http://example.com/atom.xml":                     false,
+			"http://example.com/yellatom.xml":                 false,
+		} {
+			if got := ws.isTopic(topic); got != want {
+				t.Errorf("%s: isTopic(%q) = %v, want = %v", base, topic, got, want)
+			}
+		}
+	}
+}
+
+func TestWebSubHub(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	// Stand in for a subscriber which confirms its subscription, unless told not to, and records the content it's sent.
+	var bodies, signatures []string
+	confirm := true
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if got, want := r.FormValue("hub.topic"), "http://example.com/atom.xml"; got != want {
+				t.Errorf("hub.topic = %q, want = %q", got, want)
+			}
+
+			if !confirm {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = io.WriteString(w, r.FormValue("hub.challenge"))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		signatures = append(signatures, r.Header.Get("X-Hub-Signature"))
+	}))
+	t.Cleanup(subscriber.Close)
+
+	ws := newWebSub(&url.URL{Scheme: "http", Host: "example.com", Path: "/"}, "", true, subscriber.Client())
+	ws.feeds = app
+
+	if got, want := ws.hub, "http://example.com/websub"; got != want {
+		t.Errorf("hub = %q, want = %q", got, want)
+	}
+
+	hub := handleErrors(handleHub(app.queries, ws))
+	subscribe := func(topic, secret string) int {
+		form := url.Values{
+			"hub.mode":     {"subscribe"},
+			"hub.callback": {subscriber.URL + "/callback"},
+			"hub.topic":    {topic},
+			"hub.secret":   {secret},
+		}
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/websub", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		hub.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+
+	if got, want := subscribe("https://elsewhere.example/atom.xml", "shh"), http.StatusBadRequest; got != want {
+		t.Errorf("status = %d, want = %d", got, want)
+	}
+
+	if got, want := subscribe("http://example.com/atom.xml", "shh"), http.StatusAccepted; got != want {
+		t.Fatalf("status = %d, want = %d", got, want)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if err := verifyHubSubscriptions(t.Context(), logger, app.queries, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := app.queries.HubSubscription(t.Context(), subscriber.URL+"/callback", "http://example.com/atom.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sub.Status, "active"; got != want {
+		t.Errorf("Status = %q, want = %q", got, want)
+	}
+
+	if err := app.queries.CreateNote(t.Context(), uuid.NewString(), "", "Hello, subscribers.", time.Now().Add(-1*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := announceNotes(t.Context(), logger, app.queries, []publishHook{webSubHook(app.queries, ws)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifyHubs(t.Context(), logger, app.queries, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(bodies), 1; got != want {
+		t.Fatalf("len(bodies) = %d, want = %d", got, want)
+	}
+
+	if got, want := bodies[0], "Hello, subscribers."; !strings.Contains(got, want) {
+		t.Errorf("body = %q, want = /.*%s.*/", got, want)
+	}
+
+	mac := hmac.New(sha256.New, []byte("shh"))
+	_, _ = mac.Write([]byte(bodies[0]))
+	if got, want := signatures[0], "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("X-Hub-Signature = %q, want = %q", got, want)
+	}
+
+	// An unverified request to change the subscription doesn't affect it.
+	confirm = false
+	if got, want := subscribe("http://example.com/atom.xml", "forged"), http.StatusAccepted; got != want {
+		t.Fatalf("status = %d, want = %d", got, want)
+	}
+
+	if err := verifyHubSubscriptions(t.Context(), logger, app.queries, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err = app.queries.HubSubscription(t.Context(), subscriber.URL+"/callback", "http://example.com/atom.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := sub.Status, "active"; got != want {
+		t.Errorf("Status = %q, want = %q", got, want)
+	}
+
+	if got, want := sub.Secret, "shh"; got != want {
+		t.Errorf("Secret = %q, want = %q", got, want)
+	}
+
+	if got, want := sub.PendingMode, ""; got != want {
+		t.Errorf("PendingMode = %q, want = %q", got, want)
+	}
+}
+
+func TestFeedHubLinks(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+
+	images, err := imgstore.New(app.tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := images.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	baseURL := &url.URL{Scheme: "http", Host: "example.com", Path: "/"}
+	hub := "https://hub.example/"
+	mux := http.NewServeMux()
+	mux.Handle("GET /tags/{tag}/atom.xml", handleErrors(handleTagAtomFeed(app.queries, images, "Test Man", "Test Yell", "Gotta go fast.", baseURL, hub)))
+	mux.Handle("GET /atom.xml", handleErrors(handleAtomFeed(app.queries, images, "Test Man", "Test Yell", "Gotta go fast.", baseURL, hub, false)))
+	mux.Handle("GET /rss.xml", handleErrors(handleRSSFeed(app.queries, images, "Test Man", "Test Yell", "Gotta go fast.", baseURL, hub)))
+	mux.Handle("GET /feed.json", handleErrors(handleJSONFeed(app.queries, images, "Test Man", "Test Yell", "Gotta go fast.", baseURL, hub)))
+
+	for path, want := range map[string][]string{
+		"/atom.xml": {
+			`<link href="http://example.com/atom.xml" rel="self"></link>`,
+			`<link href="https://hub.example/" rel="hub"></link>`,
+		},
+		"/tags/cats/atom.xml": {
+			`<link href="http://example.com/tags/cats/atom.xml" rel="self"></link>`,
+			`<link href="https://hub.example/" rel="hub"></link>`,
+		},
+		"/rss.xml": {
+			`xmlns:atom="http://www.w3.org/2005/Atom"`,
+			`<atom:link href="http://example.com/rss.xml" rel="self" type="application/rss+xml"></atom:link>`,
+			`<atom:link href="https://hub.example/" rel="hub"></atom:link>`,
+		},
+		"/feed.json": {
+			`"hubs":[{"type":"WebSub","url":"https://hub.example/"}]`,
+		},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		body, _ := io.ReadAll(w.Result().Body)
+		for _, want := range want {
+			if got := strings.Join(strings.Fields(string(body)), ""); !strings.Contains(got, strings.Join(strings.Fields(want), "")) {
+				t.Errorf("%s body = %q, want = /.*%s.*/", path, body, want)
+			}
+		}
+	}
+}